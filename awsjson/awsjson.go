@@ -0,0 +1,275 @@
+// Package awsjson encodes and decodes the AWS SDK shapes in the JSON protocol of DynamoDB
+// The unset members are omitted, the blobs are base64 strings and the timestamps are epoch seconds
+package awsjson
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	byteSliceType = reflect.TypeOf([]byte{})
+)
+
+// Marshal returns the JSON of the shape
+func Marshal(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := encode(buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// isUnset reports whether the member is not set and omitted
+func isUnset(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+func encode(buf *bytes.Buffer, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Type() == timeType:
+		ms := v.Interface().(time.Time).UnixNano() / int64(time.Millisecond)
+		buf.WriteString(strconv.FormatFloat(float64(ms)/1e3, 'f', -1, 64))
+		return nil
+	case v.Type() == byteSliceType:
+		return encodeString(buf, base64.StdEncoding.EncodeToString(v.Bytes()))
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		buf.WriteByte('{')
+		t := v.Type()
+		first := true
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if skipped(field) || isUnset(v.Field(i)) {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			if err := encodeString(buf, memberName(field)); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := encode(buf, v.Field(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case reflect.Slice:
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encode(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeString(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := encode(buf, v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key()))); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case reflect.String:
+		return encodeString(buf, v.String())
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Float64:
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Errorf("Unsupported float value %v", f)
+		}
+		buf.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+	default:
+		return fmt.Errorf("Unsupported value of the type %s", v.Type())
+	}
+	return nil
+}
+
+// encodeString writes the JSON string without escaping the HTML characters
+func encodeString(buf *bytes.Buffer, s string) error {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return err
+	}
+	// Encode terminates the value with a newline
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// skipped reports whether the member is not in the JSON body like the response metadata of the errors
+func skipped(field reflect.StructField) bool {
+	return field.PkgPath != "" || field.Tag.Get("json") == "-" || field.Tag.Get("location") != ""
+}
+
+func memberName(field reflect.StructField) string {
+	if name := field.Tag.Get("locationName"); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// Unmarshal decodes the JSON into the shape which v points to
+// The members missing in the JSON are left unchanged
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("Unmarshal requires a non nil pointer, got %T", v)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return err
+	}
+	return decode(rv.Elem(), tree)
+}
+
+func decode(v reflect.Value, data interface{}) error {
+	if data == nil {
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decode(v.Elem(), data)
+	}
+	switch {
+	case v.Type() == timeType:
+		n, ok := data.(json.Number)
+		if !ok {
+			return fmt.Errorf("Timestamp must be a number, got %v", data)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return err
+		}
+		sec, frac := math.Modf(f)
+		v.Set(reflect.ValueOf(time.Unix(int64(sec), int64(math.Round(frac*1e3))*int64(time.Millisecond)).UTC()))
+		return nil
+	case v.Type() == byteSliceType:
+		s, ok := data.(string)
+		if !ok {
+			return fmt.Errorf("Blob must be a base64 string, got %v", data)
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(b)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("JSON value is not a structure, got %v", data)
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if skipped(field) {
+				continue
+			}
+			if err := decode(v.Field(i), m[memberName(field)]); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		l, ok := data.([]interface{})
+		if !ok {
+			return fmt.Errorf("JSON value is not a list, got %v", data)
+		}
+		s := reflect.MakeSlice(v.Type(), len(l), len(l))
+		for i, e := range l {
+			if err := decode(s.Index(i), e); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("JSON value is not a map, got %v", data)
+		}
+		mv := reflect.MakeMapWithSize(v.Type(), len(m))
+		for k, e := range m {
+			ev := reflect.New(v.Type().Elem()).Elem()
+			if err := decode(ev, e); err != nil {
+				return err
+			}
+			mv.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), ev)
+		}
+		v.Set(mv)
+	case reflect.String:
+		s, ok := data.(string)
+		if !ok {
+			return fmt.Errorf("JSON value is not a string, got %v", data)
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, ok := data.(bool)
+		if !ok {
+			return fmt.Errorf("JSON value is not a boolean, got %v", data)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		n, ok := data.(json.Number)
+		if !ok {
+			return fmt.Errorf("JSON value is not a number, got %v", data)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return err
+		}
+		if v.Kind() == reflect.Float64 {
+			v.SetFloat(f)
+		} else {
+			v.SetInt(int64(f))
+		}
+	default:
+		return fmt.Errorf("Unsupported value of the type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package awsjson
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestMarshal(t *testing.T) {
+	testCases := []struct {
+		v        interface{}
+		expected string
+	}{
+		{
+			v: &dynamodb.PutRequest{Item: map[string]*dynamodb.AttributeValue{
+				"name": {S: aws.String("<a & b>")},
+				"id":   {N: aws.String("1")},
+				"data": {B: []byte("hi")},
+				"tags": {L: []*dynamodb.AttributeValue{{BOOL: aws.Bool(true)}, {NULL: aws.Bool(true)}}},
+			}},
+			expected: `{"Item":{"data":{"B":"aGk="},"id":{"N":"1"},"name":{"S":"<a & b>"},"tags":{"L":[{"BOOL":true},{"NULL":true}]}}}`,
+		},
+		{
+			v: &dynamodb.TableDescription{
+				TableName:        aws.String("user"),
+				ItemCount:        aws.Int64(3),
+				CreationDateTime: aws.Time(time.Unix(1600000000, 250*int64(time.Millisecond))),
+				ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{
+					ReadCapacityUnits: aws.Int64(5),
+				},
+			},
+			expected: `{"CreationDateTime":1600000000.25,"ItemCount":3,"ProvisionedThroughput":{"ReadCapacityUnits":5},"TableName":"user"}`,
+		},
+		{
+			v:        &dynamodb.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)},
+			expected: `{"CapacityUnits":0.5}`,
+		},
+		{
+			// The response metadata is not a member of the body
+			v: &dynamodb.TransactionCanceledException{
+				CancellationReasons: []*dynamodb.CancellationReason{{Code: aws.String("None")}},
+				Message_:            aws.String("canceled"),
+			},
+			expected: `{"CancellationReasons":[{"Code":"None"}],"Message":"canceled"}`,
+		},
+	}
+	for i, tc := range testCases {
+		b, err := Marshal(tc.v)
+		if err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if string(b) != tc.expected {
+			t.Errorf("[%d] Expecting %s, got %s\n", i+1, tc.expected, string(b))
+		}
+		// The shapes are the same after the round trip
+		v := reflect.New(reflect.TypeOf(tc.v).Elem())
+		if err := Unmarshal(b, v.Interface()); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if got, _ := Marshal(v.Interface()); string(got) != tc.expected {
+			t.Errorf("[%d] Expecting %s after the round trip, got %s\n", i+1, tc.expected, string(got))
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	testCases := []struct {
+		data string
+		v    interface{}
+	}{
+		{data: `{"Item":{"id":{"B":"!"}}}`, v: &dynamodb.PutRequest{}},
+		{data: `{"ItemCount":"3"}`, v: &dynamodb.TableDescription{}},
+		{data: `{"Item":[]}`, v: &dynamodb.PutRequest{}},
+		{data: `{`, v: &dynamodb.PutRequest{}},
+		{data: `{}`, v: dynamodb.PutRequest{}},
+	}
+	for i, tc := range testCases {
+		if err := Unmarshal([]byte(tc.data), tc.v); err == nil {
+			t.Errorf("[%d] Expecting the error of %s\n", i+1, tc.data)
+		}
+	}
+}
@@ -7,3 +7,11 @@ func Min(a, b int64) int64 {
 	}
 	return b
 }
+
+// Max returns a larger value
+func Max(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -17,3 +17,19 @@ func TestMin(t *testing.T) {
 		}
 	}
 }
+
+func TestMax(t *testing.T) {
+	testCases := []struct {
+		a        int64
+		b        int64
+		expected int64
+	}{
+		{a: 1, b: 4, expected: 4},
+		{a: 3, b: 2, expected: 3},
+	}
+	for i, tc := range testCases {
+		if s := Max(tc.a, tc.b); s != tc.expected {
+			t.Errorf("[%d] Expecting %v, got %v", i+1, tc.expected, s)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package calc
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	// MaxItemSize is the maximum size of an item in bytes
+	MaxItemSize = 400 * 1024
+
+	containerOverhead = 3
+	elementOverhead   = 1
+)
+
+// ItemSize returns the size of the item in bytes following the dynamodb item size rules
+func ItemSize(item map[string]*dynamodb.AttributeValue) int64 {
+	size := int64(0)
+	for name, v := range item {
+		size += int64(len(name)) + AttributeSize(v)
+	}
+	return size
+}
+
+// AttributeSize returns the size of the attribute value in bytes excluding its name
+func AttributeSize(v *dynamodb.AttributeValue) int64 {
+	if v == nil {
+		return 0
+	}
+	switch {
+	case v.S != nil:
+		return int64(len(*v.S))
+	case v.N != nil:
+		return numberSize(*v.N)
+	case v.B != nil:
+		return int64(len(v.B))
+	case v.BOOL != nil, v.NULL != nil:
+		return 1
+	case v.SS != nil:
+		size := int64(0)
+		for _, s := range v.SS {
+			size += int64(len(*s))
+		}
+		return size
+	case v.NS != nil:
+		size := int64(0)
+		for _, n := range v.NS {
+			size += numberSize(*n)
+		}
+		return size
+	case v.BS != nil:
+		size := int64(0)
+		for _, b := range v.BS {
+			size += int64(len(b))
+		}
+		return size
+	case v.M != nil:
+		size := int64(containerOverhead)
+		for name, e := range v.M {
+			size += int64(len(name)) + AttributeSize(e) + elementOverhead
+		}
+		return size
+	case v.L != nil:
+		size := int64(containerOverhead)
+		for _, e := range v.L {
+			size += AttributeSize(e) + elementOverhead
+		}
+		return size
+	}
+	return 0
+}
+
+// numberSize returns 1 byte per two significant digits plus 1 byte
+func numberSize(n string) int64 {
+	n = strings.TrimLeft(n, "+-")
+	if i := strings.IndexAny(n, "eE"); i >= 0 {
+		n = n[:i]
+	}
+	digits := strings.Replace(n, ".", "", 1)
+	digits = strings.TrimLeft(digits, "0")
+	digits = strings.TrimRight(digits, "0")
+	return int64(len(digits)+1)/2 + 1
+}
@@ -0,0 +1,52 @@
+package calc
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestItemSize(t *testing.T) {
+	testCases := []struct {
+		item     map[string]*dynamodb.AttributeValue
+		expected int64
+	}{
+		{
+			item:     map[string]*dynamodb.AttributeValue{"id": {S: aws.String("abc")}},
+			expected: 5,
+		},
+		{
+			item:     map[string]*dynamodb.AttributeValue{"n": {N: aws.String("12345")}},
+			expected: 5,
+		},
+		{
+			item:     map[string]*dynamodb.AttributeValue{"n": {N: aws.String("-0.0012300")}},
+			expected: 4,
+		},
+		{
+			item:     map[string]*dynamodb.AttributeValue{"ok": {BOOL: aws.Bool(true)}, "no": {NULL: aws.Bool(true)}},
+			expected: 6,
+		},
+		{
+			item:     map[string]*dynamodb.AttributeValue{"ss": {SS: aws.StringSlice([]string{"a", "bc"})}},
+			expected: 5,
+		},
+		{
+			item: map[string]*dynamodb.AttributeValue{"m": {M: map[string]*dynamodb.AttributeValue{
+				"a": {S: aws.String("xy")},
+				"l": {L: []*dynamodb.AttributeValue{{B: []byte{1, 2}}}},
+			}}},
+			expected: 1 + 3 + (1 + 2 + 1) + (1 + (3 + 2 + 1) + 1),
+		},
+		{
+			item:     map[string]*dynamodb.AttributeValue{"e": {L: []*dynamodb.AttributeValue{}}},
+			expected: 4,
+		},
+	}
+	for i, tc := range testCases {
+		if s := ItemSize(tc.item); s != tc.expected {
+			t.Errorf("[%d] Expecting %v, got %v", i+1, tc.expected, s)
+		}
+	}
+}
@@ -1,12 +1,21 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
 	"github.com/mingrammer/cfmt"
 	"github.com/mingrammer/dynamodb-toolkit/config"
+	"github.com/mingrammer/dynamodb-toolkit/server"
 	"github.com/mingrammer/dynamodb-toolkit/service"
 	"github.com/mingrammer/dynamodb-toolkit/toolkit"
 	"github.com/urfave/cli"
@@ -34,6 +43,22 @@ func main() {
 	app.Before = buildBeforeFunc()
 	app.Commands = []cli.Command{
 		buildTruncateCommand(),
+		buildImportCommand(),
+		buildDumpCommand(),
+		buildRestoreCommand(),
+		buildCopyCommand(),
+		buildBackfillCommand(),
+		buildServeCommand(),
+		buildTailCommand(),
+		buildReplayCommand(),
+		buildScanCommand(),
+		buildQueryCommand(),
+		buildItemCommand(),
+		buildSQLCommand(),
+		buildBrowseCommand(),
+		buildCountCommand(),
+		buildStatsCommand(),
+		buildInferSchemaCommand(),
 	}
 	err := app.Run(os.Args)
 	if err != nil {
@@ -121,3 +146,1045 @@ func buildTruncateCommand() cli.Command {
 	}
 	return cmd
 }
+
+func buildImportCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "import",
+		Usage: "import the dynamodb export to s3 from the local directory",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "export-dir",
+				Usage: "directory of the downloaded export which contains the manifest files",
+			},
+			cli.StringFlag{
+				Name:  "table-name",
+				Usage: "table name which the items will be imported into",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			dir := ctx.String("export-dir")
+			if len(dir) == 0 {
+				return errors.New(cfmt.Serror("You must pass the export directory"))
+			}
+			table := ctx.String("table-name")
+			if len(table) == 0 {
+				return errors.New(cfmt.Serror("You must pass the table name"))
+			}
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			importer := toolkit.NewImporter(client)
+			if err := importer.Import(dir, table); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildDumpCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "dump",
+		Usage: "dump the dynamodb table into the part files with a manifest",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "table-name",
+				Usage: "table name which will be dumped",
+			},
+			cli.StringFlag{
+				Name:  "output-dir",
+				Usage: "directory which the part files and manifest will be written into",
+			},
+			cli.StringFlag{
+				Name:  "compression",
+				Usage: "compression of the part files. One of none, gzip and zstd",
+				Value: toolkit.CompressionGzip,
+			},
+			cli.Int64Flag{
+				Name:  "part-items",
+				Usage: "rotate to a new part file every N items. 0 means unlimited",
+			},
+			cli.Int64Flag{
+				Name:  "part-bytes",
+				Usage: "rotate to a new part file every N uncompressed bytes. 0 means unlimited",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			table := ctx.String("table-name")
+			if len(table) == 0 {
+				return errors.New(cfmt.Serror("You must pass the table name"))
+			}
+			dir := ctx.String("output-dir")
+			if len(dir) == 0 {
+				return errors.New(cfmt.Serror("You must pass the output directory"))
+			}
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			dumper := toolkit.NewDumper(client)
+			opts := toolkit.DumpOptions{
+				Compression: ctx.String("compression"),
+				PartItems:   ctx.Int64("part-items"),
+				PartBytes:   ctx.Int64("part-bytes"),
+			}
+			if err := dumper.Dump(table, dir, opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildRestoreCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "restore",
+		Usage: "restore the dump parts into the dynamodb table",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "input-dir",
+				Usage: "directory of the dump which contains the manifest",
+			},
+			cli.StringFlag{
+				Name:  "table-name",
+				Usage: "table name which the items will be restored into",
+			},
+			cli.IntFlag{
+				Name:  "workers",
+				Usage: "number of parts restored concurrently",
+				Value: 4,
+			},
+			cli.Float64Flag{
+				Name:  "wcu",
+				Usage: "write capacity units per second used by the restore. 0 means unlimited",
+			},
+			cli.StringFlag{
+				Name:  "transform",
+				Usage: "transform rules file applied to the items before the write",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			dir := ctx.String("input-dir")
+			if len(dir) == 0 {
+				return errors.New(cfmt.Serror("You must pass the input directory"))
+			}
+			table := ctx.String("table-name")
+			if len(table) == 0 {
+				return errors.New(cfmt.Serror("You must pass the table name"))
+			}
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			transformer, err := loadTransformer(ctx.String("transform"))
+			if err != nil {
+				return err
+			}
+			restorer := toolkit.NewRestorer(client)
+			opts := toolkit.RestoreOptions{
+				Workers:     ctx.Int("workers"),
+				WCU:         ctx.Float64("wcu"),
+				Transformer: transformer,
+			}
+			if err := restorer.Restore(dir, table, opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildCopyCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "copy",
+		Usage: "copy the items of the dynamodb table into another table",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "source-table",
+				Usage: "table name which the items will be copied from",
+			},
+			cli.StringFlag{
+				Name:  "target-table",
+				Usage: "table name which the items will be copied into",
+			},
+			cli.StringFlag{
+				Name:  "target-profile",
+				Usage: "aws credential profile of the target table. Defaults to the global one",
+			},
+			cli.StringFlag{
+				Name:  "target-region",
+				Usage: "dynamodb region of the target table. Defaults to the global one",
+			},
+			cli.StringFlag{
+				Name:  "target-endpoint",
+				Usage: "dynamodb endpoint of the target table. Defaults to the global one",
+			},
+			cli.IntFlag{
+				Name:  "workers",
+				Usage: "number of concurrent writers",
+				Value: 4,
+			},
+			cli.Float64Flag{
+				Name:  "wcu",
+				Usage: "write capacity units per second used by the copy. 0 means unlimited",
+			},
+			cli.StringFlag{
+				Name:  "transform",
+				Usage: "transform rules file applied to the items before the write",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			source := ctx.String("source-table")
+			target := ctx.String("target-table")
+			if len(source) == 0 || len(target) == 0 {
+				return errors.New(cfmt.Serror("You must pass the source and target table names"))
+			}
+			sourceClient, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			targetClient, err := service.NewTargetDynamoDBClient(
+				ctx.String("target-profile"),
+				ctx.String("target-region"),
+				ctx.String("target-endpoint"),
+			)
+			if err != nil {
+				return err
+			}
+			transformer, err := loadTransformer(ctx.String("transform"))
+			if err != nil {
+				return err
+			}
+			copier := toolkit.NewCopier(sourceClient, targetClient)
+			opts := toolkit.CopyOptions{
+				Workers:     ctx.Int("workers"),
+				WCU:         ctx.Float64("wcu"),
+				Transformer: transformer,
+			}
+			if err := copier.Copy(source, target, opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func loadTransformer(name string) (toolkit.ItemTransformer, error) {
+	if len(name) == 0 {
+		return nil, nil
+	}
+	transformer, err := toolkit.LoadTransformer(name)
+	if err != nil {
+		return nil, errors.New(cfmt.Serror(err.Error()))
+	}
+	return transformer, nil
+}
+
+func buildBackfillCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "backfill",
+		Usage: "rewrite the items of the dynamodb table in place through the transform rules",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "table-name",
+				Usage: "table name which will be backfilled",
+			},
+			cli.StringFlag{
+				Name:  "transform",
+				Usage: "transform rules file applied to the items, which must keep the keys",
+			},
+			cli.IntFlag{
+				Name:  "workers",
+				Usage: "number of concurrent writers",
+				Value: 4,
+			},
+			cli.Float64Flag{
+				Name:  "wcu",
+				Usage: "write capacity units per second used by the backfill. 0 means unlimited",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			table := ctx.String("table-name")
+			if len(table) == 0 {
+				return errors.New(cfmt.Serror("You must pass the table name"))
+			}
+			if len(ctx.String("transform")) == 0 {
+				return errors.New(cfmt.Serror("You must pass the transform rules file"))
+			}
+			transformer, err := loadTransformer(ctx.String("transform"))
+			if err != nil {
+				return err
+			}
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			backfiller := toolkit.NewBackfiller(client)
+			opts := toolkit.CopyOptions{
+				Workers:     ctx.Int("workers"),
+				WCU:         ctx.Float64("wcu"),
+				Transformer: transformer,
+			}
+			if err := backfiller.Backfill(table, opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildServeCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "serve",
+		Usage: "serve the in-memory dynamodb over HTTP for the local development",
+		Flags: []cli.Flag{
+			cli.IntFlag{
+				Name:  "port",
+				Usage: "port to listen on",
+				Value: 8000,
+			},
+			cli.StringFlag{
+				Name:  "data-dir",
+				Usage: "directory where the tables are loaded from and saved into. The data is lost on exit if not set",
+			},
+			cli.DurationFlag{
+				Name:  "snapshot-interval",
+				Usage: "interval of saving the tables into the data directory. 0 means only on shutdown",
+				Value: time.Minute,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			opts := server.Options{
+				Port:             ctx.Int("port"),
+				DataDir:          ctx.String("data-dir"),
+				SnapshotInterval: ctx.Duration("snapshot-interval"),
+			}
+			if err := server.Serve(opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildTailCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "tail",
+		Usage: "follow the records of the dynamodb stream of the table",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "table-name",
+				Usage: "table name whose latest stream will be followed",
+			},
+			cli.StringFlag{
+				Name:  "from",
+				Usage: "starting position. trim-horizon, latest, an RFC3339 timestamp or a duration ago like 10m",
+				Value: toolkit.TailFromLatest,
+			},
+			cli.StringFlag{
+				Name:  "event-types",
+				Usage: "comma delimited event types to print among INSERT, MODIFY and REMOVE. All if not set",
+			},
+			cli.StringSliceFlag{
+				Name:  "key",
+				Usage: "key attribute value which the records must have as name=value. It can be repeated",
+			},
+			cli.BoolFlag{
+				Name:  "json",
+				Usage: "print the records as JSON lines",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			table := ctx.String("table-name")
+			if len(table) == 0 {
+				return errors.New(cfmt.Serror("You must pass the table name"))
+			}
+			opts := toolkit.TailOptions{
+				From: ctx.String("from"),
+				Keys: map[string]string{},
+				JSON: ctx.Bool("json"),
+			}
+			if eventTypes := ctx.String("event-types"); len(eventTypes) > 0 {
+				opts.EventNames = strings.Split(eventTypes, ",")
+			}
+			for _, kv := range ctx.StringSlice("key") {
+				parts := strings.SplitN(kv, "=", 2)
+				if len(parts) != 2 {
+					return errors.New(cfmt.Serrorf("Invalid key '%s', it must be name=value", kv))
+				}
+				opts.Keys[parts[0]] = parts[1]
+			}
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			streams, err := service.NewDynamoDBStreamsClient()
+			if err != nil {
+				return err
+			}
+
+			// Stop following on interrupt
+			c, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			sigc := make(chan os.Signal, 1)
+			signal.Notify(sigc, os.Interrupt)
+			defer signal.Stop(sigc)
+			go func() {
+				select {
+				case <-sigc:
+					cancel()
+				case <-c.Done():
+				}
+			}()
+			tailer := toolkit.NewTailer(client, streams)
+			if err := tailer.Tail(c, table, opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildReplayCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "replay",
+		Usage: "apply the stream records of a table or a tail capture to another table",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "source-table",
+				Usage: "table name whose latest stream will be replayed",
+			},
+			cli.StringFlag{
+				Name:  "input",
+				Usage: "file of the stream records captured by the tail with --json. It is used instead of the source table",
+			},
+			cli.StringFlag{
+				Name:  "target-table",
+				Usage: "table name which the records will be applied to",
+			},
+			cli.StringFlag{
+				Name:  "target-profile",
+				Usage: "aws credential profile of the target table. Defaults to the global one",
+			},
+			cli.StringFlag{
+				Name:  "target-region",
+				Usage: "dynamodb region of the target table. Defaults to the global one",
+			},
+			cli.StringFlag{
+				Name:  "target-endpoint",
+				Usage: "dynamodb endpoint of the target table. Defaults to the global one",
+			},
+			cli.StringFlag{
+				Name:  "since",
+				Usage: "RFC3339 time of the first records to replay. All records if not set",
+			},
+			cli.StringFlag{
+				Name:  "until",
+				Usage: "RFC3339 time of the last records to replay. All records if not set",
+			},
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "print the differences against the target table without writing",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			source := ctx.String("source-table")
+			input := ctx.String("input")
+			target := ctx.String("target-table")
+			if (len(source) == 0) == (len(input) == 0) || len(target) == 0 {
+				return errors.New(cfmt.Serror("You must pass either the source table or the input file, and the target table name"))
+			}
+			opts := toolkit.ReplayOptions{DryRun: ctx.Bool("dry-run")}
+			for _, t := range []struct {
+				name  string
+				value *time.Time
+			}{
+				{name: "since", value: &opts.Since},
+				{name: "until", value: &opts.Until},
+			} {
+				if v := ctx.String(t.name); len(v) > 0 {
+					parsed, err := time.Parse(time.RFC3339, v)
+					if err != nil {
+						return errors.New(cfmt.Serrorf("Invalid %s time '%s', it must be RFC3339", t.name, v))
+					}
+					*t.value = parsed
+				}
+			}
+
+			var records []*dynamodbstreams.Record
+			if len(input) > 0 {
+				loaded, err := toolkit.LoadRecords(input)
+				if err != nil {
+					return errors.New(cfmt.Serror(err.Error()))
+				}
+				records = loaded
+			} else {
+				client, err := service.NewDynamoDBClient()
+				if err != nil {
+					return err
+				}
+				streams, err := service.NewDynamoDBStreamsClient()
+				if err != nil {
+					return err
+				}
+				read, err := toolkit.NewTailer(client, streams).Records(context.Background(), source)
+				if err != nil {
+					return errors.New(cfmt.Serror(err.Error()))
+				}
+				records = read
+			}
+			targetClient, err := service.NewTargetDynamoDBClient(
+				ctx.String("target-profile"),
+				ctx.String("target-region"),
+				ctx.String("target-endpoint"),
+			)
+			if err != nil {
+				return err
+			}
+			if err := toolkit.NewReplayer(targetClient).Replay(target, records, opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+// findFlags returns the flags shared by the scan and query
+func findFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "table-name",
+			Usage: "table name which will be read",
+		},
+		cli.StringFlag{
+			Name:  "index",
+			Usage: "secondary index name which will be read instead of the table",
+		},
+		cli.StringFlag{
+			Name:  "filter",
+			Usage: "filter expression applied to the read items",
+		},
+		cli.StringFlag{
+			Name:  "projection",
+			Usage: "projection expression of the attributes to print. All attributes if not set",
+		},
+		cli.StringFlag{
+			Name:  "names",
+			Usage: "expression attribute names as a JSON object like {\"#n\": \"name\"}",
+		},
+		cli.StringFlag{
+			Name:  "values",
+			Usage: "expression attribute values as a plain JSON object like {\":id\": 1}",
+		},
+		cli.Int64Flag{
+			Name:  "limit",
+			Usage: "maximum number of the items to print. 0 means unlimited",
+			Value: 100,
+		},
+		cli.StringFlag{
+			Name:  "output",
+			Usage: "output format of the items. One of table, dynamodb and json",
+			Value: toolkit.FormatTable,
+		},
+	}
+}
+
+// findOptions returns the find options of the flags shared by the scan and query
+func findOptions(ctx *cli.Context) (toolkit.FindOptions, error) {
+	opts := toolkit.FindOptions{
+		Index:      ctx.String("index"),
+		Filter:     ctx.String("filter"),
+		Projection: ctx.String("projection"),
+		Limit:      ctx.Int64("limit"),
+		Format:     ctx.String("output"),
+	}
+	names, values, err := expressionAttributes(ctx)
+	if err != nil {
+		return opts, err
+	}
+	opts.Names, opts.Values = names, values
+	return opts, nil
+}
+
+// expressionAttributes returns the expression attribute names and values of the JSON flags
+func expressionAttributes(ctx *cli.Context) (map[string]*string, map[string]*dynamodb.AttributeValue, error) {
+	var names map[string]*string
+	var values map[string]*dynamodb.AttributeValue
+	if s := ctx.String("names"); len(s) > 0 {
+		m := map[string]string{}
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			return nil, nil, errors.New(cfmt.Serrorf("Invalid expression attribute names '%s', got %s", s, err.Error()))
+		}
+		names = aws.StringMap(m)
+	}
+	if s := ctx.String("values"); len(s) > 0 {
+		v, err := toolkit.ParsePlainJSON(s)
+		if err != nil {
+			return nil, nil, errors.New(cfmt.Serror(err.Error()))
+		}
+		values = v
+	}
+	return names, values, nil
+}
+
+func buildScanCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "scan",
+		Usage: "print the items of the dynamodb table or index",
+		Flags: findFlags(),
+		Action: func(ctx *cli.Context) error {
+			table := ctx.String("table-name")
+			if len(table) == 0 {
+				return errors.New(cfmt.Serror("You must pass the table name"))
+			}
+			opts, err := findOptions(ctx)
+			if err != nil {
+				return err
+			}
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			finder := toolkit.NewFinder(client)
+			if err := finder.Scan(table, opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildQueryCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "query",
+		Usage: "print the items of the dynamodb table or index matching the key condition",
+		Flags: append(findFlags(),
+			cli.StringFlag{
+				Name:  "key-condition",
+				Usage: "key condition expression of the query",
+			},
+			cli.BoolFlag{
+				Name:  "descending",
+				Usage: "print the items in the descending order of the sort key",
+			},
+		),
+		Action: func(ctx *cli.Context) error {
+			table := ctx.String("table-name")
+			if len(table) == 0 || len(ctx.String("key-condition")) == 0 {
+				return errors.New(cfmt.Serror("You must pass the table name and key condition"))
+			}
+			opts, err := findOptions(ctx)
+			if err != nil {
+				return err
+			}
+			opts.KeyCondition = ctx.String("key-condition")
+			opts.Descending = ctx.Bool("descending")
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			finder := toolkit.NewFinder(client)
+			if err := finder.Query(table, opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildItemCommand() cli.Command {
+	tableFlag := cli.StringFlag{
+		Name:  "table-name",
+		Usage: "table name of the item",
+	}
+	keyFlag := cli.StringFlag{
+		Name:  "key",
+		Usage: "primary key of the item as a plain JSON object like {\"id\": 1}",
+	}
+	conditionFlags := []cli.Flag{
+		cli.StringFlag{
+			Name:  "condition",
+			Usage: "condition expression which the existing item must satisfy",
+		},
+		cli.StringFlag{
+			Name:  "names",
+			Usage: "expression attribute names as a JSON object like {\"#n\": \"name\"}",
+		},
+		cli.StringFlag{
+			Name:  "values",
+			Usage: "expression attribute values as a plain JSON object like {\":id\": 1}",
+		},
+	}
+	// run parses the plain JSON of the flag and the shared options, and runs the editor operation
+	run := func(ctx *cli.Context, jsonFlag string, fn func(editor *toolkit.Editor, table string, v map[string]*dynamodb.AttributeValue, opts toolkit.ItemOptions) error) error {
+		table := ctx.String("table-name")
+		if len(table) == 0 || len(ctx.String(jsonFlag)) == 0 {
+			return errors.New(cfmt.Serrorf("You must pass the table name and %s", jsonFlag))
+		}
+		v, err := toolkit.ParsePlainJSON(ctx.String(jsonFlag))
+		if err != nil {
+			return errors.New(cfmt.Serror(err.Error()))
+		}
+		names, values, err := expressionAttributes(ctx)
+		if err != nil {
+			return err
+		}
+		opts := toolkit.ItemOptions{
+			Condition:      ctx.String("condition"),
+			Update:         ctx.String("update"),
+			Projection:     ctx.String("projection"),
+			Names:          names,
+			Values:         values,
+			ReturnValues:   ctx.String("return-values"),
+			ConsistentRead: ctx.Bool("consistent-read"),
+		}
+		client, err := service.NewDynamoDBClient()
+		if err != nil {
+			return err
+		}
+		if err := fn(toolkit.NewEditor(client), table, v, opts); err != nil {
+			return errors.New(cfmt.Serror(err.Error()))
+		}
+		return nil
+	}
+
+	cmd := cli.Command{
+		Name:  "item",
+		Usage: "get, put, update or delete a single item of the dynamodb table",
+		Subcommands: []cli.Command{
+			{
+				Name:  "get",
+				Usage: "print the item of the key",
+				Flags: []cli.Flag{
+					tableFlag,
+					keyFlag,
+					cli.StringFlag{
+						Name:  "projection",
+						Usage: "projection expression of the attributes to print. All attributes if not set",
+					},
+					cli.StringFlag{
+						Name:  "names",
+						Usage: "expression attribute names as a JSON object like {\"#n\": \"name\"}",
+					},
+					cli.BoolFlag{
+						Name:  "consistent-read",
+						Usage: "read the item with the strongly consistent read",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					return run(ctx, "key", (*toolkit.Editor).Get)
+				},
+			},
+			{
+				Name:  "put",
+				Usage: "write the item, replacing the existing one, and print the old and new items",
+				Flags: append([]cli.Flag{
+					tableFlag,
+					cli.StringFlag{
+						Name:  "item",
+						Usage: "item as a plain JSON object including the key attributes",
+					},
+				}, conditionFlags...),
+				Action: func(ctx *cli.Context) error {
+					return run(ctx, "item", (*toolkit.Editor).Put)
+				},
+			},
+			{
+				Name:  "update",
+				Usage: "update the item of the key by the update expression and print the returned values",
+				Flags: append([]cli.Flag{
+					tableFlag,
+					keyFlag,
+					cli.StringFlag{
+						Name:  "update",
+						Usage: "update expression applied to the item",
+					},
+					cli.StringFlag{
+						Name:  "return-values",
+						Usage: "values to print. One of ALL_NEW, UPDATED_NEW, ALL_OLD and UPDATED_OLD",
+						Value: "ALL_NEW",
+					},
+				}, conditionFlags...),
+				Action: func(ctx *cli.Context) error {
+					return run(ctx, "key", (*toolkit.Editor).Update)
+				},
+			},
+			{
+				Name:  "delete",
+				Usage: "delete the item of the key and print the deleted item",
+				Flags: append([]cli.Flag{tableFlag, keyFlag}, conditionFlags...),
+				Action: func(ctx *cli.Context) error {
+					return run(ctx, "key", (*toolkit.Editor).Delete)
+				},
+			},
+		},
+	}
+	return cmd
+}
+
+func buildSQLCommand() cli.Command {
+	cmd := cli.Command{
+		Name:      "sql",
+		Usage:     "execute the PartiQL statements, or start the interactive shell without the statements",
+		ArgsUsage: "[statements...]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "parameters",
+				Usage: "values of the ? placeholders of a single statement as a plain JSON array like [1, \"name\"]",
+			},
+			cli.BoolFlag{
+				Name:  "consistent-read",
+				Usage: "read the items with the strongly consistent read",
+			},
+			cli.Int64Flag{
+				Name:  "limit",
+				Usage: "maximum number of the items to print. 0 means unlimited",
+				Value: 100,
+			},
+			cli.StringFlag{
+				Name:  "output",
+				Usage: "output format of the items. One of table, dynamodb and json",
+				Value: toolkit.FormatTable,
+			},
+			cli.StringFlag{
+				Name:  "history",
+				Usage: "file of the history of the interactive shell",
+				Value: filepath.Join(os.Getenv("HOME"), ".dynamotk_history"),
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			opts := toolkit.SQLOptions{
+				ConsistentRead: ctx.Bool("consistent-read"),
+				Limit:          ctx.Int64("limit"),
+				Format:         ctx.String("output"),
+			}
+			if s := ctx.String("parameters"); len(s) > 0 {
+				params, err := toolkit.ParsePlainJSONList(s)
+				if err != nil {
+					return errors.New(cfmt.Serror(err.Error()))
+				}
+				opts.Parameters = params
+			}
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			runner := toolkit.NewSQLRunner(client)
+			statements := []string(ctx.Args())
+			switch len(statements) {
+			case 0:
+				err = runner.REPL(ctx.String("history"), opts)
+			case 1:
+				err = runner.Execute(statements[0], opts)
+			default:
+				err = runner.ExecuteBatch(statements, opts)
+			}
+			if err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildBrowseCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "browse",
+		Usage: "browse the tables and the items in the terminal UI",
+		Flags: []cli.Flag{
+			cli.Int64Flag{
+				Name:  "page-size",
+				Usage: "number of the items of a page",
+				Value: 50,
+			},
+			cli.BoolFlag{
+				Name:  "read-only",
+				Usage: "disable editing and deleting the items",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			opts := toolkit.BrowseOptions{
+				PageSize: ctx.Int64("page-size"),
+				ReadOnly: ctx.Bool("read-only"),
+			}
+			if err := toolkit.NewBrowser(client).Browse(opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildCountCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "count",
+		Usage: "count the items of the dynamodb tables exactly with the parallel scans",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "table-names",
+				Usage: "comma delimited table names which will be counted",
+			},
+			cli.StringFlag{
+				Name:  "index",
+				Usage: "secondary index name which will be counted instead of the table",
+			},
+			cli.StringFlag{
+				Name:  "filter",
+				Usage: "filter expression of the items to count",
+			},
+			cli.StringFlag{
+				Name:  "names",
+				Usage: "expression attribute names as a JSON object like {\"#n\": \"name\"}",
+			},
+			cli.StringFlag{
+				Name:  "values",
+				Usage: "expression attribute values as a plain JSON object like {\":id\": 1}",
+			},
+			cli.Int64Flag{
+				Name:  "segments",
+				Usage: "number of the parallel scan segments. 0 means one segment per megabyte of the table",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			tablesString := ctx.String("table-names")
+			if len(tablesString) == 0 {
+				return errors.New(cfmt.Serror("You must pass at least one table name"))
+			}
+			tables := strings.Split(tablesString, ",")
+			names, values, err := expressionAttributes(ctx)
+			if err != nil {
+				return err
+			}
+			opts := toolkit.CountOptions{
+				Index:    ctx.String("index"),
+				Filter:   ctx.String("filter"),
+				Names:    names,
+				Values:   values,
+				Segments: ctx.Int64("segments"),
+			}
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			if err := toolkit.NewCounter(client).Count(tables, opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildStatsCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "stats",
+		Usage: "print the item size distribution, the attributes and the largest partitions of the dynamodb table",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "table-name",
+				Usage: "table name which will be profiled",
+			},
+			cli.Int64Flag{
+				Name:  "sample",
+				Usage: "number of the items to sample at most. 0 means the full scan",
+			},
+			cli.Int64Flag{
+				Name:  "segments",
+				Usage: "number of the parallel scan segments. 0 means one segment per megabyte of the table",
+			},
+			cli.IntFlag{
+				Name:  "top",
+				Usage: "number of the largest partitions to print",
+				Value: 10,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			table := ctx.String("table-name")
+			if len(table) == 0 {
+				return errors.New(cfmt.Serror("You must pass the table name"))
+			}
+			opts := toolkit.StatsOptions{
+				Sample:   ctx.Int64("sample"),
+				Segments: ctx.Int64("segments"),
+				Top:      ctx.Int("top"),
+			}
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			if err := toolkit.NewProfiler(client).Profile(table, opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func buildInferSchemaCommand() cli.Command {
+	cmd := cli.Command{
+		Name:  "infer-schema",
+		Usage: "infer the schema of the dynamodb table from its items as a JSON Schema or the Go structs",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "table-name",
+				Usage: "table name whose schema will be inferred",
+			},
+			cli.Int64Flag{
+				Name:  "sample",
+				Usage: "number of the items to sample at most. 0 means the full scan",
+			},
+			cli.Int64Flag{
+				Name:  "segments",
+				Usage: "number of the parallel scan segments. 0 means one segment per megabyte of the table",
+			},
+			cli.StringFlag{
+				Name:  "output",
+				Usage: "output format of the schema. One of json-schema and go",
+				Value: toolkit.FormatJSONSchema,
+			},
+			cli.StringFlag{
+				Name:  "type-name",
+				Usage: "name of the Go struct. The table name in camel case if not set",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			table := ctx.String("table-name")
+			if len(table) == 0 {
+				return errors.New(cfmt.Serror("You must pass the table name"))
+			}
+			opts := toolkit.SchemaOptions{
+				Sample:   ctx.Int64("sample"),
+				Segments: ctx.Int64("segments"),
+				Format:   ctx.String("output"),
+				TypeName: ctx.String("type-name"),
+			}
+			client, err := service.NewDynamoDBClient()
+			if err != nil {
+				return err
+			}
+			if err := toolkit.NewSchemaInferrer(client).Infer(table, opts); err != nil {
+				return errors.New(cfmt.Serror(err.Error()))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
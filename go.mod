@@ -1,17 +1,30 @@
 module github.com/mingrammer/dynamodb-toolkit
 
 require (
-	github.com/aws/aws-sdk-go v1.28.4
+	github.com/aws/aws-sdk-go v1.36.0
+	github.com/gdamore/tcell/v2 v2.6.0
+	github.com/klauspost/compress v1.10.3
+	github.com/mingrammer/cfmt v1.1.0
+	github.com/peterh/liner v1.2.1
+	github.com/rivo/tview v0.0.0-20230826224341-9754ab44dc1c
+	github.com/urfave/cli v1.22.2
+)
+
+require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.0 // indirect
+	github.com/gdamore/encoding v1.0.0 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/logrusorgru/aurora v0.0.0-20200102142835-e9ef32dff381 // indirect
+	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-colorable v0.1.4 // indirect
 	github.com/mattn/go-isatty v0.0.11 // indirect
-	github.com/mingrammer/cfmt v1.1.0
-	github.com/stretchr/testify v1.3.0 // indirect
-	github.com/urfave/cli v1.22.2
-	golang.org/x/net v0.0.0-20190125091013-d26f9f9a57f3 // indirect
-	golang.org/x/sys v0.0.0-20200116001909-b77594299b42 // indirect
-	golang.org/x/text v0.3.0 // indirect
+	github.com/mattn/go-runewidth v0.0.14 // indirect
+	github.com/rivo/uniseg v0.4.3 // indirect
+	github.com/russross/blackfriday/v2 v2.0.1 // indirect
+	github.com/shurcooL/sanitized_anchor_name v1.0.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/term v0.5.0 // indirect
+	golang.org/x/text v0.7.0 // indirect
 )
 
-go 1.13
+go 1.18
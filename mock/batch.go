@@ -0,0 +1,188 @@
+package mock
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+)
+
+const (
+	maxBatchWriteItems = 25
+	maxBatchGetItems   = 100
+	maxBatchGetSize    = 16 << 20
+)
+
+// BatchWriteItem mocks the dynamodb BatchWriteItem operation
+func (d *DynamoDBClient) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	if err := d.faults.inject("BatchWriteItem"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	// Validate all requests before writing any of them
+	if err := validateReturnConsumedCapacity(input.ReturnConsumedCapacity); err != nil {
+		return nil, err
+	}
+	total := 0
+	for name, reqs := range input.RequestItems {
+		t, err := d.getTable(&name)
+		if err != nil {
+			return nil, err
+		}
+		keys := map[string]bool{}
+		for _, r := range reqs {
+			var key map[string]*dynamodb.AttributeValue
+			switch {
+			case r.PutRequest != nil && r.DeleteRequest != nil, r.PutRequest == nil && r.DeleteRequest == nil:
+				return nil, validationError("Supplied WriteRequest must contain exactly one of PutRequest or DeleteRequest")
+			case r.PutRequest != nil:
+				if err := t.validateItem(r.PutRequest.Item); err != nil {
+					return nil, err
+				}
+				key = r.PutRequest.Item
+			default:
+				if err := t.validateKey(r.DeleteRequest.Key); err != nil {
+					return nil, err
+				}
+				key = r.DeleteRequest.Key
+			}
+			k := t.keyString(key)
+			if keys[k] {
+				return nil, validationError("Provided list of item keys contains duplicates")
+			}
+			keys[k] = true
+		}
+		total += len(reqs)
+	}
+	if total == 0 || total > maxBatchWriteItems {
+		return nil, validationError("Too many items requested for the BatchWriteItem call")
+	}
+
+	// The requests left by the injected faults or the exhausted capacity are returned as unprocessed
+	output := &dynamodb.BatchWriteItemOutput{
+		UnprocessedItems: map[string][]*dynamodb.WriteRequest{},
+	}
+	consumed := consumedCapacities{}
+	processed, throttled := 0, false
+	for name, reqs := range input.RequestItems {
+		t := d.tables[name]
+		for _, r := range reqs {
+			if d.faults.unprocessed() {
+				output.UnprocessedItems[name] = append(output.UnprocessedItems[name], r)
+				continue
+			}
+			var c *consumedCapacity
+			if r.PutRequest != nil {
+				c = t.writeCapacity(t.get(r.PutRequest.Item), r.PutRequest.Item)
+			} else {
+				c = t.writeCapacity(t.get(r.DeleteRequest.Key), nil)
+			}
+			if err := d.faults.consume(c, false, d.now()); err != nil {
+				output.UnprocessedItems[name] = append(output.UnprocessedItems[name], r)
+				throttled = true
+				continue
+			}
+			if r.PutRequest != nil {
+				t.put(r.PutRequest.Item)
+			} else {
+				t.delete(r.DeleteRequest.Key)
+			}
+			consumed.add(c)
+			processed++
+		}
+	}
+	if processed == 0 && throttled {
+		return nil, throughputExceededError()
+	}
+	output.ConsumedCapacity = consumed.output(input.ReturnConsumedCapacity)
+	return output, nil
+}
+
+// BatchGetItem mocks the dynamodb BatchGetItem operation
+// The keys are returned as unprocessed once the response exceeds 16MB or by the injected faults
+func (d *DynamoDBClient) BatchGetItem(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+	if err := d.faults.inject("BatchGetItem"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if err := validateReturnConsumedCapacity(input.ReturnConsumedCapacity); err != nil {
+		return nil, err
+	}
+	total := 0
+	projections := map[string][]docPath{}
+	for name, ka := range input.RequestItems {
+		t, err := d.getTable(aws.String(name))
+		if err != nil {
+			return nil, err
+		}
+		ctx := newExpressionContext(ka.ExpressionAttributeNames, nil)
+		projection, err := projectionOf(ctx, ka.ProjectionExpression, ka.AttributesToGet)
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.validate(); err != nil {
+			return nil, err
+		}
+		projections[name] = projection
+		keys := map[string]bool{}
+		for _, key := range ka.Keys {
+			if err := t.validateKey(key); err != nil {
+				return nil, err
+			}
+			k := t.keyString(key)
+			if keys[k] {
+				return nil, validationError("Provided list of item keys contains duplicates")
+			}
+			keys[k] = true
+		}
+		total += len(ka.Keys)
+	}
+	if total == 0 || total > maxBatchGetItems {
+		return nil, validationError("Too many items requested for the BatchGetItem call")
+	}
+
+	output := &dynamodb.BatchGetItemOutput{
+		Responses:       map[string][]map[string]*dynamodb.AttributeValue{},
+		UnprocessedKeys: map[string]*dynamodb.KeysAndAttributes{},
+	}
+	size := int64(0)
+	consumed := consumedCapacities{}
+	processed, throttled := 0, false
+	for name, ka := range input.RequestItems {
+		t := d.tables[name]
+		output.Responses[name] = []map[string]*dynamodb.AttributeValue{}
+		unprocessed := []map[string]*dynamodb.AttributeValue{}
+		for _, key := range ka.Keys {
+			if size >= maxBatchGetSize || d.faults.unprocessed() {
+				unprocessed = append(unprocessed, key)
+				continue
+			}
+			item := t.get(key)
+			c := readCapacity(t, nil, calc.ItemSize(item), ka.ConsistentRead)
+			if err := d.faults.consume(c, true, d.now()); err != nil {
+				unprocessed = append(unprocessed, key)
+				throttled = true
+				continue
+			}
+			consumed.add(c)
+			processed++
+			if item != nil {
+				size += calc.ItemSize(item)
+				output.Responses[name] = append(output.Responses[name], projectItem(item, projections[name]))
+			}
+		}
+		if len(unprocessed) > 0 {
+			output.UnprocessedKeys[name] = awsutil.CopyOf(ka).(*dynamodb.KeysAndAttributes)
+			output.UnprocessedKeys[name].Keys = unprocessed
+		}
+	}
+	if processed == 0 && throttled {
+		return nil, throughputExceededError()
+	}
+	output.ConsumedCapacity = consumed.output(input.ReturnConsumedCapacity)
+	return output, nil
+}
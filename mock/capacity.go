@@ -0,0 +1,188 @@
+package mock
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+)
+
+const (
+	readUnitSize  = 4 << 10
+	writeUnitSize = 1 << 10
+)
+
+// readUnits returns the read capacity units to read the bytes, the eventually consistent read consumes the half
+func readUnits(size int64, consistentRead *bool) float64 {
+	units := float64((size + readUnitSize - 1) / readUnitSize)
+	if units == 0 {
+		units = 1
+	}
+	if !aws.BoolValue(consistentRead) {
+		units /= 2
+	}
+	return units
+}
+
+// writeUnits returns the write capacity units to write the item of the bytes
+func writeUnits(size int64) float64 {
+	units := float64((size + writeUnitSize - 1) / writeUnitSize)
+	if units == 0 {
+		units = 1
+	}
+	return units
+}
+
+// consumedCapacity is the capacity units consumed by the requests on a table and its indexes
+type consumedCapacity struct {
+	table         *table
+	units         float64
+	globalIndexes map[string]float64
+	localIndexes  map[string]float64
+}
+
+func newConsumedCapacity(t *table) *consumedCapacity {
+	return &consumedCapacity{
+		table:         t,
+		globalIndexes: map[string]float64{},
+		localIndexes:  map[string]float64{},
+	}
+}
+
+// addIndex adds the capacity units consumed on the index
+func (c *consumedCapacity) addIndex(idx *index, units float64) {
+	if idx.local {
+		c.localIndexes[idx.name] += units
+	} else {
+		c.globalIndexes[idx.name] += units
+	}
+}
+
+// add adds the capacity units consumed by the other request on the same table
+func (c *consumedCapacity) add(o *consumedCapacity) {
+	c.units += o.units
+	for name, units := range o.globalIndexes {
+		c.globalIndexes[name] += units
+	}
+	for name, units := range o.localIndexes {
+		c.localIndexes[name] += units
+	}
+}
+
+// scale multiplies all the capacity units, the transactional requests consume twice
+func (c *consumedCapacity) scale(factor float64) *consumedCapacity {
+	c.units *= factor
+	for name := range c.globalIndexes {
+		c.globalIndexes[name] *= factor
+	}
+	for name := range c.localIndexes {
+		c.localIndexes[name] *= factor
+	}
+	return c
+}
+
+// total returns the capacity units consumed on the table and all its indexes
+func (c *consumedCapacity) total() float64 {
+	total := c.units
+	for _, units := range c.globalIndexes {
+		total += units
+	}
+	for _, units := range c.localIndexes {
+		total += units
+	}
+	return total
+}
+
+// output returns the consumed capacity to return for the ReturnConsumedCapacity option
+func (c *consumedCapacity) output(rcc *string) *dynamodb.ConsumedCapacity {
+	if rcc == nil || *rcc == dynamodb.ReturnConsumedCapacityNone {
+		return nil
+	}
+	output := &dynamodb.ConsumedCapacity{
+		TableName:     c.table.desc.TableName,
+		CapacityUnits: aws.Float64(c.total()),
+	}
+	if *rcc != dynamodb.ReturnConsumedCapacityIndexes {
+		return output
+	}
+	output.Table = &dynamodb.Capacity{CapacityUnits: aws.Float64(c.units)}
+	capacities := func(units map[string]float64) map[string]*dynamodb.Capacity {
+		if len(units) == 0 {
+			return nil
+		}
+		m := map[string]*dynamodb.Capacity{}
+		for name, u := range units {
+			m[name] = &dynamodb.Capacity{CapacityUnits: aws.Float64(u)}
+		}
+		return m
+	}
+	output.GlobalSecondaryIndexes = capacities(c.globalIndexes)
+	output.LocalSecondaryIndexes = capacities(c.localIndexes)
+	return output
+}
+
+// consumedCapacities is the consumed capacity of the multiple tables by the batch or the transaction
+type consumedCapacities map[string]*consumedCapacity
+
+// add adds the consumed capacity of its table
+func (cs consumedCapacities) add(c *consumedCapacity) {
+	name := *c.table.desc.TableName
+	if _, ok := cs[name]; !ok {
+		cs[name] = newConsumedCapacity(c.table)
+	}
+	cs[name].add(c)
+}
+
+// output returns the consumed capacities sorted by the table name
+func (cs consumedCapacities) output(rcc *string) []*dynamodb.ConsumedCapacity {
+	if rcc == nil || *rcc == dynamodb.ReturnConsumedCapacityNone {
+		return nil
+	}
+	names := make([]string, 0, len(cs))
+	for name := range cs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	output := make([]*dynamodb.ConsumedCapacity, len(names))
+	for i, name := range names {
+		output[i] = cs[name].output(rcc)
+	}
+	return output
+}
+
+func validateReturnConsumedCapacity(rcc *string) error {
+	if rcc == nil {
+		return nil
+	}
+	switch *rcc {
+	case dynamodb.ReturnConsumedCapacityIndexes, dynamodb.ReturnConsumedCapacityTotal, dynamodb.ReturnConsumedCapacityNone:
+		return nil
+	}
+	return validationError("1 validation error detected: Value '%s' at 'returnConsumedCapacity' failed to satisfy constraint: Member must satisfy enum value set: [INDEXES, TOTAL, NONE]", *rcc)
+}
+
+// readCapacity returns the capacity consumed by reading the bytes from the table or the index
+func readCapacity(t *table, idx *index, size int64, consistentRead *bool) *consumedCapacity {
+	c := newConsumedCapacity(t)
+	units := readUnits(size, consistentRead)
+	if idx != nil {
+		c.addIndex(idx, units)
+	} else {
+		c.units = units
+	}
+	return c
+}
+
+// writeCapacity returns the capacity consumed by replacing the old item with the new item on the table and its indexes
+// Either of the items can be nil for the put of a new item or the delete
+func (t *table) writeCapacity(old, new map[string]*dynamodb.AttributeValue) *consumedCapacity {
+	c := newConsumedCapacity(t)
+	c.units = writeUnits(calc.Max(calc.ItemSize(old), calc.ItemSize(new)))
+	for _, idx := range t.indexes {
+		if units := idx.writeUnits(old, new); units > 0 {
+			c.addIndex(idx, units)
+		}
+	}
+	return c
+}
@@ -0,0 +1,138 @@
+package mock
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+)
+
+func TestItemSizeAccounting(t *testing.T) {
+	client := NewDynamoDBClient()
+	createIndexTestTable(t, client)
+	items := []map[string]*dynamodb.AttributeValue{
+		{"pk": {S: aws.String("a")}, "sk": {N: aws.String("1")}, "team": {S: aws.String("t")}, "score": {N: aws.String("10")}, "name": {S: aws.String("alice")}},
+		{"pk": {S: aws.String("b")}, "sk": {N: aws.String("1")}, "bio": {S: aws.String(strings.Repeat("x", 1000))}},
+	}
+	writeTestItems(t, client, "player", items)
+	client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String("player"),
+		Item:      map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("b")}, "sk": {N: aws.String("1")}},
+	})
+	client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String("player"),
+		Key:       map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("c")}, "sk": {N: aws.String("1")}},
+	})
+
+	desc, _ := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String("player")})
+	want := calc.ItemSize(items[0]) + calc.ItemSize(map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("b")}, "sk": {N: aws.String("1")}})
+	if *desc.Table.TableSizeBytes != want {
+		t.Errorf("Expecting %d, got %d\n", want, *desc.Table.TableSizeBytes)
+	}
+	if *desc.Table.ItemCount != 2 {
+		t.Errorf("Expecting %d, got %d\n", 2, *desc.Table.ItemCount)
+	}
+	if *desc.Table.GlobalSecondaryIndexes[0].IndexSizeBytes != calc.ItemSize(items[0]) {
+		t.Errorf("Expecting %d, got %d\n", calc.ItemSize(items[0]), *desc.Table.GlobalSecondaryIndexes[0].IndexSizeBytes)
+	}
+
+	// The item size is limited to 400KB
+	large := strings.Repeat("x", calc.MaxItemSize)
+	_, err := client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String("player"),
+		Item:      map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("c")}, "sk": {N: aws.String("1")}, "bio": {S: aws.String(large)}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "Item size has exceeded the maximum allowed size") {
+		t.Errorf("Expecting the item size error, got %v\n", err)
+	}
+	_, err = client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String("player"),
+		Key:                       map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}, "sk": {N: aws.String("1")}},
+		UpdateExpression:          aws.String("SET bio = :bio"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":bio": {S: aws.String(large[10:])}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "Item size has exceeded the maximum allowed size") {
+		t.Errorf("Expecting the item size error, got %v\n", err)
+	}
+}
+
+func TestConsumedCapacity(t *testing.T) {
+	client := NewDynamoDBClient()
+	createIndexTestTable(t, client)
+	// 2.5KB item in the global and local secondary indexes
+	item := map[string]*dynamodb.AttributeValue{
+		"pk":    {S: aws.String("a")},
+		"sk":    {N: aws.String("1")},
+		"team":  {S: aws.String("t")},
+		"score": {N: aws.String("1")},
+		"bio":   {S: aws.String(strings.Repeat("x", 2500))},
+	}
+	key := map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}, "sk": {N: aws.String("1")}}
+
+	put, err := client.PutItem(&dynamodb.PutItemInput{
+		TableName:              aws.String("player"),
+		Item:                   item,
+		ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityIndexes),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	cc := put.ConsumedCapacity
+	if *cc.CapacityUnits != 5 || *cc.Table.CapacityUnits != 3 || *cc.GlobalSecondaryIndexes["team-index"].CapacityUnits != 1 || *cc.LocalSecondaryIndexes["score-index"].CapacityUnits != 1 {
+		t.Errorf("Expecting the write capacity units of the table and indexes, got %v\n", cc)
+	}
+
+	// Changing the index key deletes and puts the index entry
+	updated, _ := client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String("player"),
+		Key:                       key,
+		UpdateExpression:          aws.String("SET team = :t"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":t": {S: aws.String("u")}},
+		ReturnConsumedCapacity:    aws.String(dynamodb.ReturnConsumedCapacityIndexes),
+	})
+	if *updated.ConsumedCapacity.GlobalSecondaryIndexes["team-index"].CapacityUnits != 2 {
+		t.Errorf("Expecting %d, got %v\n", 2, updated.ConsumedCapacity)
+	}
+
+	testCases := []struct {
+		consistent bool
+		expected   float64
+	}{
+		{consistent: false, expected: 0.5},
+		{consistent: true, expected: 1},
+	}
+	for i, tc := range testCases {
+		got, _ := client.GetItem(&dynamodb.GetItemInput{
+			TableName:              aws.String("player"),
+			Key:                    key,
+			ConsistentRead:         aws.Bool(tc.consistent),
+			ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityTotal),
+		})
+		if *got.ConsumedCapacity.CapacityUnits != tc.expected || got.ConsumedCapacity.Table != nil {
+			t.Errorf("[%d] Expecting %v, got %v\n", i+1, tc.expected, got.ConsumedCapacity)
+		}
+	}
+
+	queried, _ := client.Query(&dynamodb.QueryInput{
+		TableName:                 aws.String("player"),
+		IndexName:                 aws.String("team-index"),
+		KeyConditionExpression:    aws.String("team = :t"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":t": {S: aws.String("u")}},
+		ReturnConsumedCapacity:    aws.String(dynamodb.ReturnConsumedCapacityIndexes),
+	})
+	if *queried.ConsumedCapacity.Table.CapacityUnits != 0 || *queried.ConsumedCapacity.GlobalSecondaryIndexes["team-index"].CapacityUnits != 0.5 {
+		t.Errorf("Expecting the read capacity units of the index, got %v\n", queried.ConsumedCapacity)
+	}
+
+	transacted, _ := client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{Delete: &dynamodb.Delete{TableName: aws.String("player"), Key: key}},
+		},
+		ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityTotal),
+	})
+	if len(transacted.ConsumedCapacity) != 1 || *transacted.ConsumedCapacity[0].CapacityUnits != 10 {
+		t.Errorf("Expecting %d, got %v\n", 10, transacted.ConsumedCapacity)
+	}
+}
@@ -1,106 +1,160 @@
 package mock
 
 import (
+	"fmt"
+	"sort"
 	"sync"
 	"time"
-	"unsafe"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
-type table struct {
-	desc  *dynamodb.TableDescription
-	items []map[string]*dynamodb.AttributeValue
-}
+const (
+	mockRegion    = "us-east-1"
+	mockAccountID = "000000000000"
+
+	maxListTablesLimit = 100
+)
 
 // DynamoDBClient is mocking the dynamodb
 type DynamoDBClient struct {
 	dynamodbiface.DynamoDBAPI
-	tables map[string]*table
-	mutex  *sync.Mutex // For concurrent request
+	tables       map[string]*table
+	clientTokens map[string]*clientToken // For idempotent transactions
+	streams      []*stream               // All the streams in the created order, including the disabled ones
+	faults       *faultInjector
+	clock        Clock
+	mutex        *sync.Mutex // For concurrent request
 }
 
 // NewDynamoDBClient creates a mocked dynamodb client
 func NewDynamoDBClient() *DynamoDBClient {
 	return &DynamoDBClient{
-		tables: map[string]*table{},
-		mutex:  new(sync.Mutex),
+		tables:       map[string]*table{},
+		clientTokens: map[string]*clientToken{},
+		faults:       newFaultInjector(),
+		clock:        time.Now,
+		mutex:        new(sync.Mutex),
 	}
 }
 
-// BatchWriteItem mocks the dynamodb BatchWriteItem operation
-func (d *DynamoDBClient) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
-	d.mutex.Lock()
-	for t, ri := range input.RequestItems {
-		if _, ok := d.tables[t]; !ok {
-			return nil, awserr.New(dynamodb.ErrCodeResourceNotFoundException, "Not found", nil)
-		}
-		for _, r := range ri {
-			if r.DeleteRequest != nil {
-				for i, it := range d.tables[t].items {
-					match := true
-					for a, k := range r.DeleteRequest.Key {
-						if it[a] != k {
-							match = false
-							break
-						}
-					}
-					if match {
-						d.tables[t].items = append(d.tables[t].items[:i], d.tables[t].items[i+1:]...)
-						*d.tables[t].desc.ItemCount--
-						*d.tables[t].desc.TableSizeBytes -= int64(unsafe.Sizeof(it)) // It is not actual size in bytes
-					}
-				}
-			}
-			if r.PutRequest != nil {
-				d.tables[t].items = append(d.tables[t].items, r.PutRequest.Item)
-				*d.tables[t].desc.ItemCount++
-				*d.tables[t].desc.TableSizeBytes += int64(unsafe.Sizeof(r.PutRequest.Item)) // It is not actual size in bytes
-			}
-		}
+// getTable returns the table or the resource not found error
+func (d *DynamoDBClient) getTable(name *string) (*table, error) {
+	if name == nil {
+		return nil, validationError("1 validation error detected: Value null at 'tableName' failed to satisfy constraint: Member must not be null")
+	}
+	t, ok := d.tables[*name]
+	if !ok {
+		return nil, tableNotFoundError(*name)
 	}
-	d.mutex.Unlock()
-	return &dynamodb.BatchWriteItemOutput{}, nil
+	return t, nil
 }
 
 // CreateTable is mocking the dynamodb CreateTable operation
 func (d *DynamoDBClient) CreateTable(input *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+	if err := d.faults.inject("CreateTable"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
 	name := *input.TableName
-	table := &table{
-		desc: &dynamodb.TableDescription{
-			AttributeDefinitions: input.AttributeDefinitions,
-			CreationDateTime:     aws.Time(time.Now()),
-			ItemCount:            aws.Int64(0),
-			KeySchema:            input.KeySchema,
-			TableName:            &name,
-			TableSizeBytes:       aws.Int64(0),
-		},
-		items: []map[string]*dynamodb.AttributeValue{},
+	if _, ok := d.tables[name]; ok {
+		return nil, tableInUseError(name)
+	}
+	now := time.Now()
+	desc := &dynamodb.TableDescription{
+		AttributeDefinitions: input.AttributeDefinitions,
+		CreationDateTime:     aws.Time(now),
+		ItemCount:            aws.Int64(0),
+		KeySchema:            input.KeySchema,
+		TableArn:             aws.String(fmt.Sprintf("arn:aws:dynamodb:%s:%s:table/%s", mockRegion, mockAccountID, name)),
+		TableId:              aws.String(fmt.Sprintf("%08x-0000-0000-0000-%012x", len(name), now.UnixNano()&0xffffffffffff)),
+		TableName:            &name,
+		TableSizeBytes:       aws.Int64(0),
+		TableStatus:          aws.String(dynamodb.TableStatusActive),
 	}
+	billingMode := dynamodb.BillingModeProvisioned
 	if input.BillingMode != nil {
-		table.desc.SetBillingModeSummary(&dynamodb.BillingModeSummary{
+		billingMode = *input.BillingMode
+		desc.SetBillingModeSummary(&dynamodb.BillingModeSummary{
 			BillingMode: input.BillingMode,
 		})
+	}
+	if billingMode == dynamodb.BillingModePayPerRequest {
+		desc.SetProvisionedThroughput(zeroThroughput())
 	} else {
-		table.desc.SetProvisionedThroughput(&dynamodb.ProvisionedThroughputDescription{
+		if input.ProvisionedThroughput == nil {
+			return nil, validationError("One or more parameter values were invalid: ReadCapacityUnits and WriteCapacityUnits must both be specified when BillingMode is PROVISIONED")
+		}
+		desc.SetProvisionedThroughput(&dynamodb.ProvisionedThroughputDescription{
 			ReadCapacityUnits:  input.ProvisionedThroughput.ReadCapacityUnits,
 			WriteCapacityUnits: input.ProvisionedThroughput.WriteCapacityUnits,
 		})
 	}
-	d.tables[name] = table
+	if input.GlobalSecondaryIndexes != nil {
+		if len(input.GlobalSecondaryIndexes) == 0 {
+			return nil, validationError("1 validation error detected: Value '[]' at 'globalSecondaryIndexes' failed to satisfy constraint: Member must have length greater than or equal to 1")
+		}
+		for _, gsi := range input.GlobalSecondaryIndexes {
+			if err := validateIndexThroughput(billingMode, aws.StringValue(gsi.IndexName), gsi.ProvisionedThroughput); err != nil {
+				return nil, err
+			}
+			desc.GlobalSecondaryIndexes = append(desc.GlobalSecondaryIndexes, globalSecondaryIndexDescription(desc, gsi))
+		}
+	}
+	if input.LocalSecondaryIndexes != nil {
+		if len(input.LocalSecondaryIndexes) == 0 {
+			return nil, validationError("1 validation error detected: Value '[]' at 'localSecondaryIndexes' failed to satisfy constraint: Member must have length greater than or equal to 1")
+		}
+		for _, lsi := range input.LocalSecondaryIndexes {
+			desc.LocalSecondaryIndexes = append(desc.LocalSecondaryIndexes, &dynamodb.LocalSecondaryIndexDescription{
+				IndexArn:       indexArn(desc, aws.StringValue(lsi.IndexName)),
+				IndexName:      lsi.IndexName,
+				IndexSizeBytes: aws.Int64(0),
+				ItemCount:      aws.Int64(0),
+				KeySchema:      lsi.KeySchema,
+				Projection:     lsi.Projection,
+			})
+		}
+	}
+	if input.StreamSpecification != nil {
+		if err := validateStreamSpecification(input.StreamSpecification); err != nil {
+			return nil, err
+		}
+	}
+	t, err := newTable(desc)
+	if err != nil {
+		return nil, err
+	}
+	if input.StreamSpecification != nil && *input.StreamSpecification.StreamEnabled {
+		d.enableStream(t, *input.StreamSpecification.StreamViewType)
+	}
+	d.tables[name] = t
 	return &dynamodb.CreateTableOutput{
-		TableDescription: table.desc,
+		TableDescription: awsutil.CopyOf(desc).(*dynamodb.TableDescription),
 	}, nil
 }
 
 // DeleteTable is mocking the dynamodb DeleteTable operation
 func (d *DynamoDBClient) DeleteTable(input *dynamodb.DeleteTableInput) (*dynamodb.DeleteTableOutput, error) {
-	name := *input.TableName
-	desc := d.tables[name].desc
-	delete(d.tables, name)
+	if err := d.faults.inject("DeleteTable"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t, err := d.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	delete(d.tables, *input.TableName)
+	t.disableStream()
+	desc := awsutil.CopyOf(t.desc).(*dynamodb.TableDescription)
+	desc.TableStatus = aws.String(dynamodb.TableStatusDeleting)
 	return &dynamodb.DeleteTableOutput{
 		TableDescription: desc,
 	}, nil
@@ -108,44 +162,149 @@ func (d *DynamoDBClient) DeleteTable(input *dynamodb.DeleteTableInput) (*dynamod
 
 // DescribeTable is mocking the dynamodb DescribeTable operation
 func (d *DynamoDBClient) DescribeTable(input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
-	name := *input.TableName
-	if table, ok := d.tables[name]; ok {
-		return &dynamodb.DescribeTableOutput{
-			Table: table.desc,
-		}, nil
+	if err := d.faults.inject("DescribeTable"); err != nil {
+		return nil, err
 	}
-	return nil, awserr.New(dynamodb.ErrCodeResourceNotFoundException, "Not found", nil)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t, err := d.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.DescribeTableOutput{
+		Table: awsutil.CopyOf(t.desc).(*dynamodb.TableDescription),
+	}, nil
 }
 
-// Scan is mocking the dynamodb Scan operation
-func (d *DynamoDBClient) Scan(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
-	name := *input.TableName
-	if _, ok := d.tables[name]; !ok {
-		return nil, awserr.New(dynamodb.ErrCodeResourceNotFoundException, "Not found", nil)
-	}
-	total := int64(len(d.tables[name].items))
-	l := int(*input.Segment * total / *input.TotalSegments)
-	r := int((*input.Segment + 1) * total / *input.TotalSegments)
-	items := []map[string]*dynamodb.AttributeValue{}
-	for _, it := range d.tables[name].items[l:r] {
-		attr := map[string]*dynamodb.AttributeValue{}
-		for _, a := range input.AttributesToGet {
-			attr[*a] = it[*a]
-		}
-		items = append(items, attr)
-	}
-	return &dynamodb.ScanOutput{
-		Count:        aws.Int64(int64(len(items))),
-		Items:        items,
-		ScannedCount: aws.Int64(int64(len(items))),
+// zeroThroughput returns the provisioned throughput description of the on-demand tables and indexes
+func zeroThroughput() *dynamodb.ProvisionedThroughputDescription {
+	return &dynamodb.ProvisionedThroughputDescription{
+		NumberOfDecreasesToday: aws.Int64(0),
+		ReadCapacityUnits:      aws.Int64(0),
+		WriteCapacityUnits:     aws.Int64(0),
+	}
+}
+
+// UpdateTable is mocking the dynamodb UpdateTable operation
+// The changes of the billing mode, the provisioned throughput, the global secondary indexes and the stream are applied immediately
+func (d *DynamoDBClient) UpdateTable(input *dynamodb.UpdateTableInput) (*dynamodb.UpdateTableOutput, error) {
+	if err := d.faults.inject("UpdateTable"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t, err := d.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if input.BillingMode == nil && input.ProvisionedThroughput == nil && input.GlobalSecondaryIndexUpdates == nil && input.StreamSpecification == nil {
+		return nil, validationError("At least one of ProvisionedThroughput, BillingMode, UpdateStreamEnabled, GlobalSecondaryIndexUpdates or SSESpecification or ReplicaUpdates is required")
+	}
+	if input.BillingMode != nil || input.ProvisionedThroughput != nil {
+		if err := t.updateBilling(input.BillingMode, input.ProvisionedThroughput); err != nil {
+			return nil, err
+		}
+	}
+	if input.GlobalSecondaryIndexUpdates != nil {
+		if err := t.updateGlobalSecondaryIndexes(input.AttributeDefinitions, input.GlobalSecondaryIndexUpdates); err != nil {
+			return nil, err
+		}
+	}
+	if input.StreamSpecification != nil {
+		if err := d.updateStream(t, input.StreamSpecification); err != nil {
+			return nil, err
+		}
+	}
+	return &dynamodb.UpdateTableOutput{
+		TableDescription: awsutil.CopyOf(t.desc).(*dynamodb.TableDescription),
 	}, nil
 }
 
+// billingMode returns the billing mode of the table
+func (t *table) billingMode() string {
+	if t.desc.BillingModeSummary != nil {
+		return *t.desc.BillingModeSummary.BillingMode
+	}
+	return dynamodb.BillingModeProvisioned
+}
+
+// updateBilling changes the billing mode or the provisioned throughput of the table
+func (t *table) updateBilling(mode *string, pt *dynamodb.ProvisionedThroughput) error {
+	billingMode := t.billingMode()
+	if mode != nil {
+		billingMode = *mode
+	}
+	switch billingMode {
+	case dynamodb.BillingModePayPerRequest:
+		if pt != nil {
+			return validationError("One or more parameter values were invalid: Neither ReadCapacityUnits nor WriteCapacityUnits can be specified when BillingMode is PAY_PER_REQUEST")
+		}
+		t.desc.ProvisionedThroughput = zeroThroughput()
+	case dynamodb.BillingModeProvisioned:
+		if pt == nil {
+			if t.desc.ProvisionedThroughput == nil || *t.desc.ProvisionedThroughput.ReadCapacityUnits == 0 {
+				return validationError("One or more parameter values were invalid: ProvisionedThroughput must be specified when BillingMode is PROVISIONED")
+			}
+			break
+		}
+		if pt.ReadCapacityUnits == nil || pt.WriteCapacityUnits == nil || *pt.ReadCapacityUnits < 1 || *pt.WriteCapacityUnits < 1 {
+			return validationError("One or more parameter values were invalid: ReadCapacityUnits and WriteCapacityUnits must both be specified and greater than 0")
+		}
+		current := t.desc.ProvisionedThroughput
+		if current != nil && *current.ReadCapacityUnits == *pt.ReadCapacityUnits && *current.WriteCapacityUnits == *pt.WriteCapacityUnits {
+			return validationError("The provisioned throughput for the table will not change. The requested value equals the current value. Current ReadCapacityUnits provisioned for the table: %d. Requested ReadCapacityUnits: %d. Current WriteCapacityUnits provisioned for the table: %d. Requested WriteCapacityUnits: %d. Refer to the Amazon DynamoDB Developer Guide for current limits and how to request higher limits.",
+				*current.ReadCapacityUnits, *pt.ReadCapacityUnits, *current.WriteCapacityUnits, *pt.WriteCapacityUnits)
+		}
+		t.desc.ProvisionedThroughput = &dynamodb.ProvisionedThroughputDescription{
+			NumberOfDecreasesToday: aws.Int64(0),
+			ReadCapacityUnits:      pt.ReadCapacityUnits,
+			WriteCapacityUnits:     pt.WriteCapacityUnits,
+		}
+	default:
+		return validationError("1 validation error detected: Value '%s' at 'billingMode' failed to satisfy constraint: Member must satisfy enum value set: [PROVISIONED, PAY_PER_REQUEST]", billingMode)
+	}
+	summary := &dynamodb.BillingModeSummary{BillingMode: aws.String(billingMode)}
+	if billingMode == dynamodb.BillingModePayPerRequest {
+		summary.LastUpdateToPayPerRequestDateTime = aws.Time(time.Now())
+	}
+	t.desc.BillingModeSummary = summary
+	return nil
+}
+
+// ListTables is mocking the dynamodb ListTables operation
+func (d *DynamoDBClient) ListTables(input *dynamodb.ListTablesInput) (*dynamodb.ListTablesOutput, error) {
+	if err := d.faults.inject("ListTables"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	limit := int64(maxListTablesLimit)
+	if input.Limit != nil {
+		if *input.Limit < 1 || *input.Limit > maxListTablesLimit {
+			return nil, validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value less than or equal to %d", *input.Limit, maxListTablesLimit)
+		}
+		limit = *input.Limit
+	}
+	names := []string{}
+	for name := range d.tables {
+		if input.ExclusiveStartTableName == nil || name > *input.ExclusiveStartTableName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	output := &dynamodb.ListTablesOutput{}
+	if int64(len(names)) > limit {
+		names = names[:limit]
+		output.LastEvaluatedTableName = aws.String(names[limit-1])
+	}
+	output.TableNames = aws.StringSlice(names)
+	return output, nil
+}
+
 // WaitUntilTableExists is mocking the dynamodb WaitUntilTableExists operation
 func (d *DynamoDBClient) WaitUntilTableExists(input *dynamodb.DescribeTableInput) error {
-	name := *input.TableName
-	if _, ok := d.tables[name]; !ok {
-		return awserr.New(dynamodb.ErrCodeResourceNotFoundException, "Table exists", nil)
+	if _, err := d.DescribeTable(input); err != nil {
+		return awserr.New(request.WaiterResourceNotReadyErrorCode, "failed waiting for successful resource state", err)
 	}
 	time.Sleep(100 * time.Millisecond)
 	return nil
@@ -153,9 +312,8 @@ func (d *DynamoDBClient) WaitUntilTableExists(input *dynamodb.DescribeTableInput
 
 // WaitUntilTableNotExists is mocking the dynamodb WaitUntilTableNotExists operation
 func (d *DynamoDBClient) WaitUntilTableNotExists(input *dynamodb.DescribeTableInput) error {
-	name := *input.TableName
-	if _, ok := d.tables[name]; ok {
-		return awserr.New(dynamodb.ErrCodeResourceNotFoundException, "Table not exists", nil)
+	if _, err := d.DescribeTable(input); err == nil {
+		return awserr.New(request.WaiterResourceNotReadyErrorCode, "failed waiting for successful resource state", nil)
 	}
 	time.Sleep(100 * time.Millisecond)
 	return nil
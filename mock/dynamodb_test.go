@@ -0,0 +1,369 @@
+package mock
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func createTestTable(t *testing.T, client *DynamoDBClient, name string, rangeType string) {
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: aws.String("S")},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		TableName: aws.String(name),
+	}
+	if rangeType != "" {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, &dynamodb.AttributeDefinition{
+			AttributeName: aws.String("sk"), AttributeType: aws.String(rangeType),
+		})
+		input.KeySchema = append(input.KeySchema, &dynamodb.KeySchemaElement{
+			AttributeName: aws.String("sk"), KeyType: aws.String(dynamodb.KeyTypeRange),
+		})
+	}
+	if _, err := client.CreateTable(input); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestItems(t *testing.T, client *DynamoDBClient, name string, items []map[string]*dynamodb.AttributeValue) {
+	for i := 0; i < len(items); i += maxBatchWriteItems {
+		reqs := []*dynamodb.WriteRequest{}
+		for j := i; j < len(items) && j < i+maxBatchWriteItems; j++ {
+			reqs = append(reqs, &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: items[j]}})
+		}
+		_, err := client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]*dynamodb.WriteRequest{name: reqs},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func errorCode(err error) string {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code()
+	}
+	return ""
+}
+
+func scanAll(t *testing.T, client *DynamoDBClient, input *dynamodb.ScanInput) ([]map[string]*dynamodb.AttributeValue, int) {
+	items := []map[string]*dynamodb.AttributeValue{}
+	pages := 0
+	for {
+		output, err := client.Scan(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		if *output.Count != int64(len(output.Items)) || *output.ScannedCount != int64(len(output.Items)) {
+			t.Errorf("Count and ScannedCount should be %d, got %d and %d\n", len(output.Items), *output.Count, *output.ScannedCount)
+		}
+		items = append(items, output.Items...)
+		if len(output.LastEvaluatedKey) == 0 {
+			return items, pages
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+func TestBatchWriteItemByValue(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "N")
+	writeTestItems(t, client, "user", []map[string]*dynamodb.AttributeValue{
+		{"pk": {S: aws.String("a")}, "sk": {N: aws.String("1")}, "v": {S: aws.String("old")}},
+		{"pk": {S: aws.String("a")}, "sk": {N: aws.String("2")}},
+	})
+
+	// Putting the same key with a differently formatted number replaces the item
+	writeTestItems(t, client, "user", []map[string]*dynamodb.AttributeValue{
+		{"pk": {S: aws.String("a")}, "sk": {N: aws.String("1.0")}, "v": {S: aws.String("new")}},
+	})
+	items, _ := scanAll(t, client, &dynamodb.ScanInput{TableName: aws.String("user")})
+	if len(items) != 2 || *items[0]["v"].S != "new" {
+		t.Errorf("Expecting the item to be replaced, got %v\n", items)
+	}
+
+	// Deleting with the newly allocated key values
+	_, err := client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]*dynamodb.WriteRequest{"user": {
+			{DeleteRequest: &dynamodb.DeleteRequest{Key: map[string]*dynamodb.AttributeValue{
+				"pk": {S: aws.String("a")}, "sk": {N: aws.String("01")},
+			}}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, _ = scanAll(t, client, &dynamodb.ScanInput{TableName: aws.String("user")})
+	if len(items) != 1 || *items[0]["sk"].N != "2" {
+		t.Errorf("Expecting the item to be deleted, got %v\n", items)
+	}
+	desc, _ := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String("user")})
+	if *desc.Table.ItemCount != 1 {
+		t.Errorf("Expecting the item count to be 1, got %d\n", *desc.Table.ItemCount)
+	}
+}
+
+func TestBatchWriteItemValidation(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "")
+	testCases := []struct {
+		reqs []*dynamodb.WriteRequest
+		code string
+	}{
+		{
+			reqs: []*dynamodb.WriteRequest{
+				{PutRequest: &dynamodb.PutRequest{Item: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}}}},
+				{DeleteRequest: &dynamodb.DeleteRequest{Key: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}}}},
+			},
+			code: errCodeValidationException,
+		},
+		{
+			reqs: []*dynamodb.WriteRequest{
+				{PutRequest: &dynamodb.PutRequest{Item: map[string]*dynamodb.AttributeValue{"id": {S: aws.String("a")}}}},
+			},
+			code: errCodeValidationException,
+		},
+		{
+			reqs: []*dynamodb.WriteRequest{
+				{PutRequest: &dynamodb.PutRequest{Item: map[string]*dynamodb.AttributeValue{"pk": {N: aws.String("1")}}}},
+			},
+			code: errCodeValidationException,
+		},
+		{
+			reqs: []*dynamodb.WriteRequest{
+				{PutRequest: &dynamodb.PutRequest{Item: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}, "n": {N: aws.String("x")}}}},
+			},
+			code: errCodeValidationException,
+		},
+		{
+			reqs: []*dynamodb.WriteRequest{
+				{PutRequest: &dynamodb.PutRequest{Item: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}, "s": {SS: aws.StringSlice([]string{"x", "x"})}}}},
+			},
+			code: errCodeValidationException,
+		},
+	}
+	for i, tc := range testCases {
+		_, err := client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]*dynamodb.WriteRequest{"user": tc.reqs},
+		})
+		if code := errorCode(err); code != tc.code {
+			t.Errorf("[%d] Expecting the error %s, got %v\n", i+1, tc.code, err)
+		}
+	}
+	_, err := client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]*dynamodb.WriteRequest{"unknown": {}},
+	})
+	if code := errorCode(err); code != dynamodb.ErrCodeResourceNotFoundException {
+		t.Errorf("Expecting the resource not found error, got %v\n", err)
+	}
+	desc, _ := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String("user")})
+	if *desc.Table.ItemCount != 0 {
+		t.Errorf("Invalid requests should not write any items, got %d items\n", *desc.Table.ItemCount)
+	}
+}
+
+func TestScanSortKeyOrder(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "event", "N")
+	items := []map[string]*dynamodb.AttributeValue{}
+	for _, n := range []string{"10", "-3", "2.5", "100", "0", "1e1"} {
+		items = append(items, map[string]*dynamodb.AttributeValue{
+			"pk": {S: aws.String("p")}, "sk": {N: aws.String(n)},
+		})
+	}
+	writeTestItems(t, client, "event", items[:5])
+	writeTestItems(t, client, "event", items[5:])
+
+	scanned, _ := scanAll(t, client, &dynamodb.ScanInput{TableName: aws.String("event")})
+	got := []string{}
+	for _, it := range scanned {
+		got = append(got, *it["sk"].N)
+	}
+	if strings.Join(got, ",") != "-3,0,2.5,1e1,100" {
+		t.Errorf("Expecting the items ordered by the numeric sort key, got %v\n", got)
+	}
+}
+
+func TestScanPagination(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "")
+	items := []map[string]*dynamodb.AttributeValue{}
+	for i := 0; i < 100; i++ {
+		items = append(items, map[string]*dynamodb.AttributeValue{
+			"pk": {S: aws.String(strconv.Itoa(i))},
+		})
+	}
+	writeTestItems(t, client, "user", items)
+
+	// Paginate with the limit
+	scanned, pages := scanAll(t, client, &dynamodb.ScanInput{TableName: aws.String("user"), Limit: aws.Int64(30)})
+	if len(scanned) != 100 || pages != 4 {
+		t.Errorf("Expecting 100 items in 4 pages, got %d items in %d pages\n", len(scanned), pages)
+	}
+	seen := map[string]bool{}
+	for _, it := range scanned {
+		seen[*it["pk"].S] = true
+	}
+	if len(seen) != 100 {
+		t.Errorf("Expecting 100 distinct items, got %d\n", len(seen))
+	}
+
+	// Parallel scan segments do not overlap and cover all items
+	seen = map[string]bool{}
+	for segment := int64(0); segment < 7; segment++ {
+		scanned, _ := scanAll(t, client, &dynamodb.ScanInput{
+			TableName:     aws.String("user"),
+			Segment:       aws.Int64(segment),
+			TotalSegments: aws.Int64(7),
+			Limit:         aws.Int64(5),
+		})
+		for _, it := range scanned {
+			if seen[*it["pk"].S] {
+				t.Errorf("Item %s is scanned twice\n", *it["pk"].S)
+			}
+			seen[*it["pk"].S] = true
+		}
+	}
+	if len(seen) != 100 {
+		t.Errorf("Expecting 100 items from the segments, got %d\n", len(seen))
+	}
+
+	// Count only
+	output, _ := client.Scan(&dynamodb.ScanInput{TableName: aws.String("user"), Select: aws.String(dynamodb.SelectCount)})
+	if *output.Count != 100 || output.Items != nil {
+		t.Errorf("Expecting the count of 100 without items, got %d\n", *output.Count)
+	}
+}
+
+func TestScanPageSize(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "blob", "")
+	items := []map[string]*dynamodb.AttributeValue{}
+	for i := 0; i < 30; i++ {
+		items = append(items, map[string]*dynamodb.AttributeValue{
+			"pk":   {S: aws.String(strconv.Itoa(i))},
+			"data": {B: make([]byte, 100*1024)},
+		})
+	}
+	writeTestItems(t, client, "blob", items)
+	output, _ := client.Scan(&dynamodb.ScanInput{TableName: aws.String("blob")})
+	if *output.Count != 11 || len(output.LastEvaluatedKey) == 0 {
+		t.Errorf("Expecting 11 items of 1MB page with the last evaluated key, got %d items\n", *output.Count)
+	}
+	scanned, pages := scanAll(t, client, &dynamodb.ScanInput{TableName: aws.String("blob")})
+	if len(scanned) != 30 || pages != 3 {
+		t.Errorf("Expecting 30 items in 3 pages, got %d items in %d pages\n", len(scanned), pages)
+	}
+}
+
+func TestReturnedItemsAreCopied(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "")
+	item := map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}, "v": {S: aws.String("1")}}
+	writeTestItems(t, client, "user", []map[string]*dynamodb.AttributeValue{item})
+	*item["v"].S = "2"
+	output, _ := client.Scan(&dynamodb.ScanInput{TableName: aws.String("user")})
+	*output.Items[0]["v"].S = "3"
+	output, _ = client.Scan(&dynamodb.ScanInput{TableName: aws.String("user")})
+	if *output.Items[0]["v"].S != "1" {
+		t.Errorf("The stored item should not be modified from outside, got %s\n", *output.Items[0]["v"].S)
+	}
+}
+
+func TestBatchGetItem(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "blob", "")
+	items := []map[string]*dynamodb.AttributeValue{}
+	keys := []map[string]*dynamodb.AttributeValue{}
+	for i := 0; i < 60; i++ {
+		items = append(items, map[string]*dynamodb.AttributeValue{
+			"pk":   {S: aws.String(strconv.Itoa(i))},
+			"data": {B: make([]byte, 390*1024)},
+		})
+		keys = append(keys, map[string]*dynamodb.AttributeValue{"pk": {S: aws.String(strconv.Itoa(i))}})
+	}
+	writeTestItems(t, client, "blob", items)
+
+	// Items over 16MB are returned as the unprocessed keys
+	got := 0
+	requests := map[string]*dynamodb.KeysAndAttributes{"blob": {Keys: keys, ProjectionExpression: aws.String("pk, #d")}}
+	requests["blob"].ExpressionAttributeNames = map[string]*string{"#d": aws.String("data")}
+	for i := 0; i < 3 && len(requests) > 0; i++ {
+		output, err := client.BatchGetItem(&dynamodb.BatchGetItemInput{RequestItems: requests})
+		if err != nil {
+			t.Fatalf("There should be no errors, Got %s\n", err.Error())
+		}
+		if i == 0 && len(output.UnprocessedKeys) == 0 {
+			t.Errorf("Expecting the unprocessed keys\n")
+		}
+		got += len(output.Responses["blob"])
+		requests = output.UnprocessedKeys
+	}
+	if got != 60 || len(requests) != 0 {
+		t.Errorf("Expecting 60 items, got %d\n", got)
+	}
+
+	_, err := client.BatchGetItem(&dynamodb.BatchGetItemInput{RequestItems: map[string]*dynamodb.KeysAndAttributes{
+		"blob": {Keys: []map[string]*dynamodb.AttributeValue{keys[0], keys[0]}},
+	}})
+	if errorCode(err) != errCodeValidationException {
+		t.Errorf("Expecting the validation error on the duplicated keys, got %v\n", err)
+	}
+}
+
+func TestListTables(t *testing.T) {
+	client := NewDynamoDBClient()
+	for _, name := range []string{"c", "a", "e", "b", "d"} {
+		createTestTable(t, client, name, "")
+	}
+	names := []string{}
+	input := &dynamodb.ListTablesInput{Limit: aws.Int64(2)}
+	for {
+		output, err := client.ListTables(input)
+		if err != nil {
+			t.Fatalf("There should be no errors, Got %s\n", err.Error())
+		}
+		names = append(names, aws.StringValueSlice(output.TableNames)...)
+		if output.LastEvaluatedTableName == nil {
+			break
+		}
+		input.ExclusiveStartTableName = output.LastEvaluatedTableName
+	}
+	if strings.Join(names, ",") != "a,b,c,d,e" {
+		t.Errorf("Expecting the sorted table names, got %v\n", names)
+	}
+}
+
+func TestUpdateTable(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "")
+	output, err := client.UpdateTable(&dynamodb.UpdateTableInput{
+		TableName:             aws.String("user"),
+		BillingMode:           aws.String(dynamodb.BillingModeProvisioned),
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(5), WriteCapacityUnits: aws.Int64(10)},
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if *output.TableDescription.BillingModeSummary.BillingMode != dynamodb.BillingModeProvisioned || *output.TableDescription.ProvisionedThroughput.WriteCapacityUnits != 10 {
+		t.Errorf("Expecting the provisioned billing mode, got %v\n", output.TableDescription)
+	}
+	_, err = client.UpdateTable(&dynamodb.UpdateTableInput{
+		TableName:             aws.String("user"),
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(5), WriteCapacityUnits: aws.Int64(10)},
+	})
+	if errorCode(err) != errCodeValidationException {
+		t.Errorf("Expecting the validation error on the same throughput, got %v\n", err)
+	}
+}
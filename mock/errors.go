@@ -0,0 +1,66 @@
+package mock
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+// errCodeValidationException is the error code of the invalid requests
+const errCodeValidationException = "ValidationException"
+
+func validationError(format string, a ...interface{}) error {
+	return awserr.New(errCodeValidationException, fmt.Sprintf(format, a...), nil)
+}
+
+func tableNotFoundError(table string) error {
+	return awserr.New(dynamodb.ErrCodeResourceNotFoundException, fmt.Sprintf("Requested resource not found: Table: %s not found", table), nil)
+}
+
+func tableInUseError(table string) error {
+	return awserr.New(dynamodb.ErrCodeResourceInUseException, fmt.Sprintf("Table already exists: %s", table), nil)
+}
+
+// awsMessage returns the message of the aws error
+func awsMessage(err error) string {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Message()
+	}
+	return err.Error()
+}
+
+func conditionalCheckFailedError() error {
+	return awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+}
+
+// transactionCanceledError returns the cancellation error with the reasons of all the transaction items
+func transactionCanceledError(reasons []*dynamodb.CancellationReason) error {
+	codes := make([]string, len(reasons))
+	for i, r := range reasons {
+		codes[i] = *r.Code
+	}
+	return &dynamodb.TransactionCanceledException{
+		CancellationReasons: reasons,
+		Message_:            aws.String(fmt.Sprintf("Transaction cancelled, please refer cancellation reasons for specific reasons [%s]", strings.Join(codes, ", "))),
+	}
+}
+
+func idempotentParameterMismatchError() error {
+	return awserr.New(dynamodb.ErrCodeIdempotentParameterMismatchException, "The request uses the same client token as a previous, but non-identical request.", nil)
+}
+
+func streamNotFoundError(arn string) error {
+	return awserr.New(dynamodbstreams.ErrCodeResourceNotFoundException, fmt.Sprintf("Requested resource not found: Stream: %s not found", arn), nil)
+}
+
+func shardNotFoundError(id string) error {
+	return awserr.New(dynamodbstreams.ErrCodeResourceNotFoundException, fmt.Sprintf("Requested resource not found: Shard: %s not found", id), nil)
+}
+
+func expiredIteratorError() error {
+	return awserr.New(dynamodbstreams.ErrCodeExpiredIteratorException, "Iterator expired. The iterator was created more than 15 minutes ago", nil)
+}
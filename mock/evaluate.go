@@ -0,0 +1,518 @@
+package mock
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// resolvePath returns the value of the document path in the item, or nil if it does not exist
+func resolvePath(item map[string]*dynamodb.AttributeValue, path docPath) *dynamodb.AttributeValue {
+	v := item[path[0].name]
+	for _, e := range path[1:] {
+		switch {
+		case v == nil:
+			return nil
+		case e.isIndex:
+			if v.L == nil || e.index >= len(v.L) {
+				return nil
+			}
+			v = v.L[e.index]
+		default:
+			if v.M == nil {
+				return nil
+			}
+			v = v.M[e.name]
+		}
+	}
+	return v
+}
+
+// sizeOf returns the size of the value for the size function, or nil if the size is not defined
+func sizeOf(v *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	size := -1
+	switch typeOf(v) {
+	case typeS:
+		size = len(*v.S)
+	case typeB:
+		size = len(v.B)
+	case typeSS, typeNS, typeBS:
+		size = len(setElements(v))
+	case typeM:
+		size = len(v.M)
+	case typeL:
+		size = len(v.L)
+	}
+	if size < 0 {
+		return nil
+	}
+	n := strconv.Itoa(size)
+	return &dynamodb.AttributeValue{N: &n}
+}
+
+// resolve returns the value of the condition operand, or nil if it does not exist
+func (o *operand) resolve(item map[string]*dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	switch o.kind {
+	case operandPath:
+		return resolvePath(item, o.path)
+	case operandValue:
+		return o.value
+	case operandSize:
+		if v := o.args[0].resolve(item); v != nil {
+			return sizeOf(v)
+		}
+	}
+	return nil
+}
+
+// sameScalars reports whether the values are the scalars of the same type
+func sameScalars(a, b *dynamodb.AttributeValue) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	typ := typeOf(a)
+	return (typ == typeS || typ == typeN || typ == typeB) && typ == typeOf(b)
+}
+
+// eval evaluates the condition against the item, the missing attributes and the mismatched types are false
+func (c *condition) eval(item map[string]*dynamodb.AttributeValue) bool {
+	switch c.op {
+	case "AND":
+		return c.conds[0].eval(item) && c.conds[1].eval(item)
+	case "OR":
+		return c.conds[0].eval(item) || c.conds[1].eval(item)
+	case "NOT":
+		return !c.conds[0].eval(item)
+	}
+	values := make([]*dynamodb.AttributeValue, len(c.operands))
+	for i, o := range c.operands {
+		values[i] = o.resolve(item)
+	}
+	a := values[0]
+	switch c.op {
+	case "=":
+		return a != nil && values[1] != nil && equalValues(a, values[1])
+	case "<>":
+		return a == nil || values[1] == nil || !equalValues(a, values[1])
+	case "<":
+		return sameScalars(a, values[1]) && compareScalars(a, values[1]) < 0
+	case "<=":
+		return sameScalars(a, values[1]) && compareScalars(a, values[1]) <= 0
+	case ">":
+		return sameScalars(a, values[1]) && compareScalars(a, values[1]) > 0
+	case ">=":
+		return sameScalars(a, values[1]) && compareScalars(a, values[1]) >= 0
+	case "BETWEEN":
+		return sameScalars(a, values[1]) && sameScalars(a, values[2]) &&
+			compareScalars(a, values[1]) >= 0 && compareScalars(a, values[2]) <= 0
+	case "IN":
+		for _, v := range values[1:] {
+			if a != nil && v != nil && equalValues(a, v) {
+				return true
+			}
+		}
+		return false
+	case "attribute_exists":
+		return a != nil
+	case "attribute_not_exists":
+		return a == nil
+	case "attribute_type":
+		return a != nil && values[1] != nil && values[1].S != nil && typeOf(a) == *values[1].S
+	case "begins_with":
+		if !sameScalars(a, values[1]) {
+			return false
+		}
+		if a.S != nil {
+			return strings.HasPrefix(*a.S, *values[1].S)
+		}
+		return a.B != nil && bytes.HasPrefix(a.B, values[1].B)
+	case "contains":
+		return contains(a, values[1])
+	}
+	return false
+}
+
+// contains reports whether the string or binary has the substring, or the set or list has the element
+func contains(a, b *dynamodb.AttributeValue) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	switch typeOf(a) {
+	case typeS:
+		return b.S != nil && strings.Contains(*a.S, *b.S)
+	case typeB:
+		return b.B != nil && bytes.Contains(a.B, b.B)
+	case typeSS, typeNS, typeBS:
+		for _, e := range setElements(a) {
+			if equalValues(e, b) {
+				return true
+			}
+		}
+	case typeL:
+		for _, e := range a.L {
+			if equalValues(e, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// project returns the copy of the item with the given document paths only
+func project(item map[string]*dynamodb.AttributeValue, paths []docPath) map[string]*dynamodb.AttributeValue {
+	projected := map[string]*dynamodb.AttributeValue{}
+	rests := map[string][]docPath{}
+	for _, p := range paths {
+		rests[p[0].name] = append(rests[p[0].name], p[1:])
+	}
+	for name, rest := range rests {
+		if v := projectValue(item[name], rest); v != nil {
+			projected[name] = v
+		}
+	}
+	return projected
+}
+
+// projectValue returns the copy of the value with the given remaining paths only
+func projectValue(v *dynamodb.AttributeValue, rests []docPath) *dynamodb.AttributeValue {
+	if v == nil {
+		return nil
+	}
+	for _, r := range rests {
+		if len(r) == 0 {
+			return copyValue(v)
+		}
+	}
+	if rests[0][0].isIndex {
+		if v.L == nil {
+			return nil
+		}
+		byIndex := map[int][]docPath{}
+		for _, r := range rests {
+			if r[0].isIndex {
+				byIndex[r[0].index] = append(byIndex[r[0].index], r[1:])
+			}
+		}
+		indexes := []int{}
+		for i := range byIndex {
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		projected := &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{}}
+		for _, i := range indexes {
+			if i >= len(v.L) {
+				continue
+			}
+			if e := projectValue(v.L[i], byIndex[i]); e != nil {
+				projected.L = append(projected.L, e)
+			}
+		}
+		if len(projected.L) == 0 {
+			return nil
+		}
+		return projected
+	}
+	if v.M == nil {
+		return nil
+	}
+	byName := map[string][]docPath{}
+	for _, r := range rests {
+		if !r[0].isIndex {
+			byName[r[0].name] = append(byName[r[0].name], r[1:])
+		}
+	}
+	projected := &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{}}
+	for name, rest := range byName {
+		if e := projectValue(v.M[name], rest); e != nil {
+			projected.M[name] = e
+		}
+	}
+	if len(projected.M) == 0 {
+		return nil
+	}
+	return projected
+}
+
+func invalidUpdatePathError() error {
+	return validationError("The document path provided in the update expression is invalid for update")
+}
+
+func incorrectOperandTypeError() error {
+	return validationError("An operand in the update expression has an incorrect data type")
+}
+
+func missingAttributeError() error {
+	return validationError("The provided expression refers to an attribute that does not exist in the item")
+}
+
+// updateValue returns the value of the SET action operand
+func (o *operand) updateValue(item map[string]*dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
+	switch o.kind {
+	case operandPath:
+		v := resolvePath(item, o.path)
+		if v == nil {
+			return nil, missingAttributeError()
+		}
+		return v, nil
+	case operandValue:
+		return o.value, nil
+	case operandIfNotExists:
+		if v := resolvePath(item, o.args[0].path); v != nil {
+			return v, nil
+		}
+		return o.args[1].updateValue(item)
+	}
+	args := make([]*dynamodb.AttributeValue, len(o.args))
+	for i, arg := range o.args {
+		v, err := arg.updateValue(item)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	if o.kind == operandListAppend {
+		if args[0].L == nil || args[1].L == nil {
+			return nil, incorrectOperandTypeError()
+		}
+		l := append(append([]*dynamodb.AttributeValue{}, args[0].L...), args[1].L...)
+		return &dynamodb.AttributeValue{L: l}, nil
+	}
+	if args[0].N == nil || args[1].N == nil {
+		return nil, incorrectOperandTypeError()
+	}
+	a, _ := parseNumber(*args[0].N)
+	b, _ := parseNumber(*args[1].N)
+	if o.op == "+" {
+		return numberValue(a.add(b))
+	}
+	return numberValue(a.sub(b))
+}
+
+func numberValue(n *number) (*dynamodb.AttributeValue, error) {
+	if !n.valid() {
+		return nil, validationError("Number overflow. Attempting to store a number with magnitude larger than supported range")
+	}
+	s := n.String()
+	return &dynamodb.AttributeValue{N: &s}, nil
+}
+
+// setPath sets the value at the document path, the parent of the nested path must exist
+func setPath(item map[string]*dynamodb.AttributeValue, path docPath, v *dynamodb.AttributeValue) error {
+	if len(path) == 1 {
+		item[path[0].name] = v
+		return nil
+	}
+	parent := resolvePath(item, path[:len(path)-1])
+	last := path[len(path)-1]
+	switch {
+	case parent == nil:
+		return invalidUpdatePathError()
+	case last.isIndex:
+		if parent.L == nil {
+			return invalidUpdatePathError()
+		}
+		if last.index >= len(parent.L) {
+			parent.L = append(parent.L, v)
+		} else {
+			parent.L[last.index] = v
+		}
+	default:
+		if parent.M == nil {
+			return invalidUpdatePathError()
+		}
+		parent.M[last.name] = v
+	}
+	return nil
+}
+
+// removePath removes the value at the document path, the missing attributes are ignored
+func removePath(item map[string]*dynamodb.AttributeValue, path docPath) error {
+	if len(path) == 1 {
+		delete(item, path[0].name)
+		return nil
+	}
+	parent := resolvePath(item, path[:len(path)-1])
+	last := path[len(path)-1]
+	switch {
+	case parent == nil:
+		return invalidUpdatePathError()
+	case last.isIndex:
+		if parent.L == nil {
+			return invalidUpdatePathError()
+		}
+		if last.index < len(parent.L) {
+			parent.L = append(parent.L[:last.index], parent.L[last.index+1:]...)
+		}
+	default:
+		if parent.M == nil {
+			return invalidUpdatePathError()
+		}
+		delete(parent.M, last.name)
+	}
+	return nil
+}
+
+// comparePathsForRemove orders the paths to remove the list elements from the highest index,
+// so that the indexes of the other elements to remove are not shifted
+func comparePathsForRemove(a, b docPath) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		if a[i].isIndex && b[i].isIndex {
+			return a[i].index > b[i].index
+		}
+		return a[i].name < b[i].name
+	}
+	return len(a) > len(b)
+}
+
+// applyUpdate returns the copy of the item updated by the actions
+// All values are evaluated against the original item before any action is applied
+func applyUpdate(item map[string]*dynamodb.AttributeValue, actions []*updateAction) (map[string]*dynamodb.AttributeValue, error) {
+	values := make([]*dynamodb.AttributeValue, len(actions))
+	for i, a := range actions {
+		if a.action != "SET" {
+			continue
+		}
+		v, err := a.value.updateValue(item)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = copyValue(v)
+	}
+
+	updated := copyItem(item)
+	removes := []docPath{}
+	for i, a := range actions {
+		switch a.action {
+		case "SET":
+			if err := setPath(updated, a.path, values[i]); err != nil {
+				return nil, err
+			}
+		case "REMOVE":
+			removes = append(removes, a.path)
+		case "ADD":
+			v, err := addValue(resolvePath(updated, a.path), a.value.value)
+			if err != nil {
+				return nil, err
+			}
+			if err := setPath(updated, a.path, v); err != nil {
+				return nil, err
+			}
+		case "DELETE":
+			existing := resolvePath(updated, a.path)
+			if existing == nil {
+				continue
+			}
+			if typeOf(existing) != typeOf(a.value.value) {
+				return nil, incorrectOperandTypeError()
+			}
+			v := deleteElements(existing, a.value.value)
+			if v == nil {
+				removes = append(removes, a.path)
+			} else if err := setPath(updated, a.path, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.SliceStable(removes, func(i, j int) bool {
+		return comparePathsForRemove(removes[i], removes[j])
+	})
+	for _, p := range removes {
+		if err := removePath(updated, p); err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
+// addValue returns the result of the ADD action on the existing value
+func addValue(existing, v *dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
+	if existing == nil {
+		return copyValue(v), nil
+	}
+	if typeOf(existing) != typeOf(v) {
+		return nil, incorrectOperandTypeError()
+	}
+	if v.N != nil {
+		a, _ := parseNumber(*existing.N)
+		b, _ := parseNumber(*v.N)
+		return numberValue(a.add(b))
+	}
+	union := copyValue(existing)
+	seen := map[string]bool{}
+	for _, e := range setElements(existing) {
+		seen[encodeValue(e)] = true
+	}
+	for _, e := range setElements(v) {
+		if seen[encodeValue(e)] {
+			continue
+		}
+		e = copyValue(e)
+		switch {
+		case e.S != nil:
+			union.SS = append(union.SS, e.S)
+		case e.N != nil:
+			union.NS = append(union.NS, e.N)
+		default:
+			union.BS = append(union.BS, e.B)
+		}
+	}
+	return union, nil
+}
+
+// deleteElements returns the set without the elements of the other set, or nil if it becomes empty
+func deleteElements(existing, v *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	removed := map[string]bool{}
+	for _, e := range setElements(v) {
+		removed[encodeValue(e)] = true
+	}
+	rest := &dynamodb.AttributeValue{}
+	count := 0
+	for _, e := range setElements(existing) {
+		if removed[encodeValue(e)] {
+			continue
+		}
+		count++
+		e = copyValue(e)
+		switch {
+		case e.S != nil:
+			rest.SS = append(rest.SS, e.S)
+		case e.N != nil:
+			rest.NS = append(rest.NS, e.N)
+		default:
+			rest.BS = append(rest.BS, e.B)
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	return rest
+}
+
+// paths returns the document paths referred by the condition
+func (c *condition) paths() []docPath {
+	paths := []docPath{}
+	for _, sub := range c.conds {
+		paths = append(paths, sub.paths()...)
+	}
+	for _, o := range c.operands {
+		paths = append(paths, o.paths()...)
+	}
+	return paths
+}
+
+func (o *operand) paths() []docPath {
+	if o.kind == operandPath {
+		return []docPath{o.path}
+	}
+	paths := []docPath{}
+	for _, arg := range o.args {
+		paths = append(paths, arg.paths()...)
+	}
+	return paths
+}
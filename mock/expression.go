@@ -0,0 +1,905 @@
+package mock
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Kinds of the expressions used in the error messages
+const (
+	conditionExpression    = "ConditionExpression"
+	filterExpression       = "FilterExpression"
+	keyConditionExpression = "KeyConditionExpression"
+	projectionExpression   = "ProjectionExpression"
+	updateExpression       = "UpdateExpression"
+)
+
+const maxInOperands = 100
+
+// typeNames are the names of the attribute types used in the error messages
+var typeNames = map[string]string{
+	typeS:    "STRING",
+	typeN:    "NUMBER",
+	typeB:    "BINARY",
+	typeBOOL: "BOOLEAN",
+	typeNULL: "NULL",
+	typeSS:   "STRING_SET",
+	typeNS:   "NUMBER_SET",
+	typeBS:   "BINARY_SET",
+	typeM:    "MAP",
+	typeL:    "LIST",
+}
+
+// pathElement is an attribute name or a list index of the document path
+type pathElement struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// docPath is a document path such as a.b[1].c
+type docPath []pathElement
+
+func (p docPath) String() string {
+	elems := []string{}
+	for _, e := range p {
+		if e.isIndex {
+			elems = append(elems, fmt.Sprintf("[%d]", e.index))
+		} else {
+			elems = append(elems, e.name)
+		}
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+// overlaps reports whether one of the paths is the prefix of the other
+func (p docPath) overlaps(o docPath) bool {
+	n := len(p)
+	if len(o) < n {
+		n = len(o)
+	}
+	for i := 0; i < n; i++ {
+		if p[i] != o[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkOverlaps returns the error if any two of the paths overlap with each other
+func checkOverlaps(kind string, paths []docPath) error {
+	for i := range paths {
+		for j := i + 1; j < len(paths); j++ {
+			if paths[i].overlaps(paths[j]) {
+				return validationError("Invalid %s: Two document paths overlap with each other; must remove or rewrite one of these paths; path one: %s, path two: %s", kind, paths[i], paths[j])
+			}
+		}
+	}
+	return nil
+}
+
+// Kinds of the operands
+const (
+	operandPath = iota
+	operandValue
+	operandSize
+	operandIfNotExists
+	operandListAppend
+	operandArithmetic
+)
+
+// operand is a value in the expression which is resolved against an item
+type operand struct {
+	kind  int
+	path  docPath
+	value *dynamodb.AttributeValue
+	op    string // Operator of the arithmetic operand
+	args  []*operand
+}
+
+// condition is a node of the condition expression tree
+// The op is one of the comparators, BETWEEN, IN, AND, OR, NOT or a function name
+type condition struct {
+	op       string
+	operands []*operand
+	conds    []*condition
+}
+
+// updateAction is a single action of the update expression
+type updateAction struct {
+	action string // SET, REMOVE, ADD or DELETE
+	path   docPath
+	value  *operand
+}
+
+// keyCondition is a condition on a single key attribute of the key condition expression
+type keyCondition struct {
+	name   string
+	op     string // =, <, <=, >, >=, BETWEEN or begins_with
+	values []*dynamodb.AttributeValue
+}
+
+// expressionContext parses the expressions of a request with the expression attribute names and values
+// It keeps track of the used names and values to report the unused ones like the dynamodb does
+type expressionContext struct {
+	names       map[string]*string
+	values      map[string]*dynamodb.AttributeValue
+	usedNames   map[string]bool
+	usedValues  map[string]bool
+	expressions int
+}
+
+func newExpressionContext(names map[string]*string, values map[string]*dynamodb.AttributeValue) *expressionContext {
+	return &expressionContext{
+		names:      names,
+		values:     values,
+		usedNames:  map[string]bool{},
+		usedValues: map[string]bool{},
+	}
+}
+
+// validate checks the expression attribute names and values, it must be called after all expressions are parsed
+func (c *expressionContext) validate() error {
+	if c.names != nil {
+		if c.expressions == 0 {
+			return validationError("ExpressionAttributeNames can only be specified when using expressions")
+		}
+		if len(c.names) == 0 {
+			return validationError("ExpressionAttributeNames must not be empty")
+		}
+		for k, v := range c.names {
+			if !strings.HasPrefix(k, "#") || len(k) == 1 {
+				return validationError("ExpressionAttributeNames contains invalid key: Syntax error; key: \"%s\"", k)
+			}
+			if v == nil || *v == "" {
+				return validationError("ExpressionAttributeNames contains invalid value: Empty attribute name for key %s", k)
+			}
+		}
+		unused := []string{}
+		for k := range c.names {
+			if !c.usedNames[k] {
+				unused = append(unused, k)
+			}
+		}
+		if len(unused) > 0 {
+			sort.Strings(unused)
+			return validationError("Value provided in ExpressionAttributeNames unused in expressions: keys: {%s}", strings.Join(unused, ", "))
+		}
+	}
+	if c.values != nil {
+		if c.expressions == 0 {
+			return validationError("ExpressionAttributeValues can only be specified when using expressions")
+		}
+		if len(c.values) == 0 {
+			return validationError("ExpressionAttributeValues must not be empty")
+		}
+		for k, v := range c.values {
+			if !strings.HasPrefix(k, ":") || len(k) == 1 {
+				return validationError("ExpressionAttributeValues contains invalid key: Syntax error; key: \"%s\"", k)
+			}
+			if err := validateValue(v); err != nil {
+				return validationError("ExpressionAttributeValues contains invalid value: %s for key %s", awsMessage(err), k)
+			}
+		}
+		unused := []string{}
+		for k := range c.values {
+			if !c.usedValues[k] {
+				unused = append(unused, k)
+			}
+		}
+		if len(unused) > 0 {
+			sort.Strings(unused)
+			return validationError("Value provided in ExpressionAttributeValues unused in expressions: keys: {%s}", strings.Join(unused, ", "))
+		}
+	}
+	return nil
+}
+
+// condition parses the condition or filter expression, it returns nil if the expression is not given
+func (c *expressionContext) condition(kind string, expr *string) (*condition, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	p, err := c.newParser(kind, *expr)
+	if err != nil {
+		return nil, err
+	}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+	return cond, nil
+}
+
+// projection parses the projection expression, it returns nil if the expression is not given
+func (c *expressionContext) projection(expr *string) ([]docPath, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	p, err := c.newParser(projectionExpression, *expr)
+	if err != nil {
+		return nil, err
+	}
+	paths := []docPath{}
+	for {
+		path, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+	if err := checkOverlaps(projectionExpression, paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// update parses the update expression, it returns nil if the expression is not given
+func (c *expressionContext) update(expr *string) ([]*updateAction, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	p, err := c.newParser(updateExpression, *expr)
+	if err != nil {
+		return nil, err
+	}
+	actions := []*updateAction{}
+	seen := map[string]bool{}
+	for p.peek().kind != tokenEOF {
+		tok := p.next()
+		clause := strings.ToUpper(tok.text)
+		if tok.kind != tokenName || !isUpdateClause(clause) {
+			return nil, p.syntaxError(tok)
+		}
+		if seen[clause] {
+			return nil, p.error("The \"%s\" section can only be used once in an update expression;", clause)
+		}
+		seen[clause] = true
+		for {
+			action, err := p.parseUpdateAction(clause)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, action)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	paths := make([]docPath, len(actions))
+	for i, a := range actions {
+		paths[i] = a.path
+	}
+	if err := checkOverlaps(updateExpression, paths); err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
+
+// keyConditions parses the key condition expression into the conditions of each key attribute
+func (c *expressionContext) keyConditions(expr *string) ([]*keyCondition, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	cond, err := c.condition(keyConditionExpression, expr)
+	if err != nil {
+		return nil, err
+	}
+	conds := []*condition{cond}
+	if cond.op == "AND" {
+		conds = cond.conds
+		for _, sub := range conds {
+			if sub.op == "AND" {
+				return nil, validationError("KeyConditionExpressions must only contain one condition per key")
+			}
+		}
+	}
+	keyConds := []*keyCondition{}
+	names := map[string]bool{}
+	for _, sub := range conds {
+		switch sub.op {
+		case "=", "<", "<=", ">", ">=", "BETWEEN", "begins_with":
+		default:
+			return nil, validationError("Invalid operator used in KeyConditionExpression: %s", sub.op)
+		}
+		kc := &keyCondition{op: sub.op}
+		for i, o := range sub.operands {
+			switch {
+			case i == 0 && o.kind == operandPath:
+				if len(o.path) > 1 {
+					return nil, validationError("KeyConditionExpressions cannot have conditions on nested attributes")
+				}
+				kc.name = o.path[0].name
+			case i > 0 && o.kind == operandValue:
+				kc.values = append(kc.values, o.value)
+			default:
+				return nil, validationError("Invalid condition in KeyConditionExpression: %s operator must have the key attribute as its first operand and the values as the others", sub.op)
+			}
+		}
+		if names[kc.name] {
+			return nil, validationError("KeyConditionExpressions must only contain one condition per key")
+		}
+		names[kc.name] = true
+		keyConds = append(keyConds, kc)
+	}
+	return keyConds, nil
+}
+
+func isUpdateClause(s string) bool {
+	return s == "SET" || s == "REMOVE" || s == "ADD" || s == "DELETE"
+}
+
+// Kinds of the tokens
+const (
+	tokenEOF = iota
+	tokenName
+	tokenAttrName
+	tokenAttrValue
+	tokenNumber
+	tokenPunct
+)
+
+type token struct {
+	kind  int
+	text  string
+	start int
+	end   int
+}
+
+// parser is a recursive descent parser for the expression grammar
+type parser struct {
+	ctx    *expressionContext
+	kind   string
+	src    string
+	tokens []token
+	pos    int
+}
+
+func (c *expressionContext) newParser(kind, src string) (*parser, error) {
+	c.expressions++
+	p := &parser{ctx: c, kind: kind, src: src}
+	if strings.TrimSpace(src) == "" {
+		return nil, p.error("The expression can not be empty;")
+	}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func isNameChar(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+func (p *parser) tokenize() error {
+	src := p.src
+	for i := 0; i < len(src); {
+		b := src[i]
+		start := i
+		switch {
+		case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+			i++
+			continue
+		case b >= '0' && b <= '9':
+			for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokenNumber, text: src[start:i], start: start, end: i})
+		case isNameChar(b):
+			for i < len(src) && isNameChar(src[i]) {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokenName, text: src[start:i], start: start, end: i})
+		case b == '#' || b == ':':
+			i++
+			for i < len(src) && isNameChar(src[i]) {
+				i++
+			}
+			kind := tokenAttrName
+			if b == ':' {
+				kind = tokenAttrValue
+			}
+			tok := token{kind: kind, text: src[start:i], start: start, end: i}
+			if i == start+1 {
+				return p.syntaxError(tok)
+			}
+			p.tokens = append(p.tokens, tok)
+		case strings.HasPrefix(src[i:], "<>") || strings.HasPrefix(src[i:], "<=") || strings.HasPrefix(src[i:], ">="):
+			i += 2
+			p.tokens = append(p.tokens, token{kind: tokenPunct, text: src[start:i], start: start, end: i})
+		case strings.IndexByte("()[],.=<>+-", b) >= 0:
+			i++
+			p.tokens = append(p.tokens, token{kind: tokenPunct, text: src[start:i], start: start, end: i})
+		default:
+			return p.syntaxError(token{kind: tokenPunct, text: src[i : i+1], start: i, end: i + 1})
+		}
+	}
+	p.tokens = append(p.tokens, token{kind: tokenEOF, text: "<EOF>", start: len(src), end: len(src)})
+	return nil
+}
+
+func (p *parser) error(format string, a ...interface{}) error {
+	return validationError("Invalid %s: %s", p.kind, fmt.Sprintf(format, a...))
+}
+
+// syntaxError returns the syntax error with the token and the text around it
+func (p *parser) syntaxError(tok token) error {
+	start, end := tok.start, tok.end
+	for i, t := range p.tokens {
+		if t.start >= tok.start {
+			if i > 0 {
+				start = p.tokens[i-1].start
+			}
+			if t.start == tok.start && i+1 < len(p.tokens) {
+				end = p.tokens[i+1].end
+			}
+			break
+		}
+	}
+	return p.error("Syntax error; token: \"%s\", near: \"%s\"", tok.text, p.src[start:end])
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the punctuation if it is the next token
+func (p *parser) accept(punct string) bool {
+	if tok := p.peek(); tok.kind == tokenPunct && tok.text == punct {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.accept(punct) {
+		return p.syntaxError(p.peek())
+	}
+	return nil
+}
+
+// acceptKeyword consumes the keyword if it is the next token, keywords are case insensitive
+func (p *parser) acceptKeyword(keyword string) bool {
+	if tok := p.peek(); tok.kind == tokenName && strings.ToUpper(tok.text) == keyword {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectEOF() error {
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return p.syntaxError(tok)
+	}
+	return nil
+}
+
+// isFunction reports whether the next tokens are the function call
+func (p *parser) isFunction() bool {
+	return p.peek().kind == tokenName && p.pos+1 < len(p.tokens) &&
+		p.tokens[p.pos+1].kind == tokenPunct && p.tokens[p.pos+1].text == "("
+}
+
+func (p *parser) parseOr() (*condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &condition{op: "OR", conds: []*condition{left, right}}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (*condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &condition{op: "AND", conds: []*condition{left, right}}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (*condition, error) {
+	if p.acceptKeyword("NOT") {
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &condition{op: "NOT", conds: []*condition{cond}}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (*condition, error) {
+	if p.accept("(") {
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return cond, p.expect(")")
+	}
+	if p.isFunction() {
+		switch name := p.peek().text; name {
+		case "attribute_exists", "attribute_not_exists", "attribute_type", "begins_with", "contains":
+			return p.parseFunction()
+		case "size":
+		case "if_not_exists", "list_append":
+			return nil, p.error("The function is not allowed to be used this way in an expression; function: %s", name)
+		default:
+			return nil, p.error("Invalid function name; function: %s", name)
+		}
+	}
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenPunct && isComparator(tok.text):
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		cond := &condition{op: tok.text, operands: []*operand{left, right}}
+		return cond, p.checkComparable(cond)
+	case p.acceptKeyword("BETWEEN"):
+		lower, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.acceptKeyword("AND") {
+			return nil, p.syntaxError(p.peek())
+		}
+		upper, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		cond := &condition{op: "BETWEEN", operands: []*operand{left, lower, upper}}
+		if err := p.checkComparable(cond); err != nil {
+			return nil, err
+		}
+		if lower.kind == operandValue && upper.kind == operandValue && typeOf(lower.value) == typeOf(upper.value) && compareScalars(lower.value, upper.value) > 0 {
+			return nil, p.error("The BETWEEN operator requires upper bound to be greater than or equal to lower bound; lower bound operand: AttributeValue: {%s}, upper bound operand: AttributeValue: {%s}",
+				encodeValue(lower.value), encodeValue(upper.value))
+		}
+		return cond, nil
+	case p.acceptKeyword("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		cond := &condition{op: "IN", operands: []*operand{left}}
+		for {
+			o, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			cond.operands = append(cond.operands, o)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if len(cond.operands)-1 > maxInOperands {
+			return nil, p.error("Too many operands for IN; number of operands: %d, maximum: %d", len(cond.operands)-1, maxInOperands)
+		}
+		return cond, p.expect(")")
+	}
+	if left.kind == operandSize {
+		return nil, p.error("The function is not allowed to be used this way in an expression; function: size")
+	}
+	return nil, p.syntaxError(tok)
+}
+
+func isComparator(s string) bool {
+	switch s {
+	case "=", "<>", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+// checkComparable checks the value operands of the ordering operators are scalars
+func (p *parser) checkComparable(cond *condition) error {
+	if cond.op == "=" || cond.op == "<>" {
+		return nil
+	}
+	for _, o := range cond.operands {
+		if o.kind != operandValue {
+			continue
+		}
+		if typ := typeOf(o.value); typ != typeS && typ != typeN && typ != typeB {
+			return p.error("Incorrect operand type for operator or function; operator or function: %s, operand type: %s", cond.op, typeNames[typ])
+		}
+	}
+	return nil
+}
+
+// parseFunction parses the function call which is a condition by itself
+func (p *parser) parseFunction() (*condition, error) {
+	name := p.next().text
+	p.next()
+	cond := &condition{op: name}
+	if !p.accept(")") {
+		for {
+			o, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			cond.operands = append(cond.operands, o)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	want := 2
+	if name == "attribute_exists" || name == "attribute_not_exists" {
+		want = 1
+	}
+	if len(cond.operands) != want {
+		return nil, p.error("Incorrect number of operands for operator or function; operator or function: %s, number of operands: %d", name, len(cond.operands))
+	}
+	if name != "contains" && cond.operands[0].kind != operandPath {
+		return nil, p.error("Operator or function requires a document path; operator or function: %s", name)
+	}
+	if len(cond.operands) < 2 || cond.operands[1].kind != operandValue {
+		return cond, nil
+	}
+	v := cond.operands[1].value
+	switch name {
+	case "attribute_type":
+		if v.S == nil {
+			return nil, p.error("Incorrect operand type for operator or function; operator or function: %s, operand type: %s", name, typeNames[typeOf(v)])
+		}
+		if typeNames[*v.S] == "" {
+			return nil, p.error("Invalid attribute type name found; type: %s, valid types: { B,NULL,SS,BOOL,L,BS,N,NS,S,M }", *v.S)
+		}
+	case "begins_with":
+		if typ := typeOf(v); typ != typeS && typ != typeB {
+			return nil, p.error("Incorrect operand type for operator or function; operator or function: %s, operand type: %s", name, typeNames[typ])
+		}
+	}
+	return cond, nil
+}
+
+// parseOperand parses the operand of the conditions which is a path, a value or the size function
+func (p *parser) parseOperand() (*operand, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenAttrValue:
+		return p.parseValue()
+	case p.isFunction():
+		if tok.text != "size" {
+			switch tok.text {
+			case "attribute_exists", "attribute_not_exists", "attribute_type", "begins_with", "contains", "if_not_exists", "list_append":
+				return nil, p.error("The function is not allowed to be used this way in an expression; function: %s", tok.text)
+			}
+			return nil, p.error("Invalid function name; function: %s", tok.text)
+		}
+		p.next()
+		p.next()
+		arg, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if arg.kind != operandPath {
+			return nil, p.error("Operator or function requires a document path; operator or function: size")
+		}
+		return &operand{kind: operandSize, args: []*operand{arg}}, nil
+	}
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	return &operand{kind: operandPath, path: path}, nil
+}
+
+// parseValue parses the expression attribute value placeholder
+func (p *parser) parseValue() (*operand, error) {
+	tok := p.next()
+	if tok.kind != tokenAttrValue {
+		return nil, p.syntaxError(tok)
+	}
+	v, ok := p.ctx.values[tok.text]
+	if !ok {
+		return nil, p.error("An expression attribute value used in expression is not defined; attribute value: %s", tok.text)
+	}
+	p.ctx.usedValues[tok.text] = true
+	return &operand{kind: operandValue, value: v}, nil
+}
+
+// parsePath parses the document path with the names, the placeholders and the list indexes
+func (p *parser) parsePath() (docPath, error) {
+	path := docPath{}
+	for {
+		name, err := p.parsePathName()
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, pathElement{name: name})
+		for p.accept("[") {
+			tok := p.next()
+			if tok.kind != tokenNumber {
+				return nil, p.syntaxError(tok)
+			}
+			index, err := strconv.Atoi(tok.text)
+			if err != nil {
+				return nil, p.syntaxError(tok)
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			path = append(path, pathElement{index: index, isIndex: true})
+		}
+		if !p.accept(".") {
+			return path, nil
+		}
+	}
+}
+
+func (p *parser) parsePathName() (string, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenName:
+		if tok.text[0] >= '0' && tok.text[0] <= '9' {
+			return "", p.syntaxError(tok)
+		}
+		if isReservedWord(tok.text) {
+			return "", p.error("Attribute name is a reserved keyword; reserved keyword: %s", tok.text)
+		}
+		return tok.text, nil
+	case tokenAttrName:
+		name, ok := p.ctx.names[tok.text]
+		if !ok || name == nil {
+			return "", p.error("An expression attribute name used in the document path is not defined; attribute name: %s", tok.text)
+		}
+		p.ctx.usedNames[tok.text] = true
+		return *name, nil
+	}
+	return "", p.syntaxError(tok)
+}
+
+// parseUpdateAction parses a single action of the update clause
+func (p *parser) parseUpdateAction(clause string) (*updateAction, error) {
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	action := &updateAction{action: clause, path: path}
+	switch clause {
+	case "SET":
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		if action.value, err = p.parseSetValue(); err != nil {
+			return nil, err
+		}
+	case "ADD", "DELETE":
+		if action.value, err = p.parseValue(); err != nil {
+			return nil, err
+		}
+		typ := typeOf(action.value.value)
+		if (clause == "ADD" && typ != typeN && setTypeNames[typ] == "") || (clause == "DELETE" && setTypeNames[typ] == "") {
+			return nil, p.error("Incorrect operand type for operator or function; operator: %s, operand type: %s", clause, typeNames[typ])
+		}
+	}
+	return action, nil
+}
+
+// parseSetValue parses the value of the SET action which can be the sum or difference of two operands
+func (p *parser) parseSetValue() (*operand, error) {
+	left, err := p.parseSetOperand()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"+", "-"} {
+		if !p.accept(op) {
+			continue
+		}
+		right, err := p.parseSetOperand()
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range []*operand{left, right} {
+			if o.kind == operandValue && typeOf(o.value) != typeN {
+				return nil, p.error("Incorrect operand type for operator or function; operator or function: %s, operand type: %s", op, typeNames[typeOf(o.value)])
+			}
+		}
+		return &operand{kind: operandArithmetic, op: op, args: []*operand{left, right}}, nil
+	}
+	return left, nil
+}
+
+// parseSetOperand parses a path, a value or one of the update functions
+func (p *parser) parseSetOperand() (*operand, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenAttrValue:
+		return p.parseValue()
+	case p.isFunction():
+		var kind int
+		switch tok.text {
+		case "if_not_exists":
+			kind = operandIfNotExists
+		case "list_append":
+			kind = operandListAppend
+		case "attribute_exists", "attribute_not_exists", "attribute_type", "begins_with", "contains", "size":
+			return nil, p.error("The function is not allowed in an update expression; function: %s", tok.text)
+		default:
+			return nil, p.error("Invalid function name; function: %s", tok.text)
+		}
+		p.next()
+		p.next()
+		o := &operand{kind: kind}
+		for {
+			arg, err := p.parseSetOperand()
+			if err != nil {
+				return nil, err
+			}
+			o.args = append(o.args, arg)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if len(o.args) != 2 {
+			return nil, p.error("Incorrect number of operands for operator or function; operator or function: %s, number of operands: %d", tok.text, len(o.args))
+		}
+		if kind == operandIfNotExists && o.args[0].kind != operandPath {
+			return nil, p.error("Operator or function requires a document path; operator or function: %s", tok.text)
+		}
+		if kind == operandListAppend {
+			for _, arg := range o.args {
+				if arg.kind == operandValue && arg.value.L == nil {
+					return nil, p.error("Incorrect operand type for operator or function; operator or function: %s, operand type: %s", tok.text, typeNames[typeOf(arg.value)])
+				}
+			}
+		}
+		return o, nil
+	}
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	return &operand{kind: operandPath, path: path}, nil
+}
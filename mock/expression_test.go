@@ -0,0 +1,323 @@
+package mock
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+func testItem() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"id":    {S: aws.String("user#1")},
+		"age":   {N: aws.String("30")},
+		"tags":  {SS: aws.StringSlice([]string{"a", "b"})},
+		"nums":  {L: []*dynamodb.AttributeValue{{N: aws.String("1")}, {N: aws.String("2")}, {N: aws.String("3")}}},
+		"addr":  {M: map[string]*dynamodb.AttributeValue{"city": {S: aws.String("Seoul")}, "zip": {S: aws.String("04524")}}},
+		"admin": {BOOL: aws.Bool(true)},
+		"bin":   {B: []byte{1, 2, 3}},
+	}
+}
+
+func testValues() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		":n":    {N: aws.String("30.0")},
+		":m":    {N: aws.String("40")},
+		":s":    {S: aws.String("user")},
+		":a":    {S: aws.String("a")},
+		":city": {S: aws.String("Seoul")},
+		":t":    {S: aws.String("SS")},
+		":one":  {N: aws.String("1")},
+		":bin":  {B: []byte{2, 3}},
+		":bool": {BOOL: aws.Bool(true)},
+		":l":    {L: []*dynamodb.AttributeValue{{N: aws.String("4")}}},
+		":ss":   {SS: aws.StringSlice([]string{"b", "c"})},
+	}
+}
+
+// usedOnly returns the values which are used in the expression to pass the unused values validation
+func usedOnly(expr string, values map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	used := map[string]*dynamodb.AttributeValue{}
+	for k, v := range values {
+		if strings.Contains(expr+" ", k+" ") || strings.Contains(expr, k+")") || strings.Contains(expr, k+",") {
+			used[k] = v
+		}
+	}
+	if len(used) == 0 {
+		return nil
+	}
+	return used
+}
+
+func TestConditionExpression(t *testing.T) {
+	testCases := []struct {
+		expr  string
+		names map[string]*string
+		want  bool
+	}{
+		{expr: "age = :n", want: true},
+		{expr: "age <> :n", want: false},
+		{expr: "nope <> :n", want: true},
+		{expr: "age < :m AND age >= :n", want: true},
+		{expr: "age > :s", want: false},
+		{expr: "age BETWEEN :one AND :m", want: true},
+		{expr: "age IN (:one, :m, :n)", want: true},
+		{expr: "begins_with(id, :s)", want: true},
+		{expr: "contains(tags, :a)", want: true},
+		{expr: "contains(nums, :one)", want: true},
+		{expr: "contains(bin, :bin)", want: true},
+		{expr: "contains(id, :s) AND NOT contains(id, :a)", want: true},
+		{expr: "attribute_exists(addr.city) AND attribute_not_exists(addr.country)", want: true},
+		{expr: "attribute_type(tags, :t)", want: true},
+		{expr: "size(nums) = :m OR size(nums[0]) = :one", want: false},
+		{expr: "size(tags) < size(nums)", want: true},
+		{expr: "nums[1] > :one AND nums[5] = :one", want: false},
+		{expr: "(age = :m OR admin = :bool) AND #a.city = :city", names: map[string]*string{"#a": aws.String("addr")}, want: true},
+		{expr: "NOT (age = :m OR admin = :bool)", want: false},
+		{expr: "AGE = :n", want: false},
+	}
+	for i, tc := range testCases {
+		ctx := newExpressionContext(tc.names, usedOnly(tc.expr, testValues()))
+		cond, err := ctx.condition(conditionExpression, aws.String(tc.expr))
+		if err == nil {
+			err = ctx.validate()
+		}
+		if err != nil {
+			t.Errorf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+			continue
+		}
+		if got := cond.eval(testItem()); got != tc.want {
+			t.Errorf("[%d] Expecting %v for %s, got %v\n", i+1, tc.want, tc.expr, got)
+		}
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	testCases := []struct {
+		kind   string
+		expr   string
+		names  map[string]*string
+		values map[string]*dynamodb.AttributeValue
+		err    string
+	}{
+		{kind: conditionExpression, expr: "", err: "Invalid ConditionExpression: The expression can not be empty;"},
+		{kind: conditionExpression, expr: "a = ", values: testValues(), err: `Syntax error; token: "<EOF>", near: "= "`},
+		{kind: conditionExpression, expr: "a == :one", values: usedOnly(":one", testValues()), err: `Syntax error; token: "=", near: "== :one"`},
+		{kind: conditionExpression, expr: "status = :one", values: usedOnly(":one", testValues()), err: "Attribute name is a reserved keyword; reserved keyword: status"},
+		{kind: conditionExpression, expr: "a = :x", err: "An expression attribute value used in expression is not defined; attribute value: :x"},
+		{kind: conditionExpression, expr: "#x = :one", values: usedOnly(":one", testValues()), err: "An expression attribute name used in the document path is not defined; attribute name: #x"},
+		{kind: conditionExpression, expr: "a = :one", values: usedOnly(":one :m", testValues()), err: "Value provided in ExpressionAttributeValues unused in expressions: keys: {:m}"},
+		{kind: conditionExpression, expr: "a = :one", names: map[string]*string{"#a": aws.String("a")}, values: usedOnly(":one", testValues()), err: "Value provided in ExpressionAttributeNames unused in expressions: keys: {#a}"},
+		{kind: conditionExpression, expr: "foo(a)", err: "Invalid function name; function: foo"},
+		{kind: conditionExpression, expr: "size(a)", err: "The function is not allowed to be used this way in an expression; function: size"},
+		{kind: conditionExpression, expr: "begins_with(a, :one)", values: usedOnly(":one", testValues()), err: "Incorrect operand type for operator or function; operator or function: begins_with, operand type: NUMBER"},
+		{kind: conditionExpression, expr: "a < :bool", values: usedOnly(":bool", testValues()), err: "Incorrect operand type for operator or function; operator or function: <, operand type: BOOLEAN"},
+		{kind: conditionExpression, expr: "a BETWEEN :m AND :one", values: usedOnly(":m :one", testValues()), err: "The BETWEEN operator requires upper bound to be greater than or equal to lower bound"},
+		{kind: conditionExpression, expr: "attribute_exists(:one)", values: usedOnly(":one", testValues()), err: "Operator or function requires a document path; operator or function: attribute_exists"},
+		{kind: conditionExpression, expr: "attribute_type(a, :s)", values: usedOnly(":s", testValues()), err: "Invalid attribute type name found; type: user"},
+		{kind: projectionExpression, expr: "a, a.b", err: "Invalid ProjectionExpression: Two document paths overlap with each other; must remove or rewrite one of these paths; path one: [a], path two: [a, b]"},
+		{kind: updateExpression, expr: "SET a = :one SET b = :one", values: usedOnly(":one", testValues()), err: `The "SET" section can only be used once in an update expression;`},
+		{kind: updateExpression, expr: "SET a = :one REMOVE a", values: usedOnly(":one", testValues()), err: "Two document paths overlap with each other"},
+		{kind: updateExpression, expr: "ADD a :s", values: usedOnly(":s", testValues()), err: "Incorrect operand type for operator or function; operator: ADD, operand type: STRING"},
+		{kind: updateExpression, expr: "DELETE a :one", values: usedOnly(":one", testValues()), err: "Incorrect operand type for operator or function; operator: DELETE, operand type: NUMBER"},
+		{kind: updateExpression, expr: "SET a = b + :s", values: usedOnly(":s", testValues()), err: "Incorrect operand type for operator or function; operator or function: +, operand type: STRING"},
+		{kind: updateExpression, expr: "SET a = size(b)", err: "The function is not allowed in an update expression; function: size"},
+		{kind: updateExpression, expr: "PUT a = :one", values: usedOnly(":one", testValues()), err: `Syntax error; token: "PUT"`},
+	}
+	for i, tc := range testCases {
+		ctx := newExpressionContext(tc.names, tc.values)
+		var err error
+		switch tc.kind {
+		case projectionExpression:
+			_, err = ctx.projection(aws.String(tc.expr))
+		case updateExpression:
+			_, err = ctx.update(aws.String(tc.expr))
+		default:
+			_, err = ctx.condition(tc.kind, aws.String(tc.expr))
+		}
+		if err == nil {
+			err = ctx.validate()
+		}
+		if err == nil || errorCode(err) != errCodeValidationException || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("[%d] Expecting the error %q, got %v\n", i+1, tc.err, err)
+		}
+	}
+}
+
+func TestUpdateExpression(t *testing.T) {
+	testCases := []struct {
+		expr string
+		want map[string]interface{}
+		err  string
+	}{
+		{
+			expr: "SET age = age + :one, addr.city = :a, nums[1] = :m, nums[10] = :one REMOVE bin, admin, id, tags",
+			want: map[string]interface{}{
+				"age":  31,
+				"addr": map[string]interface{}{"city": "a", "zip": "04524"},
+				"nums": []interface{}{1, 40, 3, 1},
+			},
+		},
+		{
+			expr: "SET nums = list_append(nums, :l), cnt = if_not_exists(cnt, :one) - :m REMOVE bin, admin, id, tags, addr",
+			want: map[string]interface{}{
+				"age":  30,
+				"cnt":  -39,
+				"nums": []interface{}{1, 2, 3, 4},
+			},
+		},
+		{
+			expr: "REMOVE nums[0], nums[2], bin, admin, id, addr ADD age :one, tags :ss",
+			want: map[string]interface{}{
+				"age":  31,
+				"nums": []interface{}{2},
+				"tags": []interface{}{"a", "b", "c"},
+			},
+		},
+		{
+			expr: "DELETE tags :ss REMOVE bin, admin, id, addr, nums, age",
+			want: map[string]interface{}{
+				"tags": []interface{}{"a"},
+			},
+		},
+		{expr: "SET a = nope + :one", err: "The provided expression refers to an attribute that does not exist in the item"},
+		{expr: "SET a = id + :one", err: "An operand in the update expression has an incorrect data type"},
+		{expr: "SET nope.a = :one", err: "The document path provided in the update expression is invalid for update"},
+		{expr: "ADD id :one", err: "An operand in the update expression has an incorrect data type"},
+	}
+	for i, tc := range testCases {
+		ctx := newExpressionContext(nil, usedOnly(tc.expr, testValues()))
+		actions, err := ctx.update(aws.String(tc.expr))
+		if err != nil {
+			t.Errorf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+			continue
+		}
+		updated, err := applyUpdate(testItem(), actions)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("[%d] Expecting the error %q, got %v\n", i+1, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+			continue
+		}
+		want, _ := dynamodbattribute.MarshalMap(tc.want)
+		if tags, ok := tc.want["tags"]; ok {
+			want["tags"] = &dynamodb.AttributeValue{SS: aws.StringSlice(stringsOf(tags))}
+		}
+		if !equalValues(&dynamodb.AttributeValue{M: updated}, &dynamodb.AttributeValue{M: want}) {
+			t.Errorf("[%d] Expecting %v, got %v\n", i+1, want, updated)
+		}
+	}
+}
+
+func stringsOf(v interface{}) []string {
+	s := []string{}
+	for _, e := range v.([]interface{}) {
+		s = append(s, e.(string))
+	}
+	return s
+}
+
+func TestProjectionExpression(t *testing.T) {
+	ctx := newExpressionContext(map[string]*string{"#n": aws.String("nums")}, nil)
+	paths, err := ctx.projection(aws.String("age, addr.city, #n[2], #n[0], nope, tags[0]"))
+	if err == nil {
+		err = ctx.validate()
+	}
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	want, _ := dynamodbattribute.MarshalMap(map[string]interface{}{
+		"age":  30,
+		"addr": map[string]interface{}{"city": "Seoul"},
+		"nums": []interface{}{1, 3},
+	})
+	if got := project(testItem(), paths); !equalValues(&dynamodb.AttributeValue{M: got}, &dynamodb.AttributeValue{M: want}) {
+		t.Errorf("Expecting %v, got %v\n", want, got)
+	}
+}
+
+func TestKeyConditionExpression(t *testing.T) {
+	testCases := []struct {
+		expr string
+		want []string
+		err  string
+	}{
+		{expr: "pk = :s", want: []string{"pk ="}},
+		{expr: "pk = :s AND begins_with(sk, :a)", want: []string{"pk =", "sk begins_with"}},
+		{expr: "sk BETWEEN :one AND :m AND pk = :s", want: []string{"sk BETWEEN", "pk =", ""}},
+		{expr: "pk = :s OR sk = :a", err: "Invalid operator used in KeyConditionExpression: OR"},
+		{expr: "pk = :s AND pk = :a", err: "KeyConditionExpressions must only contain one condition per key"},
+		{expr: "contains(pk, :s)", err: "Invalid operator used in KeyConditionExpression: contains"},
+		{expr: "pk.a = :s", err: "KeyConditionExpressions cannot have conditions on nested attributes"},
+	}
+	for i, tc := range testCases {
+		ctx := newExpressionContext(nil, usedOnly(tc.expr, testValues()))
+		conds, err := ctx.keyConditions(aws.String(tc.expr))
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("[%d] Expecting the error %q, got %v\n", i+1, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+			continue
+		}
+		got := []string{}
+		for _, c := range conds {
+			got = append(got, c.name+" "+c.op)
+		}
+		if len(tc.want) > len(got) {
+			got = append(got, "")
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("[%d] Expecting %v, got %v\n", i+1, tc.want, got)
+		}
+	}
+}
+
+func TestScanExpressions(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "N")
+	items := []map[string]*dynamodb.AttributeValue{}
+	for i := 0; i < 10; i++ {
+		item, _ := dynamodbattribute.MarshalMap(map[string]interface{}{"pk": "p", "sk": i, "even": i%2 == 0})
+		items = append(items, item)
+	}
+	writeTestItems(t, client, "user", items)
+
+	output, err := client.Scan(&dynamodb.ScanInput{
+		TableName:                 aws.String("user"),
+		FilterExpression:          aws.String("#e = :t"),
+		ProjectionExpression:      aws.String("sk"),
+		ExpressionAttributeNames:  map[string]*string{"#e": aws.String("even")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":t": {BOOL: aws.Bool(true)}},
+		Limit:                     aws.Int64(6),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if *output.Count != 3 || *output.ScannedCount != 6 || len(output.LastEvaluatedKey) == 0 {
+		t.Errorf("Expecting 3 of 6 scanned items with the last evaluated key, got %d of %d\n", *output.Count, *output.ScannedCount)
+	}
+	for _, it := range output.Items {
+		if len(it) != 1 || it["sk"] == nil {
+			t.Errorf("Expecting the projected item, got %v\n", it)
+		}
+	}
+
+	_, err = client.Scan(&dynamodb.ScanInput{
+		TableName:            aws.String("user"),
+		ProjectionExpression: aws.String("sk"),
+		AttributesToGet:      aws.StringSlice([]string{"sk"}),
+	})
+	if errorCode(err) != errCodeValidationException {
+		t.Errorf("Expecting the validation error, got %v\n", err)
+	}
+}
@@ -0,0 +1,193 @@
+package mock
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// allOperations is the operation name which matches all the operations of the faults
+const allOperations = "*"
+
+// Faults configures the faults injected into the operations of the mock client
+// The same seed injects the same faults into the same sequence of the requests
+type Faults struct {
+	Seed int64
+
+	// ErrorRates are the rates of the internal server errors by the operation name
+	ErrorRates map[string]float64
+	// ThrottleRates are the rates of the provisioned throughput exceeded errors by the operation name
+	ThrottleRates map[string]float64
+	// UnprocessedRate is the rate of each request returned as unprocessed by BatchWriteItem and BatchGetItem
+	UnprocessedRate float64
+	// Latencies are the latency distributions by the operation name
+	Latencies map[string]Latency
+	// SimulateCapacity throttles the requests to the provisioned table once it consumed its capacity units in a second
+	SimulateCapacity bool
+}
+
+// Latency returns the random latency of an operation
+type Latency func(r *rand.Rand) time.Duration
+
+// FixedLatency returns the latency of the constant duration
+func FixedLatency(d time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		return d
+	}
+}
+
+// UniformLatency returns the latency uniformly distributed between the min and max
+func UniformLatency(min, max time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		return min + time.Duration(r.Int63n(int64(max-min)+1))
+	}
+}
+
+// NormalLatency returns the latency normally distributed with the mean and the standard deviation
+func NormalLatency(mean, stddev time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		d := time.Duration(r.NormFloat64()*float64(stddev)) + mean
+		if d < 0 {
+			return 0
+		}
+		return d
+	}
+}
+
+// capacityUsage is the consumed capacity units of a table in a second
+type capacityUsage struct {
+	second int64
+	read   float64
+	write  float64
+}
+
+// faultInjector injects the configured faults with its own random source
+type faultInjector struct {
+	faults *Faults
+	random *rand.Rand
+	usages map[string]*capacityUsage
+	mutex  *sync.Mutex
+}
+
+func newFaultInjector() *faultInjector {
+	return &faultInjector{
+		usages: map[string]*capacityUsage{},
+		mutex:  new(sync.Mutex),
+	}
+}
+
+// SetFaults configures the faults injected into the operations, nil disables the faults
+func (d *DynamoDBClient) SetFaults(faults *Faults) {
+	f := d.faults
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.faults = faults
+	f.usages = map[string]*capacityUsage{}
+	if faults != nil {
+		f.random = rand.New(rand.NewSource(faults.Seed))
+	}
+}
+
+// rateOf returns the rate of the operation falling back to the rate of all the operations
+func rateOf(rates map[string]float64, op string) float64 {
+	if r, ok := rates[op]; ok {
+		return r
+	}
+	return rates[allOperations]
+}
+
+// inject waits for the latency of the operation and returns the injected error if any
+func (f *faultInjector) inject(op string) error {
+	f.mutex.Lock()
+	if f.faults == nil {
+		f.mutex.Unlock()
+		return nil
+	}
+	latency, ok := f.faults.Latencies[op]
+	if !ok {
+		latency = f.faults.Latencies[allOperations]
+	}
+	wait := time.Duration(0)
+	if latency != nil {
+		wait = latency(f.random)
+	}
+	// Both are always rolled to keep the sequence of the random source regardless of the rates
+	failed := f.random.Float64() < rateOf(f.faults.ErrorRates, op)
+	throttled := f.random.Float64() < rateOf(f.faults.ThrottleRates, op)
+	f.mutex.Unlock()
+
+	time.Sleep(wait)
+	if failed {
+		return internalServerError()
+	}
+	if throttled {
+		return throughputExceededError()
+	}
+	return nil
+}
+
+// unprocessed reports whether the request of the batch operation should be returned as unprocessed
+func (f *faultInjector) unprocessed() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.faults == nil || f.faults.UnprocessedRate == 0 {
+		return false
+	}
+	return f.random.Float64() < f.faults.UnprocessedRate
+}
+
+// consume consumes the capacity units of the provisioned table and returns the throttling error if exhausted
+// A request is allowed while the units consumed in the current second of the client clock are under the capacity
+func (f *faultInjector) consume(c *consumedCapacity, read bool, now time.Time) error {
+	cs := consumedCapacities{}
+	cs.add(c)
+	return f.consumeAll(cs, read, now)
+}
+
+// consumeAll consumes the capacity units of all the tables of a transaction
+// Nothing is consumed if any of the tables is throttled
+func (f *faultInjector) consumeAll(cs consumedCapacities, read bool, now time.Time) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.faults == nil || !f.faults.SimulateCapacity {
+		return nil
+	}
+	second := now.Unix()
+	usages := map[*capacityUsage]float64{}
+	for name, c := range cs {
+		t := c.table
+		units := c.total()
+		if t.billingMode() == dynamodb.BillingModePayPerRequest || units == 0 {
+			continue
+		}
+		u, ok := f.usages[name]
+		if !ok || u.second != second {
+			u = &capacityUsage{second: second}
+			f.usages[name] = u
+		}
+		pt := t.desc.ProvisionedThroughput
+		if (read && u.read >= float64(*pt.ReadCapacityUnits)) || (!read && u.write >= float64(*pt.WriteCapacityUnits)) {
+			return throughputExceededError()
+		}
+		usages[u] = units
+	}
+	for u, units := range usages {
+		if read {
+			u.read += units
+		} else {
+			u.write += units
+		}
+	}
+	return nil
+}
+
+func internalServerError() error {
+	return awserr.New(dynamodb.ErrCodeInternalServerError, "Internal server error", nil)
+}
+
+func throughputExceededError() error {
+	return awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "The level of configured provisioned throughput for the table was exceeded. Consider increasing your provisioning level with the UpdateTable API.", nil)
+}
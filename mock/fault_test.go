@@ -0,0 +1,183 @@
+package mock
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestFaultsWithSeed(t *testing.T) {
+	errorsOf := func(seed int64) string {
+		client := NewDynamoDBClient()
+		createTestTable(t, client, "user", "")
+		client.SetFaults(&Faults{
+			Seed:          seed,
+			ErrorRates:    map[string]float64{"GetItem": 0.3},
+			ThrottleRates: map[string]float64{allOperations: 0.3},
+		})
+		codes := ""
+		for i := 0; i < 20; i++ {
+			_, err := client.GetItem(&dynamodb.GetItemInput{
+				TableName: aws.String("user"),
+				Key:       map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}},
+			})
+			switch errorCode(err) {
+			case dynamodb.ErrCodeInternalServerError:
+				codes += "E"
+			case dynamodb.ErrCodeProvisionedThroughputExceededException:
+				codes += "T"
+			default:
+				codes += "."
+			}
+		}
+		return codes
+	}
+	got := errorsOf(1)
+	if got != errorsOf(1) {
+		t.Errorf("Expecting the same faults with the same seed, got %s and %s\n", got, errorsOf(1))
+	}
+	if got == "...................." {
+		t.Errorf("Expecting the injected faults, got %s\n", got)
+	}
+}
+
+func TestFaultsUnprocessedItems(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "")
+	client.SetFaults(&Faults{Seed: 1, UnprocessedRate: 0.5})
+	reqs := []*dynamodb.WriteRequest{}
+	for i := 0; i < maxBatchWriteItems; i++ {
+		reqs = append(reqs, &dynamodb.WriteRequest{
+			PutRequest: &dynamodb.PutRequest{Item: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String(strconv.Itoa(i))}}},
+		})
+	}
+	unprocessed := map[string][]*dynamodb.WriteRequest{"user": reqs}
+	attempts := 0
+	for len(unprocessed["user"]) > 0 {
+		output, err := client.BatchWriteItem(&dynamodb.BatchWriteItemInput{RequestItems: unprocessed})
+		if err != nil {
+			t.Fatalf("There should be no errors, Got %s\n", err.Error())
+		}
+		unprocessed = output.UnprocessedItems
+		attempts++
+	}
+	if attempts < 2 {
+		t.Errorf("Expecting the unprocessed items to be retried, got %d attempts\n", attempts)
+	}
+	client.SetFaults(nil)
+	items, _ := scanAll(t, client, &dynamodb.ScanInput{TableName: aws.String("user")})
+	if len(items) != maxBatchWriteItems {
+		t.Errorf("Expecting %d, got %d\n", maxBatchWriteItems, len(items))
+	}
+}
+
+func TestFaultsCapacityAndLatency(t *testing.T) {
+	client := NewDynamoDBClient()
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(1),
+			WriteCapacityUnits: aws.Int64(1),
+		},
+		TableName: aws.String("user"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetFaults(&Faults{
+		SimulateCapacity: true,
+		Latencies:        map[string]Latency{"PutItem": FixedLatency(5 * time.Millisecond)},
+	})
+
+	start := time.Now()
+	throttled := 0
+	for i := 0; i < 5; i++ {
+		_, err := client.PutItem(&dynamodb.PutItemInput{
+			TableName: aws.String("user"),
+			Item:      map[string]*dynamodb.AttributeValue{"pk": {S: aws.String(strconv.Itoa(i))}},
+		})
+		if errorCode(err) == dynamodb.ErrCodeProvisionedThroughputExceededException {
+			throttled++
+		}
+	}
+	if throttled == 0 {
+		t.Errorf("Expecting the writes exceeding the capacity to be throttled\n")
+	}
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Errorf("Expecting the latency of %s at least, got %s\n", 25*time.Millisecond, elapsed)
+	}
+}
+
+func TestFaultsCapacityOfTransactions(t *testing.T) {
+	client := NewDynamoDBClient()
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	client.SetClock(clock.Now)
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(1),
+			WriteCapacityUnits: aws.Int64(1),
+		},
+		TableName: aws.String("user"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetFaults(&Faults{SimulateCapacity: true})
+	write := func(pk string) error {
+		_, err := client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+			TransactItems: []*dynamodb.TransactWriteItem{
+				{Put: &dynamodb.Put{TableName: aws.String("user"), Item: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String(pk)}}}},
+			},
+		})
+		return err
+	}
+	read := func(pk string) error {
+		_, err := client.TransactGetItems(&dynamodb.TransactGetItemsInput{
+			TransactItems: []*dynamodb.TransactGetItem{
+				{Get: &dynamodb.Get{TableName: aws.String("user"), Key: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String(pk)}}}},
+			},
+		})
+		return err
+	}
+
+	// The capacity is consumed in the second of the client clock
+	testCases := []struct {
+		call      func(pk string) error
+		pk        string
+		advance   time.Duration
+		throttled bool
+	}{
+		{call: write, pk: "a"},
+		{call: write, pk: "b", throttled: true},
+		{call: write, pk: "b", advance: time.Second},
+		{call: read, pk: "a"},
+		{call: read, pk: "a", throttled: true},
+		{call: read, pk: "a", advance: time.Second},
+	}
+	for i, tc := range testCases {
+		clock.Advance(tc.advance)
+		err := tc.call(tc.pk)
+		if throttled := errorCode(err) == dynamodb.ErrCodeProvisionedThroughputExceededException; throttled != tc.throttled || (!throttled && err != nil) {
+			t.Errorf("[%d] Expecting throttled %v, got %v\n", i+1, tc.throttled, err)
+		}
+	}
+	client.SetFaults(nil)
+	items, _ := scanAll(t, client, &dynamodb.ScanInput{TableName: aws.String("user")})
+	if len(items) != 2 {
+		t.Errorf("Expecting %d, got %d\n", 2, len(items))
+	}
+}
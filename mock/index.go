@@ -0,0 +1,335 @@
+package mock
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+)
+
+// index is a global or local secondary index of the table
+// The records keep the projected items and the sparse index has no records of the items without the index keys
+type index struct {
+	name string
+	store
+	local      bool
+	table      *table
+	projection *dynamodb.Projection
+	entries    map[string]*record // Records by the encoded primary key of the table item
+	itemCount  *int64
+	sizeBytes  *int64
+}
+
+func newIndex(t *table, name string, keySchema []*dynamodb.KeySchemaElement, projection *dynamodb.Projection, local bool, itemCount, sizeBytes *int64) (*index, error) {
+	if name == "" {
+		return nil, validationError("One or more parameter values were invalid: IndexName must be specified for the secondary index")
+	}
+	for _, idx := range t.indexes {
+		if idx.name == name {
+			return nil, validationError("One or more parameter values were invalid: Duplicate index name: %s", name)
+		}
+	}
+	hash, rng, err := keyAttributesOf(keySchema, t.desc.AttributeDefinitions)
+	if err != nil {
+		return nil, err
+	}
+	if local {
+		if t.rangeKey == nil {
+			return nil, validationError("One or more parameter values were invalid: Table KeySchema does not have a range key, which is required when specifying a LocalSecondaryIndex")
+		}
+		if hash.name != t.hashKey.name {
+			return nil, validationError("One or more parameter values were invalid: Index KeySchema does not have the same leading hash key as table KeySchema for index: %s. index hash key: %s, table hash key: %s", name, hash.name, t.hashKey.name)
+		}
+		if rng == nil {
+			return nil, validationError("One or more parameter values were invalid: Index KeySchema must have a range key for index: %s", name)
+		}
+	}
+	if projection == nil || projection.ProjectionType == nil {
+		return nil, validationError("One or more parameter values were invalid: Unknown ProjectionType: null")
+	}
+	switch *projection.ProjectionType {
+	case dynamodb.ProjectionTypeAll, dynamodb.ProjectionTypeKeysOnly:
+		if len(projection.NonKeyAttributes) > 0 {
+			return nil, validationError("One or more parameter values were invalid: ProjectionType is %s, but NonKeyAttributes is specified", *projection.ProjectionType)
+		}
+	case dynamodb.ProjectionTypeInclude:
+		if len(projection.NonKeyAttributes) == 0 {
+			return nil, validationError("One or more parameter values were invalid: ProjectionType is INCLUDE, but NonKeyAttributes is not specified")
+		}
+	default:
+		return nil, validationError("One or more parameter values were invalid: Unknown ProjectionType: %s", *projection.ProjectionType)
+	}
+	idx := &index{
+		name:       name,
+		store:      store{hashKey: hash, rangeKey: rng, records: []*record{}},
+		local:      local,
+		table:      t,
+		projection: projection,
+		entries:    map[string]*record{},
+		itemCount:  itemCount,
+		sizeBytes:  sizeBytes,
+	}
+	for _, r := range t.records {
+		idx.addItem(r.item, r.key)
+	}
+	return idx, nil
+}
+
+// entryKeyAttributes returns the key attributes of the index and the table which identify an index entry
+func (idx *index) entryKeyAttributes() []keyAttribute {
+	attrs := idx.keyAttributes()
+	for _, k := range idx.table.keyAttributes() {
+		if k.name != idx.hashKey.name && (idx.rangeKey == nil || k.name != idx.rangeKey.name) {
+			attrs = append(attrs, k)
+		}
+	}
+	return attrs
+}
+
+// validateItem checks the types of the index key attributes if the item has them
+func (idx *index) validateItem(item map[string]*dynamodb.AttributeValue) error {
+	for _, k := range idx.keyAttributes() {
+		v, ok := item[k.name]
+		if !ok {
+			continue
+		}
+		if typ := typeOf(v); typ != k.typ {
+			return validationError("One or more parameter values were invalid: Type mismatch for Index Key %s Expected: %s Actual: %s IndexName: %s", k.name, k.typ, typ, idx.name)
+		}
+		if (v.S != nil && len(*v.S) == 0) || (v.B != nil && len(v.B) == 0) {
+			return validationError("One or more parameter values are not valid. A value specified for a secondary index key is not supported. The AttributeValue for a key attribute cannot contain an empty %s value. IndexName: %s, IndexKey: %s", map[string]string{typeS: "string", typeB: "binary"}[k.typ], idx.name, k.name)
+		}
+	}
+	return nil
+}
+
+// project returns the attributes of the item projected into the index
+func (idx *index) project(item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if *idx.projection.ProjectionType == dynamodb.ProjectionTypeAll {
+		return item
+	}
+	projected := map[string]*dynamodb.AttributeValue{}
+	for _, k := range idx.entryKeyAttributes() {
+		projected[k.name] = item[k.name]
+	}
+	for _, a := range idx.projection.NonKeyAttributes {
+		if v, ok := item[*a]; ok {
+			projected[*a] = v
+		}
+	}
+	return projected
+}
+
+// contains reports whether the item has all the index key attributes
+func (idx *index) contains(item map[string]*dynamodb.AttributeValue) bool {
+	if item == nil {
+		return false
+	}
+	for _, k := range idx.keyAttributes() {
+		if typeOf(item[k.name]) != k.typ {
+			return false
+		}
+	}
+	return true
+}
+
+// addItem adds the entry of the item if it has all the index key attributes
+func (idx *index) addItem(item map[string]*dynamodb.AttributeValue, key string) {
+	if !idx.contains(item) {
+		return
+	}
+	r := idx.newRecord(idx.project(item), key)
+	idx.insert(r)
+	idx.entries[key] = r
+	*idx.itemCount++
+	*idx.sizeBytes += calc.ItemSize(r.item)
+}
+
+// removeItem removes the entry of the item if exists
+func (idx *index) removeItem(key string) {
+	r, ok := idx.entries[key]
+	if !ok {
+		return
+	}
+	idx.remove(r)
+	delete(idx.entries, key)
+	*idx.itemCount--
+	*idx.sizeBytes -= calc.ItemSize(r.item)
+}
+
+// writeUnits returns the write capacity units consumed on the index by replacing the old item with the new item
+// Changing the index key deletes the old entry and puts the new entry
+func (idx *index) writeUnits(old, new map[string]*dynamodb.AttributeValue) float64 {
+	oldIn, newIn := idx.contains(old), idx.contains(new)
+	switch {
+	case !oldIn && !newIn:
+		return 0
+	case !newIn:
+		return writeUnits(calc.ItemSize(idx.project(old)))
+	case !oldIn:
+		return writeUnits(calc.ItemSize(idx.project(new)))
+	}
+	for _, k := range idx.keyAttributes() {
+		if !equalValues(old[k.name], new[k.name]) {
+			return writeUnits(calc.ItemSize(idx.project(old))) + writeUnits(calc.ItemSize(idx.project(new)))
+		}
+	}
+	return writeUnits(calc.Max(calc.ItemSize(idx.project(old)), calc.ItemSize(idx.project(new))))
+}
+
+// indexArn returns the arn of the index of the table
+func indexArn(desc *dynamodb.TableDescription, name string) *string {
+	return aws.String(fmt.Sprintf("%s/index/%s", *desc.TableArn, name))
+}
+
+// globalSecondaryIndexDescription returns the description of the global secondary index to create
+func globalSecondaryIndexDescription(desc *dynamodb.TableDescription, gsi *dynamodb.GlobalSecondaryIndex) *dynamodb.GlobalSecondaryIndexDescription {
+	d := &dynamodb.GlobalSecondaryIndexDescription{
+		IndexArn:       indexArn(desc, aws.StringValue(gsi.IndexName)),
+		IndexName:      gsi.IndexName,
+		IndexSizeBytes: aws.Int64(0),
+		IndexStatus:    aws.String(dynamodb.IndexStatusActive),
+		ItemCount:      aws.Int64(0),
+		KeySchema:      gsi.KeySchema,
+		Projection:     gsi.Projection,
+	}
+	if gsi.ProvisionedThroughput == nil {
+		d.ProvisionedThroughput = zeroThroughput()
+	} else {
+		d.ProvisionedThroughput = &dynamodb.ProvisionedThroughputDescription{
+			NumberOfDecreasesToday: aws.Int64(0),
+			ReadCapacityUnits:      gsi.ProvisionedThroughput.ReadCapacityUnits,
+			WriteCapacityUnits:     gsi.ProvisionedThroughput.WriteCapacityUnits,
+		}
+	}
+	return d
+}
+
+// validateIndexThroughput checks the provisioned throughput of the global secondary index with the billing mode
+func validateIndexThroughput(billingMode string, name string, throughput *dynamodb.ProvisionedThroughput) error {
+	if billingMode == dynamodb.BillingModePayPerRequest && throughput != nil {
+		return validationError("One or more parameter values were invalid: ProvisionedThroughput should not be specified for index: %s when BillingMode is PAY_PER_REQUEST", name)
+	}
+	if billingMode != dynamodb.BillingModePayPerRequest && throughput == nil {
+		return validationError("One or more parameter values were invalid: ProvisionedThroughput must be specified for index: %s", name)
+	}
+	return nil
+}
+
+// updateGlobalSecondaryIndexes creates, deletes or updates the global secondary indexes of the table
+// The created index is backfilled immediately
+func (t *table) updateGlobalSecondaryIndexes(defs []*dynamodb.AttributeDefinition, updates []*dynamodb.GlobalSecondaryIndexUpdate) error {
+	for _, u := range updates {
+		switch {
+		case u.Create != nil:
+			name := aws.StringValue(u.Create.IndexName)
+			if err := validateIndexThroughput(t.billingMode(), name, u.Create.ProvisionedThroughput); err != nil {
+				return err
+			}
+			merged, err := mergeAttributeDefinitions(t.desc.AttributeDefinitions, defs)
+			if err != nil {
+				return err
+			}
+			desc := globalSecondaryIndexDescription(t.desc, &dynamodb.GlobalSecondaryIndex{
+				IndexName:             u.Create.IndexName,
+				KeySchema:             u.Create.KeySchema,
+				Projection:            u.Create.Projection,
+				ProvisionedThroughput: u.Create.ProvisionedThroughput,
+			})
+			current := t.desc.AttributeDefinitions
+			t.desc.AttributeDefinitions = merged
+			idx, err := newIndex(t, name, desc.KeySchema, desc.Projection, false, desc.ItemCount, desc.IndexSizeBytes)
+			if err != nil {
+				t.desc.AttributeDefinitions = current
+				return err
+			}
+			t.indexes = append(t.indexes, idx)
+			t.desc.GlobalSecondaryIndexes = append(t.desc.GlobalSecondaryIndexes, desc)
+		case u.Delete != nil:
+			name := aws.StringValue(u.Delete.IndexName)
+			found := false
+			for i, idx := range t.indexes {
+				if idx.name == name && !idx.local {
+					t.indexes = append(t.indexes[:i], t.indexes[i+1:]...)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return awserr.New(dynamodb.ErrCodeResourceNotFoundException, fmt.Sprintf("Requested resource not found: Index: %s not found", name), nil)
+			}
+			for i, gsi := range t.desc.GlobalSecondaryIndexes {
+				if *gsi.IndexName == name {
+					t.desc.GlobalSecondaryIndexes = append(t.desc.GlobalSecondaryIndexes[:i], t.desc.GlobalSecondaryIndexes[i+1:]...)
+					break
+				}
+			}
+			t.desc.AttributeDefinitions = t.usedAttributeDefinitions()
+		case u.Update != nil:
+			name := aws.StringValue(u.Update.IndexName)
+			var desc *dynamodb.GlobalSecondaryIndexDescription
+			for _, gsi := range t.desc.GlobalSecondaryIndexes {
+				if *gsi.IndexName == name {
+					desc = gsi
+				}
+			}
+			if desc == nil {
+				return awserr.New(dynamodb.ErrCodeResourceNotFoundException, fmt.Sprintf("Requested resource not found: Index: %s not found", name), nil)
+			}
+			if err := validateIndexThroughput(t.billingMode(), name, u.Update.ProvisionedThroughput); err != nil {
+				return err
+			}
+			desc.ProvisionedThroughput = &dynamodb.ProvisionedThroughputDescription{
+				NumberOfDecreasesToday: aws.Int64(0),
+				ReadCapacityUnits:      u.Update.ProvisionedThroughput.ReadCapacityUnits,
+				WriteCapacityUnits:     u.Update.ProvisionedThroughput.WriteCapacityUnits,
+			}
+		default:
+			return validationError("One or more parameter values were invalid: One of GlobalSecondaryIndexUpdate actions must be specified")
+		}
+	}
+	return nil
+}
+
+// mergeAttributeDefinitions returns the attribute definitions with the new ones added
+func mergeAttributeDefinitions(current, defs []*dynamodb.AttributeDefinition) ([]*dynamodb.AttributeDefinition, error) {
+	merged := append([]*dynamodb.AttributeDefinition{}, current...)
+	for _, d := range defs {
+		found := false
+		for _, c := range current {
+			if *c.AttributeName != *d.AttributeName {
+				continue
+			}
+			if *c.AttributeType != *d.AttributeType {
+				return nil, validationError("One or more parameter values were invalid: Cannot change the type of the attribute %s", *d.AttributeName)
+			}
+			found = true
+		}
+		if !found {
+			merged = append(merged, d)
+		}
+	}
+	return merged, nil
+}
+
+// usedAttributeDefinitions returns the attribute definitions used by the table and the indexes
+func (t *table) usedAttributeDefinitions() []*dynamodb.AttributeDefinition {
+	used := map[string]bool{}
+	for _, k := range t.keyAttributes() {
+		used[k.name] = true
+	}
+	for _, idx := range t.indexes {
+		for _, k := range idx.keyAttributes() {
+			used[k.name] = true
+		}
+	}
+	defs := []*dynamodb.AttributeDefinition{}
+	for _, d := range t.desc.AttributeDefinitions {
+		if used[*d.AttributeName] {
+			defs = append(defs, d)
+		}
+	}
+	return defs
+}
@@ -0,0 +1,261 @@
+package mock
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func createIndexTestTable(t *testing.T, client *DynamoDBClient) {
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("sk"), AttributeType: aws.String("N")},
+			{AttributeName: aws.String("team"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("score"), AttributeType: aws.String("N")},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+			{AttributeName: aws.String("sk"), KeyType: aws.String(dynamodb.KeyTypeRange)},
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String("team-index"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("team"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+					{AttributeName: aws.String("score"), KeyType: aws.String(dynamodb.KeyTypeRange)},
+				},
+				Projection: &dynamodb.Projection{
+					ProjectionType:   aws.String(dynamodb.ProjectionTypeInclude),
+					NonKeyAttributes: aws.StringSlice([]string{"name"}),
+				},
+			},
+		},
+		LocalSecondaryIndexes: []*dynamodb.LocalSecondaryIndex{
+			{
+				IndexName: aws.String("score-index"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("pk"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+					{AttributeName: aws.String("score"), KeyType: aws.String(dynamodb.KeyTypeRange)},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeKeysOnly)},
+			},
+		},
+		TableName: aws.String("player"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSecondaryIndexes(t *testing.T) {
+	client := NewDynamoDBClient()
+	createIndexTestTable(t, client)
+	items := []map[string]*dynamodb.AttributeValue{}
+	for i := 0; i < 10; i++ {
+		item := map[string]*dynamodb.AttributeValue{
+			"pk":    {S: aws.String("p" + strconv.Itoa(i%2))},
+			"sk":    {N: aws.String(strconv.Itoa(i))},
+			"name":  {S: aws.String("n" + strconv.Itoa(i))},
+			"extra": {S: aws.String("x")},
+		}
+		// Items without the team are not in the sparse global secondary index
+		if i < 8 {
+			item["team"] = &dynamodb.AttributeValue{S: aws.String("t")}
+			item["score"] = &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(100 - i))}
+		}
+		items = append(items, item)
+	}
+	writeTestItems(t, client, "player", items)
+
+	// Query the global secondary index with the pagination
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String("player"),
+		IndexName:                 aws.String("team-index"),
+		KeyConditionExpression:    aws.String("team = :t AND score > :s"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":t": {S: aws.String("t")}, ":s": {N: aws.String("93")}},
+		Limit:                     aws.Int64(2),
+	}
+	got := []string{}
+	for {
+		output, err := client.Query(input)
+		if err != nil {
+			t.Fatalf("There should be no errors, Got %s\n", err.Error())
+		}
+		for _, it := range output.Items {
+			if _, ok := it["extra"]; ok || it["name"] == nil {
+				t.Errorf("Expecting the projected attributes only, got %v\n", it)
+			}
+			got = append(got, *it["score"].N)
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		if len(output.LastEvaluatedKey) != 4 {
+			t.Errorf("Expecting the index and table keys in the last evaluated key, got %v\n", output.LastEvaluatedKey)
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+	if strings.Join(got, ",") != "94,95,96,97,98,99,100" {
+		t.Errorf("Expecting %s, got %s\n", "94,95,96,97,98,99,100", strings.Join(got, ","))
+	}
+
+	// Updates and deletes keep the index entries current
+	client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:        aws.String("player"),
+		Key:              map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("p0")}, "sk": {N: aws.String("0")}},
+		UpdateExpression: aws.String("REMOVE team"),
+	})
+	client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String("player"),
+		Key:       map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("p1")}, "sk": {N: aws.String("1")}},
+	})
+	client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String("player"),
+		Key:                       map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("p0")}, "sk": {N: aws.String("8")}},
+		UpdateExpression:          aws.String("SET team = :t, score = :s"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":t": {S: aws.String("t")}, ":s": {N: aws.String("1")}},
+	})
+	scanned, err := client.Scan(&dynamodb.ScanInput{
+		TableName: aws.String("player"),
+		IndexName: aws.String("team-index"),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if *scanned.Count != 7 {
+		t.Errorf("Expecting %d, got %d\n", 7, *scanned.Count)
+	}
+	desc, _ := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String("player")})
+	if *desc.Table.GlobalSecondaryIndexes[0].ItemCount != 7 {
+		t.Errorf("Expecting %d, got %d\n", 7, *desc.Table.GlobalSecondaryIndexes[0].ItemCount)
+	}
+
+	// The local secondary index fetches the non projected attributes from the table
+	queried, err := client.Query(&dynamodb.QueryInput{
+		TableName:                 aws.String("player"),
+		IndexName:                 aws.String("score-index"),
+		KeyConditionExpression:    aws.String("pk = :p"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":p": {S: aws.String("p0")}},
+		Select:                    aws.String(dynamodb.SelectAllAttributes),
+		ScanIndexForward:          aws.Bool(false),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if *queried.Count != 5 || *queried.Items[0]["sk"].N != "0" || queried.Items[0]["extra"] == nil {
+		t.Errorf("Expecting the table items in the index order, got %v\n", queried.Items)
+	}
+}
+
+func TestUpdateTableIndexes(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "")
+	writeTestItems(t, client, "user", []map[string]*dynamodb.AttributeValue{
+		{"pk": {S: aws.String("a")}, "email": {S: aws.String("a@example.com")}},
+		{"pk": {S: aws.String("b")}},
+	})
+
+	// The created index is backfilled from the existing items
+	output, err := client.UpdateTable(&dynamodb.UpdateTableInput{
+		TableName: aws.String("user"),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("email"), AttributeType: aws.String("S")},
+		},
+		GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{
+			{
+				Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+					IndexName: aws.String("email-index"),
+					KeySchema: []*dynamodb.KeySchemaElement{
+						{AttributeName: aws.String("email"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+					},
+					Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if len(output.TableDescription.GlobalSecondaryIndexes) != 1 || *output.TableDescription.GlobalSecondaryIndexes[0].ItemCount != 1 {
+		t.Errorf("Expecting the backfilled index, got %v\n", output.TableDescription.GlobalSecondaryIndexes)
+	}
+
+	// The deleted index removes its attribute definitions
+	output, err = client.UpdateTable(&dynamodb.UpdateTableInput{
+		TableName: aws.String("user"),
+		GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{
+			{Delete: &dynamodb.DeleteGlobalSecondaryIndexAction{IndexName: aws.String("email-index")}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if len(output.TableDescription.GlobalSecondaryIndexes) != 0 || len(output.TableDescription.AttributeDefinitions) != 1 {
+		t.Errorf("Expecting the index to be deleted, got %v\n", output.TableDescription)
+	}
+}
+
+func TestSecondaryIndexErrors(t *testing.T) {
+	client := NewDynamoDBClient()
+	createIndexTestTable(t, client)
+	testCases := []struct {
+		fn  func() error
+		err string
+	}{
+		{
+			fn: func() error {
+				_, err := client.PutItem(&dynamodb.PutItemInput{
+					TableName: aws.String("player"),
+					Item:      map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}, "sk": {N: aws.String("1")}, "team": {N: aws.String("1")}},
+				})
+				return err
+			},
+			err: "Type mismatch for Index Key team Expected: S Actual: N IndexName: team-index",
+		},
+		{
+			fn: func() error {
+				_, err := client.Query(&dynamodb.QueryInput{
+					TableName:                 aws.String("player"),
+					IndexName:                 aws.String("unknown"),
+					KeyConditionExpression:    aws.String("pk = :p"),
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":p": {S: aws.String("a")}},
+				})
+				return err
+			},
+			err: "The table does not have the specified index: unknown",
+		},
+		{
+			fn: func() error {
+				_, err := client.Scan(&dynamodb.ScanInput{
+					TableName:      aws.String("player"),
+					IndexName:      aws.String("team-index"),
+					ConsistentRead: aws.Bool(true),
+				})
+				return err
+			},
+			err: "Consistent reads are not supported on global secondary indexes",
+		},
+		{
+			fn: func() error {
+				_, err := client.Scan(&dynamodb.ScanInput{
+					TableName: aws.String("player"),
+					IndexName: aws.String("team-index"),
+					Select:    aws.String(dynamodb.SelectAllAttributes),
+				})
+				return err
+			},
+			err: "One or more parameter values were invalid: Select type ALL_ATTRIBUTES is not supported for global secondary index team-index because its projection type is not ALL",
+		},
+	}
+	for i, tc := range testCases {
+		err := tc.fn()
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("[%d] Expecting the error %q, got %v\n", i+1, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package mock
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+)
+
+// checkCondition evaluates the condition against the current item, the missing item is an empty item
+func checkCondition(cond *condition, item map[string]*dynamodb.AttributeValue) error {
+	if cond == nil {
+		return nil
+	}
+	if item == nil {
+		item = map[string]*dynamodb.AttributeValue{}
+	}
+	if !cond.eval(item) {
+		return conditionalCheckFailedError()
+	}
+	return nil
+}
+
+func validateReturnValues(rv *string, allowed ...string) error {
+	if rv == nil {
+		return nil
+	}
+	for _, a := range append(allowed, dynamodb.ReturnValueNone) {
+		if *rv == a {
+			return nil
+		}
+	}
+	return validationError("Return values set to invalid value")
+}
+
+// returnedItem returns the copy of the item if the return values option asks for it
+func returnedItem(rv *string, item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if rv == nil || *rv == dynamodb.ReturnValueNone || len(item) == 0 {
+		return nil
+	}
+	return copyItem(item)
+}
+
+// validateActions checks the update actions do not change the key attributes
+func (t *table) validateActions(actions []*updateAction) error {
+	for _, a := range actions {
+		for _, k := range t.keyAttributes() {
+			if a.path[0].name == k.name {
+				return validationError("One or more parameter values were invalid: Cannot update attribute %s. This attribute is part of the key", k.name)
+			}
+		}
+	}
+	return nil
+}
+
+// legacyUpdateActions returns the update actions of the legacy AttributeUpdates parameter
+// PUT is the default action, and DELETE without the value removes the attribute
+func legacyUpdateActions(updates map[string]*dynamodb.AttributeValueUpdate) ([]*updateAction, error) {
+	names := make([]string, 0, len(updates))
+	for name := range updates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	actions := []*updateAction{}
+	for _, name := range names {
+		u := updates[name]
+		action := dynamodb.AttributeActionPut
+		if u != nil && u.Action != nil {
+			action = *u.Action
+		}
+		var value *dynamodb.AttributeValue
+		if u != nil {
+			value = u.Value
+		}
+		path := docPath{{name: name}}
+		switch {
+		case action == dynamodb.AttributeActionDelete && value == nil:
+			actions = append(actions, &updateAction{action: "REMOVE", path: path})
+			continue
+		case action != dynamodb.AttributeActionPut && action != dynamodb.AttributeActionAdd && action != dynamodb.AttributeActionDelete:
+			return nil, validationError("1 validation error detected: Value '%s' at 'attributeUpdates.%s.member.action' failed to satisfy constraint: Member must satisfy enum value set: [ADD, PUT, DELETE]", action, name)
+		case value == nil:
+			return nil, validationError("One or more parameter values were invalid: Only DELETE action is allowed when no attribute value is specified")
+		}
+		if err := validateValue(value); err != nil {
+			return nil, err
+		}
+		typ := typeOf(value)
+		if (action == dynamodb.AttributeActionAdd && typ != typeN && setTypeNames[typ] == "") || (action == dynamodb.AttributeActionDelete && setTypeNames[typ] == "") {
+			return nil, validationError("One or more parameter values were invalid: %s action is not supported for the type %s", action, typ)
+		}
+		switch action {
+		case dynamodb.AttributeActionPut:
+			actions = append(actions, &updateAction{action: "SET", path: path, value: &operand{kind: operandValue, value: value}})
+		default:
+			actions = append(actions, &updateAction{action: action, path: path, value: &operand{kind: operandValue, value: value}})
+		}
+	}
+	return actions, nil
+}
+
+// updatedItem returns the item with the update actions applied, the missing item starts from the key
+func (t *table) updatedItem(key, old map[string]*dynamodb.AttributeValue, actions []*updateAction) (map[string]*dynamodb.AttributeValue, error) {
+	base := old
+	if base == nil {
+		base = key
+	}
+	updated, err := applyUpdate(base, actions)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.validateItem(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// GetItem is mocking the dynamodb GetItem operation
+func (d *DynamoDBClient) GetItem(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	if err := d.faults.inject("GetItem"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t, err := d.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	ctx := newExpressionContext(input.ExpressionAttributeNames, nil)
+	projection, err := projectionOf(ctx, input.ProjectionExpression, input.AttributesToGet)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateReturnConsumedCapacity(input.ReturnConsumedCapacity); err != nil {
+		return nil, err
+	}
+	if err := t.validateKey(input.Key); err != nil {
+		return nil, err
+	}
+	item := t.get(input.Key)
+	consumed := readCapacity(t, nil, calc.ItemSize(item), input.ConsistentRead)
+	if err := d.faults.consume(consumed, true, d.now()); err != nil {
+		return nil, err
+	}
+	output := &dynamodb.GetItemOutput{
+		ConsumedCapacity: consumed.output(input.ReturnConsumedCapacity),
+	}
+	if item != nil {
+		output.Item = projectItem(item, projection)
+	}
+	return output, nil
+}
+
+// PutItem is mocking the dynamodb PutItem operation
+func (d *DynamoDBClient) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	if err := d.faults.inject("PutItem"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t, err := d.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	ctx := newExpressionContext(input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	cond, err := ctx.condition(conditionExpression, input.ConditionExpression)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateReturnValues(input.ReturnValues, dynamodb.ReturnValueAllOld); err != nil {
+		return nil, err
+	}
+	if err := validateReturnConsumedCapacity(input.ReturnConsumedCapacity); err != nil {
+		return nil, err
+	}
+	if err := t.validateItem(input.Item); err != nil {
+		return nil, err
+	}
+	current := t.get(input.Item)
+	if err := checkCondition(cond, current); err != nil {
+		return nil, err
+	}
+	consumed := t.writeCapacity(current, input.Item)
+	if err := d.faults.consume(consumed, false, d.now()); err != nil {
+		return nil, err
+	}
+	old := t.put(input.Item)
+	return &dynamodb.PutItemOutput{
+		Attributes:       returnedItem(input.ReturnValues, old),
+		ConsumedCapacity: consumed.output(input.ReturnConsumedCapacity),
+	}, nil
+}
+
+// DeleteItem is mocking the dynamodb DeleteItem operation
+func (d *DynamoDBClient) DeleteItem(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	if err := d.faults.inject("DeleteItem"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t, err := d.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	ctx := newExpressionContext(input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	cond, err := ctx.condition(conditionExpression, input.ConditionExpression)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateReturnValues(input.ReturnValues, dynamodb.ReturnValueAllOld); err != nil {
+		return nil, err
+	}
+	if err := validateReturnConsumedCapacity(input.ReturnConsumedCapacity); err != nil {
+		return nil, err
+	}
+	if err := t.validateKey(input.Key); err != nil {
+		return nil, err
+	}
+	current := t.get(input.Key)
+	if err := checkCondition(cond, current); err != nil {
+		return nil, err
+	}
+	consumed := t.writeCapacity(current, nil)
+	if err := d.faults.consume(consumed, false, d.now()); err != nil {
+		return nil, err
+	}
+	old := t.delete(input.Key)
+	return &dynamodb.DeleteItemOutput{
+		Attributes:       returnedItem(input.ReturnValues, old),
+		ConsumedCapacity: consumed.output(input.ReturnConsumedCapacity),
+	}, nil
+}
+
+// UpdateItem is mocking the dynamodb UpdateItem operation
+// The item is created with the key attributes if it does not exist
+func (d *DynamoDBClient) UpdateItem(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	if err := d.faults.inject("UpdateItem"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t, err := d.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if input.UpdateExpression != nil && input.AttributeUpdates != nil {
+		return nil, validationError("Can not use both expression and non-expression parameters in the same request: Non-expression parameters: {AttributeUpdates} Expression parameters: {UpdateExpression}")
+	}
+	ctx := newExpressionContext(input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	actions, err := ctx.update(input.UpdateExpression)
+	if err != nil {
+		return nil, err
+	}
+	if input.AttributeUpdates != nil {
+		if actions, err = legacyUpdateActions(input.AttributeUpdates); err != nil {
+			return nil, err
+		}
+	}
+	cond, err := ctx.condition(conditionExpression, input.ConditionExpression)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateReturnValues(input.ReturnValues, dynamodb.ReturnValueAllOld, dynamodb.ReturnValueUpdatedOld, dynamodb.ReturnValueAllNew, dynamodb.ReturnValueUpdatedNew); err != nil {
+		return nil, err
+	}
+	if err := validateReturnConsumedCapacity(input.ReturnConsumedCapacity); err != nil {
+		return nil, err
+	}
+	if err := t.validateKey(input.Key); err != nil {
+		return nil, err
+	}
+	if err := t.validateActions(actions); err != nil {
+		return nil, err
+	}
+
+	old := t.get(input.Key)
+	if err := checkCondition(cond, old); err != nil {
+		return nil, err
+	}
+	updated, err := t.updatedItem(input.Key, old, actions)
+	if err != nil {
+		return nil, err
+	}
+	consumed := t.writeCapacity(old, updated)
+	if err := d.faults.consume(consumed, false, d.now()); err != nil {
+		return nil, err
+	}
+	t.put(updated)
+
+	output := &dynamodb.UpdateItemOutput{
+		ConsumedCapacity: consumed.output(input.ReturnConsumedCapacity),
+	}
+	if input.ReturnValues == nil {
+		return output, nil
+	}
+	paths := make([]docPath, len(actions))
+	for i, a := range actions {
+		paths[i] = a.path
+	}
+	switch *input.ReturnValues {
+	case dynamodb.ReturnValueAllOld:
+		output.Attributes = returnedItem(input.ReturnValues, old)
+	case dynamodb.ReturnValueAllNew:
+		output.Attributes = returnedItem(input.ReturnValues, updated)
+	case dynamodb.ReturnValueUpdatedOld:
+		if old != nil {
+			output.Attributes = returnedItem(input.ReturnValues, project(old, paths))
+		}
+	case dynamodb.ReturnValueUpdatedNew:
+		output.Attributes = returnedItem(input.ReturnValues, project(updated, paths))
+	}
+	return output, nil
+}
@@ -0,0 +1,159 @@
+package mock
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestItemOperations(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "")
+	key := map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}}
+
+	// Conditional put only succeeds when the item does not exist
+	put := &dynamodb.PutItemInput{
+		TableName:           aws.String("user"),
+		Item:                map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}, "n": {N: aws.String("1")}},
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	}
+	if _, err := client.PutItem(put); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if _, err := client.PutItem(put); errorCode(err) != dynamodb.ErrCodeConditionalCheckFailedException {
+		t.Errorf("Expecting the conditional check failure, got %v\n", err)
+	}
+
+	// Update with the return values
+	updated, err := client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String("user"),
+		Key:                       key,
+		UpdateExpression:          aws.String("SET n = n + :inc, tags = :tags"),
+		ConditionExpression:       aws.String("n < :max"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":inc": {N: aws.String("2")}, ":max": {N: aws.String("10")}, ":tags": {SS: aws.StringSlice([]string{"x"})}},
+		ReturnValues:              aws.String(dynamodb.ReturnValueUpdatedNew),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if len(updated.Attributes) != 2 || *updated.Attributes["n"].N != "3" {
+		t.Errorf("Expecting the updated attributes, got %v\n", updated.Attributes)
+	}
+	_, err = client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String("user"),
+		Key:                       key,
+		UpdateExpression:          aws.String("SET pk = :v"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":v": {S: aws.String("b")}},
+	})
+	if errorCode(err) != errCodeValidationException {
+		t.Errorf("Expecting the validation error on updating the key, got %v\n", err)
+	}
+
+	// Update creates the item if it does not exist
+	_, err = client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String("user"),
+		Key:                       map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("b")}},
+		UpdateExpression:          aws.String("ADD visits :one"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":one": {N: aws.String("1")}},
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	got, _ := client.GetItem(&dynamodb.GetItemInput{
+		TableName:            aws.String("user"),
+		Key:                  map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("b")}},
+		ProjectionExpression: aws.String("visits"),
+	})
+	if len(got.Item) != 1 || *got.Item["visits"].N != "1" {
+		t.Errorf("Expecting the created item, got %v\n", got.Item)
+	}
+
+	// Delete with the old item returned
+	deleted, err := client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName:    aws.String("user"),
+		Key:          key,
+		ReturnValues: aws.String(dynamodb.ReturnValueAllOld),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if *deleted.Attributes["n"].N != "3" {
+		t.Errorf("Expecting the deleted item, got %v\n", deleted.Attributes)
+	}
+	got, _ = client.GetItem(&dynamodb.GetItemInput{TableName: aws.String("user"), Key: key})
+	if got.Item != nil {
+		t.Errorf("The item should be deleted, got %v\n", got.Item)
+	}
+	_, err = client.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String("user"),
+		Key:       map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}, "n": {N: aws.String("1")}},
+	})
+	if errorCode(err) != errCodeValidationException {
+		t.Errorf("Expecting the validation error on the invalid key, got %v\n", err)
+	}
+}
+
+func TestLegacyAttributeUpdates(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "")
+	key := map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}}
+
+	updated, err := client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String("user"),
+		Key:       key,
+		AttributeUpdates: map[string]*dynamodb.AttributeValueUpdate{
+			"name":   {Value: &dynamodb.AttributeValue{S: aws.String("kim")}},
+			"visits": {Action: aws.String(dynamodb.AttributeActionAdd), Value: &dynamodb.AttributeValue{N: aws.String("2")}},
+			"tags":   {Action: aws.String(dynamodb.AttributeActionAdd), Value: &dynamodb.AttributeValue{SS: aws.StringSlice([]string{"x", "y"})}},
+		},
+		ReturnValues: aws.String(dynamodb.ReturnValueAllNew),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if len(updated.Attributes) != 4 || *updated.Attributes["name"].S != "kim" || *updated.Attributes["visits"].N != "2" || len(updated.Attributes["tags"].SS) != 2 {
+		t.Errorf("Expecting the updated item, got %v\n", updated.Attributes)
+	}
+
+	_, err = client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String("user"),
+		Key:       key,
+		AttributeUpdates: map[string]*dynamodb.AttributeValueUpdate{
+			"name":   {Action: aws.String(dynamodb.AttributeActionDelete)},
+			"visits": {Action: aws.String(dynamodb.AttributeActionAdd), Value: &dynamodb.AttributeValue{N: aws.String("1")}},
+			"tags":   {Action: aws.String(dynamodb.AttributeActionDelete), Value: &dynamodb.AttributeValue{SS: aws.StringSlice([]string{"x"})}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	output, _ := client.GetItem(&dynamodb.GetItemInput{TableName: aws.String("user"), Key: key})
+	if output.Item["name"] != nil || *output.Item["visits"].N != "3" || len(output.Item["tags"].SS) != 1 || *output.Item["tags"].SS[0] != "y" {
+		t.Errorf("Expecting the updated item, got %v\n", output.Item)
+	}
+
+	errCases := []map[string]*dynamodb.AttributeValueUpdate{
+		{"name": {Action: aws.String(dynamodb.AttributeActionPut)}},
+		{"name": {Action: aws.String("SET"), Value: &dynamodb.AttributeValue{S: aws.String("kim")}}},
+		{"name": {Action: aws.String(dynamodb.AttributeActionDelete), Value: &dynamodb.AttributeValue{S: aws.String("kim")}}},
+		{"name": {Action: aws.String(dynamodb.AttributeActionAdd), Value: &dynamodb.AttributeValue{S: aws.String("kim")}}},
+		{"pk": {Value: &dynamodb.AttributeValue{S: aws.String("b")}}},
+	}
+	for i, updates := range errCases {
+		_, err := client.UpdateItem(&dynamodb.UpdateItemInput{TableName: aws.String("user"), Key: key, AttributeUpdates: updates})
+		if errorCode(err) != errCodeValidationException {
+			t.Errorf("[%d] Expecting the validation error, got %v\n", i+1, err)
+		}
+	}
+	_, err = client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String("user"),
+		Key:                       key,
+		UpdateExpression:          aws.String("SET visits = :v"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":v": {N: aws.String("1")}},
+		AttributeUpdates:          map[string]*dynamodb.AttributeValueUpdate{"name": {Value: &dynamodb.AttributeValue{S: aws.String("kim")}}},
+	})
+	if errorCode(err) != errCodeValidationException {
+		t.Errorf("Expecting the validation error on mixing the parameters, got %v\n", err)
+	}
+}
@@ -0,0 +1,183 @@
+package mock
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// matchKeyCondition reports whether the key value satisfies the key condition
+func matchKeyCondition(kc *keyCondition, v *dynamodb.AttributeValue) bool {
+	if v == nil {
+		return false
+	}
+	switch kc.op {
+	case "=":
+		return compareScalars(v, kc.values[0]) == 0
+	case "<":
+		return compareScalars(v, kc.values[0]) < 0
+	case "<=":
+		return compareScalars(v, kc.values[0]) <= 0
+	case ">":
+		return compareScalars(v, kc.values[0]) > 0
+	case ">=":
+		return compareScalars(v, kc.values[0]) >= 0
+	case "BETWEEN":
+		return compareScalars(v, kc.values[0]) >= 0 && compareScalars(v, kc.values[1]) <= 0
+	case "begins_with":
+		if v.S != nil {
+			return strings.HasPrefix(*v.S, *kc.values[0].S)
+		}
+		return bytes.HasPrefix(v.B, kc.values[0].B)
+	}
+	return false
+}
+
+// queryConditions returns the conditions of the partition key and the sort key (optional) of the table or index
+func (s *store) queryConditions(conds []*keyCondition) (*keyCondition, *keyCondition, error) {
+	var hashCond, rangeCond *keyCondition
+	for _, kc := range conds {
+		var key keyAttribute
+		switch {
+		case kc.name == s.hashKey.name:
+			if kc.op != "=" {
+				return nil, nil, validationError("Query key condition not supported")
+			}
+			hashCond, key = kc, s.hashKey
+		case s.rangeKey != nil && kc.name == s.rangeKey.name:
+			rangeCond, key = kc, *s.rangeKey
+		default:
+			missed := s.hashKey.name
+			if s.rangeKey != nil {
+				missed = s.rangeKey.name
+			}
+			return nil, nil, validationError("Query condition missed key schema element: %s", missed)
+		}
+		for _, v := range kc.values {
+			if typeOf(v) != key.typ {
+				return nil, nil, validationError("One or more parameter values were invalid: Condition parameter type does not match schema type")
+			}
+		}
+	}
+	if hashCond == nil {
+		return nil, nil, validationError("Query condition missed key schema element: %s", s.hashKey.name)
+	}
+	return hashCond, rangeCond, nil
+}
+
+// Query is mocking the dynamodb Query operation
+func (d *DynamoDBClient) Query(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	if err := d.faults.inject("Query"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t, err := d.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateLimit(input.Limit); err != nil {
+		return nil, err
+	}
+	if input.KeyConditionExpression == nil {
+		return nil, validationError("Either the KeyConditions or KeyConditionExpression parameter must be specified in the request.")
+	}
+	if input.FilterExpression != nil && input.QueryFilter != nil {
+		return nil, validationError("Can not use both expression and non-expression parameters in the same request: Non-expression parameters: {QueryFilter} Expression parameters: {FilterExpression}")
+	}
+	ctx := newExpressionContext(input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	conds, err := ctx.keyConditions(input.KeyConditionExpression)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := ctx.condition(filterExpression, input.FilterExpression)
+	if err != nil {
+		return nil, err
+	}
+	projection, err := projectionOf(ctx, input.ProjectionExpression, input.AttributesToGet)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateSelect(input.Select, projection); err != nil {
+		return nil, err
+	}
+	if err := validateReturnConsumedCapacity(input.ReturnConsumedCapacity); err != nil {
+		return nil, err
+	}
+	src, err := t.source(input.IndexName, input.ConsistentRead, input.Select, projection)
+	if err != nil {
+		return nil, err
+	}
+	hashCond, rangeCond, err := src.queryConditions(conds)
+	if err != nil {
+		return nil, err
+	}
+	if filter != nil {
+		for _, p := range filter.paths() {
+			for _, k := range src.keyAttributes() {
+				if p[0].name == k.name {
+					return nil, validationError("Filter Expression can only contain non-primary key attributes: Primary key attribute: %s", k.name)
+				}
+			}
+		}
+	}
+
+	forward := input.ScanIndexForward == nil || *input.ScanIndexForward
+	lo, hi := src.partition(hashCond.values[0])
+	pos := lo
+	if !forward {
+		pos = hi - 1
+	}
+	if len(input.ExclusiveStartKey) > 0 {
+		if err := src.validateStartKey(input.ExclusiveStartKey); err != nil {
+			return nil, err
+		}
+		if !equalValues(input.ExclusiveStartKey[src.hashKey.name], hashCond.values[0]) {
+			return nil, validationError("The provided starting key is outside query boundaries based on provided conditions")
+		}
+		i, found := src.search(src.newRecord(input.ExclusiveStartKey, t.keyString(input.ExclusiveStartKey)))
+		if forward && found {
+			i++
+		} else if !forward {
+			i--
+		}
+		pos = i
+	}
+	p := src.paginate(input.Limit, func() *record {
+		for pos >= lo && pos < hi {
+			r := src.records[pos]
+			if forward {
+				pos++
+			} else {
+				pos--
+			}
+			if rangeCond == nil || matchKeyCondition(rangeCond, r.item[src.rangeKey.name]) {
+				return r
+			}
+		}
+		return nil
+	}, filter)
+	consumed := readCapacity(t, src.index, p.scannedBytes, input.ConsistentRead)
+	if err := d.faults.consume(consumed, true, d.now()); err != nil {
+		return nil, err
+	}
+
+	output := &dynamodb.QueryOutput{
+		ConsumedCapacity: consumed.output(input.ReturnConsumedCapacity),
+		Count:            aws.Int64(int64(len(p.items))),
+		ScannedCount:     aws.Int64(p.scannedCount),
+		LastEvaluatedKey: p.lastEvaluatedKey,
+	}
+	if input.Select == nil || *input.Select != dynamodb.SelectCount {
+		output.Items = make([]map[string]*dynamodb.AttributeValue, len(p.items))
+		for i, it := range p.items {
+			output.Items[i] = projectItem(it, projection)
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,113 @@
+package mock
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestQuery(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "event", "N")
+	items := []map[string]*dynamodb.AttributeValue{}
+	for _, pk := range []string{"a", "b", "c"} {
+		for i := 0; i < 10; i++ {
+			items = append(items, map[string]*dynamodb.AttributeValue{
+				"pk":  {S: aws.String(pk)},
+				"sk":  {N: aws.String(strconv.Itoa(i))},
+				"odd": {BOOL: aws.Bool(i%2 == 1)},
+			})
+		}
+	}
+	writeTestItems(t, client, "event", items)
+
+	testCases := []struct {
+		expr    string
+		filter  string
+		forward bool
+		limit   int64
+		want    string
+	}{
+		{expr: "pk = :b", forward: true, want: "0,1,2,3,4,5,6,7,8,9"},
+		{expr: "pk = :b", forward: false, limit: 3, want: "9,8,7,6,5,4,3,2,1,0"},
+		{expr: "pk = :b AND sk BETWEEN :two AND :five", forward: true, limit: 2, want: "2,3,4,5"},
+		{expr: "sk > :five AND pk = :b", forward: false, want: "9,8,7,6"},
+		{expr: "pk = :b AND sk <= :two", filter: "odd = :t", forward: true, limit: 1, want: "1"},
+	}
+	for i, tc := range testCases {
+		values := map[string]*dynamodb.AttributeValue{
+			":b":    {S: aws.String("b")},
+			":two":  {N: aws.String("2")},
+			":five": {N: aws.String("5")},
+			":t":    {BOOL: aws.Bool(true)},
+		}
+		input := &dynamodb.QueryInput{
+			TableName:                 aws.String("event"),
+			KeyConditionExpression:    aws.String(tc.expr),
+			ExpressionAttributeValues: usedOnly(tc.expr+" "+tc.filter, values),
+			ScanIndexForward:          aws.Bool(tc.forward),
+		}
+		if tc.filter != "" {
+			input.FilterExpression = aws.String(tc.filter)
+		}
+		if tc.limit > 0 {
+			input.Limit = aws.Int64(tc.limit)
+		}
+		got := []string{}
+		for {
+			output, err := client.Query(input)
+			if err != nil {
+				t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+			}
+			for _, it := range output.Items {
+				if *it["pk"].S != "b" {
+					t.Errorf("[%d] Expecting the items of the partition b, got %s\n", i+1, *it["pk"].S)
+				}
+				got = append(got, *it["sk"].N)
+			}
+			if len(output.LastEvaluatedKey) == 0 {
+				break
+			}
+			input.ExclusiveStartKey = output.LastEvaluatedKey
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("[%d] Expecting %s, got %s\n", i+1, tc.want, strings.Join(got, ","))
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "event", "N")
+	testCases := []struct {
+		expr   string
+		filter string
+		err    string
+	}{
+		{expr: "sk = :n", err: "Query condition missed key schema element: pk"},
+		{expr: "pk > :s", err: "Query key condition not supported"},
+		{expr: "pk = :n", err: "Condition parameter type does not match schema type"},
+		{expr: "pk = :s AND extra = :n", err: "Query condition missed key schema element"},
+		{expr: "pk = :s", filter: "sk = :n", err: "Filter Expression can only contain non-primary key attributes: Primary key attribute: sk"},
+	}
+	for i, tc := range testCases {
+		input := &dynamodb.QueryInput{
+			TableName:              aws.String("event"),
+			KeyConditionExpression: aws.String(tc.expr),
+			ExpressionAttributeValues: usedOnly(tc.expr+" "+tc.filter, map[string]*dynamodb.AttributeValue{
+				":s": {S: aws.String("a")},
+				":n": {N: aws.String("1")},
+			}),
+		}
+		if tc.filter != "" {
+			input.FilterExpression = aws.String(tc.filter)
+		}
+		_, err := client.Query(input)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("[%d] Expecting the error %q, got %v\n", i+1, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package mock
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+)
+
+const (
+	maxPageSize      = 1 << 20
+	maxTotalSegments = 1000000
+)
+
+// page holds the evaluated items of a single page of the scan or query
+type page struct {
+	items            []map[string]*dynamodb.AttributeValue
+	scannedCount     int64
+	scannedBytes     int64
+	lastEvaluatedKey map[string]*dynamodb.AttributeValue
+}
+
+// source is the table or the index to read by the scan or query
+type source struct {
+	*store
+	index  *index                                              // Index to read, nil for the table
+	keys   []keyAttribute                                      // Key attributes of the last evaluated key
+	itemOf func(r *record) map[string]*dynamodb.AttributeValue // Item to return for the record
+}
+
+func recordItem(r *record) map[string]*dynamodb.AttributeValue {
+	return r.item
+}
+
+// source returns the table or the index of the name to read
+// The items of the local secondary index are fetched from the table when the other attributes are requested
+func (t *table) source(indexName *string, consistentRead *bool, sel *string, projection []docPath) (*source, error) {
+	if indexName == nil {
+		if sel != nil && *sel == dynamodb.SelectAllProjectedAttributes {
+			return nil, validationError("ALL_PROJECTED_ATTRIBUTES can be used only when Querying using an IndexName")
+		}
+		return &source{store: &t.store, keys: t.keyAttributes(), itemOf: recordItem}, nil
+	}
+	idx, err := t.index(*indexName)
+	if err != nil {
+		return nil, err
+	}
+	if !idx.local && aws.BoolValue(consistentRead) {
+		return nil, validationError("Consistent reads are not supported on global secondary indexes")
+	}
+	src := &source{store: &idx.store, index: idx, keys: idx.entryKeyAttributes(), itemOf: recordItem}
+	if *idx.projection.ProjectionType == dynamodb.ProjectionTypeAll {
+		return src, nil
+	}
+	allAttributes := sel != nil && *sel == dynamodb.SelectAllAttributes
+	if allAttributes && !idx.local {
+		return nil, validationError("One or more parameter values were invalid: Select type ALL_ATTRIBUTES is not supported for global secondary index %s because its projection type is not ALL", idx.name)
+	}
+	if idx.local && (allAttributes || len(projection) > 0) {
+		src.itemOf = func(r *record) map[string]*dynamodb.AttributeValue {
+			return t.keys[r.key].item
+		}
+	}
+	return src, nil
+}
+
+// validateStartKey checks the exclusive start key has exactly the key attributes of the source
+func (src *source) validateStartKey(key map[string]*dynamodb.AttributeValue) error {
+	if len(key) != len(src.keys) {
+		return validationError("The provided starting key is invalid: The provided key element does not match the schema")
+	}
+	for _, k := range src.keys {
+		if v, ok := key[k.name]; !ok || typeOf(v) != k.typ {
+			return validationError("The provided starting key is invalid: The provided key element does not match the schema")
+		}
+	}
+	return nil
+}
+
+// paginate evaluates the records in order until the limit or 1MB of data is read
+// The next function returns nil when there are no more records, and the filter is applied after reading
+func (src *source) paginate(limit *int64, next func() *record, filter *condition) *page {
+	p := &page{items: []map[string]*dynamodb.AttributeValue{}}
+	for r := next(); r != nil; r = next() {
+		item := src.itemOf(r)
+		p.scannedCount++
+		p.scannedBytes += calc.ItemSize(item)
+		if filter == nil || filter.eval(item) {
+			p.items = append(p.items, item)
+		}
+		if (limit != nil && p.scannedCount >= *limit) || p.scannedBytes >= maxPageSize {
+			p.lastEvaluatedKey = keyOf(r.item, src.keys)
+			break
+		}
+	}
+	return p
+}
+
+// projectionOf returns the document paths of the projection expression or the legacy attributes to get
+func projectionOf(ctx *expressionContext, expr *string, attributesToGet []*string) ([]docPath, error) {
+	if expr != nil && attributesToGet != nil {
+		return nil, validationError("Can not use both expression and non-expression parameters in the same request: Non-expression parameters: {AttributesToGet} Expression parameters: {ProjectionExpression}")
+	}
+	paths, err := ctx.projection(expr)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attributesToGet {
+		paths = append(paths, docPath{{name: *a}})
+	}
+	return paths, nil
+}
+
+// projectItem returns the copy of the item with the given document paths only
+func projectItem(item map[string]*dynamodb.AttributeValue, paths []docPath) map[string]*dynamodb.AttributeValue {
+	if len(paths) == 0 {
+		return copyItem(item)
+	}
+	return project(item, paths)
+}
+
+func validateSelect(sel *string, projection []docPath) error {
+	if sel == nil {
+		return nil
+	}
+	switch *sel {
+	case dynamodb.SelectAllAttributes, dynamodb.SelectAllProjectedAttributes, dynamodb.SelectCount:
+		if len(projection) > 0 {
+			return validationError("Cannot specify the AttributesToGet when choosing to get only the %s", *sel)
+		}
+	case dynamodb.SelectSpecificAttributes:
+		if len(projection) == 0 {
+			return validationError("Must specify the AttributesToGet or ProjectionExpression when choosing to get SPECIFIC_ATTRIBUTES")
+		}
+	default:
+		return validationError("1 validation error detected: Value '%s' at 'select' failed to satisfy constraint: Member must satisfy enum value set: [SPECIFIC_ATTRIBUTES, COUNT, ALL_ATTRIBUTES, ALL_PROJECTED_ATTRIBUTES]", *sel)
+	}
+	return nil
+}
+
+func validateLimit(limit *int64) error {
+	if limit != nil && *limit < 1 {
+		return validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value greater than or equal to 1", *limit)
+	}
+	return nil
+}
+
+// Scan is mocking the dynamodb Scan operation
+func (d *DynamoDBClient) Scan(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	if err := d.faults.inject("Scan"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t, err := d.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateLimit(input.Limit); err != nil {
+		return nil, err
+	}
+	if input.FilterExpression != nil && input.ScanFilter != nil {
+		return nil, validationError("Can not use both expression and non-expression parameters in the same request: Non-expression parameters: {ScanFilter} Expression parameters: {FilterExpression}")
+	}
+	ctx := newExpressionContext(input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	filter, err := ctx.condition(filterExpression, input.FilterExpression)
+	if err != nil {
+		return nil, err
+	}
+	projection, err := projectionOf(ctx, input.ProjectionExpression, input.AttributesToGet)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateSelect(input.Select, projection); err != nil {
+		return nil, err
+	}
+	if err := validateReturnConsumedCapacity(input.ReturnConsumedCapacity); err != nil {
+		return nil, err
+	}
+	src, err := t.source(input.IndexName, input.ConsistentRead, input.Select, projection)
+	if err != nil {
+		return nil, err
+	}
+	segment, totalSegments := int64(0), int64(1)
+	if (input.Segment == nil) != (input.TotalSegments == nil) {
+		return nil, validationError("The TotalSegments parameter is required but was not present in the request when Segment parameter is present")
+	}
+	if input.TotalSegments != nil {
+		segment, totalSegments = *input.Segment, *input.TotalSegments
+		if totalSegments < 1 || totalSegments > maxTotalSegments {
+			return nil, validationError("1 validation error detected: Value '%d' at 'totalSegments' failed to satisfy constraint: Member must have value less than or equal to %d", totalSegments, maxTotalSegments)
+		}
+		if segment < 0 || segment >= totalSegments {
+			return nil, validationError("The Segment parameter is zero-based and must be less than parameter TotalSegments: Segment: %d is not less than TotalSegments: %d", segment, totalSegments)
+		}
+	}
+
+	// Records of a segment are contiguous since the segment is decided by the token
+	pos := sort.Search(len(src.records), func(i int) bool {
+		return segmentOf(src.records[i].token, totalSegments) >= segment
+	})
+	if len(input.ExclusiveStartKey) > 0 {
+		if err := src.validateStartKey(input.ExclusiveStartKey); err != nil {
+			return nil, err
+		}
+		start := src.newRecord(input.ExclusiveStartKey, t.keyString(input.ExclusiveStartKey))
+		if segmentOf(start.token, totalSegments) != segment {
+			return nil, validationError("The provided starting key is invalid: The provided key element does not match the segment")
+		}
+		i, found := src.search(start)
+		if found {
+			i++
+		}
+		pos = i
+	}
+	p := src.paginate(input.Limit, func() *record {
+		if pos >= len(src.records) || segmentOf(src.records[pos].token, totalSegments) != segment {
+			return nil
+		}
+		pos++
+		return src.records[pos-1]
+	}, filter)
+	consumed := readCapacity(t, src.index, p.scannedBytes, input.ConsistentRead)
+	if err := d.faults.consume(consumed, true, d.now()); err != nil {
+		return nil, err
+	}
+
+	output := &dynamodb.ScanOutput{
+		ConsumedCapacity: consumed.output(input.ReturnConsumedCapacity),
+		Count:            aws.Int64(int64(len(p.items))),
+		ScannedCount:     aws.Int64(p.scannedCount),
+		LastEvaluatedKey: p.lastEvaluatedKey,
+	}
+	if input.Select == nil || *input.Select != dynamodb.SelectCount {
+		output.Items = make([]map[string]*dynamodb.AttributeValue, len(p.items))
+		for i, it := range p.items {
+			output.Items[i] = projectItem(it, projection)
+		}
+	}
+	return output, nil
+}
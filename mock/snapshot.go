@@ -0,0 +1,134 @@
+package mock
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/awsjson"
+)
+
+// snapshot is all the tables in the dynamodb JSON format
+type snapshot struct {
+	_ struct{} `type:"structure"`
+
+	Tables []*snapshotTable `type:"list"`
+}
+
+// snapshotTable is the description and the items of a table
+type snapshotTable struct {
+	_ struct{} `type:"structure"`
+
+	Table      *dynamodb.TableDescription            `type:"structure"`
+	TimeToLive *dynamodb.TimeToLiveDescription       `type:"structure"`
+	Items      []map[string]*dynamodb.AttributeValue `type:"list"`
+}
+
+// Save writes the snapshot of all the tables to the writer
+func (d *DynamoDBClient) Save(w io.Writer) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	names := make([]string, 0, len(d.tables))
+	for name := range d.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s := &snapshot{Tables: []*snapshotTable{}}
+	for _, name := range names {
+		t := d.tables[name]
+		items := make([]map[string]*dynamodb.AttributeValue, len(t.records))
+		for i, r := range t.records {
+			items[i] = r.item
+		}
+		s.Tables = append(s.Tables, &snapshotTable{Table: t.desc, TimeToLive: t.ttl, Items: items})
+	}
+	b, err := awsjson.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// Load replaces all the tables with the snapshot read from the reader
+// An empty snapshot has no tables
+func (d *DynamoDBClient) Load(r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s := &snapshot{}
+	if len(bytes.TrimSpace(b)) > 0 {
+		if err := awsjson.Unmarshal(b, s); err != nil {
+			return err
+		}
+	}
+	tables := map[string]*table{}
+	for _, st := range s.Tables {
+		// The counters are rebuilt by putting the items
+		desc := st.Table
+		desc.ItemCount, desc.TableSizeBytes = aws.Int64(0), aws.Int64(0)
+		for _, gsi := range desc.GlobalSecondaryIndexes {
+			gsi.ItemCount, gsi.IndexSizeBytes = aws.Int64(0), aws.Int64(0)
+		}
+		for _, lsi := range desc.LocalSecondaryIndexes {
+			lsi.ItemCount, lsi.IndexSizeBytes = aws.Int64(0), aws.Int64(0)
+		}
+		t, err := newTable(desc)
+		if err != nil {
+			return err
+		}
+		for _, item := range st.Items {
+			if err := t.validateItem(item); err != nil {
+				return err
+			}
+			t.put(item)
+		}
+		t.ttl = st.TimeToLive
+		tables[*desc.TableName] = t
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.tables = tables
+	d.clientTokens = map[string]*clientToken{}
+	// The stream records are not saved, the tables with the stream get new streams
+	d.streams = nil
+	for _, st := range s.Tables {
+		if spec := st.Table.StreamSpecification; spec != nil && aws.BoolValue(spec.StreamEnabled) {
+			d.enableStream(tables[*st.Table.TableName], aws.StringValue(spec.StreamViewType))
+		}
+	}
+	return nil
+}
+
+// SaveFile writes the snapshot to the file, replacing it only after the snapshot is completely written
+func (d *DynamoDBClient) SaveFile(path string) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := d.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// LoadFile replaces all the tables with the snapshot file
+func (d *DynamoDBClient) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return d.Load(f)
+}
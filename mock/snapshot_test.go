@@ -0,0 +1,72 @@
+package mock
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.json")
+
+	client := NewDynamoDBClient()
+	createIndexTestTable(t, client)
+	createTestTable(t, client, "user", "")
+	items := []map[string]*dynamodb.AttributeValue{}
+	for i := 0; i < 10; i++ {
+		items = append(items, map[string]*dynamodb.AttributeValue{
+			"pk":    {S: aws.String("p")},
+			"sk":    {N: aws.String(strconv.Itoa(i))},
+			"team":  {S: aws.String("t")},
+			"score": {N: aws.String(strconv.Itoa(i % 3))},
+			"bin":   {B: []byte{byte(i)}},
+		})
+	}
+	writeTestItems(t, client, "player", items)
+	if err := client.SaveFile(path); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+
+	loaded := NewDynamoDBClient()
+	if err := loaded.LoadFile(path); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	tables, _ := loaded.ListTables(&dynamodb.ListTablesInput{})
+	if len(tables.TableNames) != 2 {
+		t.Errorf("Expecting %d, got %d\n", 2, len(tables.TableNames))
+	}
+	want, _ := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String("player")})
+	got, _ := loaded.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String("player")})
+	if *got.Table.ItemCount != 10 || *got.Table.TableSizeBytes != *want.Table.TableSizeBytes || *got.Table.GlobalSecondaryIndexes[0].ItemCount != 10 {
+		t.Errorf("Expecting %v, got %v\n", want.Table, got.Table)
+	}
+	queried, err := loaded.Query(&dynamodb.QueryInput{
+		TableName:                 aws.String("player"),
+		IndexName:                 aws.String("team-index"),
+		KeyConditionExpression:    aws.String("team = :t AND score = :s"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":t": {S: aws.String("t")}, ":s": {N: aws.String("0")}},
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if *queried.Count != 4 {
+		t.Errorf("Expecting %d, got %d\n", 4, *queried.Count)
+	}
+	item, _ := loaded.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String("player"),
+		Key:       map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("p")}, "sk": {N: aws.String("7")}},
+	})
+	if len(item.Item["bin"].B) != 1 || item.Item["bin"].B[0] != 7 {
+		t.Errorf("Expecting the binary value, got %v\n", item.Item)
+	}
+}
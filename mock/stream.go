@@ -0,0 +1,494 @@
+package mock
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+)
+
+const (
+	maxShardRecords       = 1000 // Records of a shard before it is closed and split into a child shard
+	maxGetRecordsLimit    = 1000
+	maxListStreamsLimit   = 100
+	maxDescribeShardLimit = 100
+	shardIteratorLifetime = 15 * time.Minute
+
+	streamEventSource  = "aws:dynamodb"
+	streamEventVersion = "1.1"
+)
+
+// shard keeps the stream records of a range of the sequence numbers
+type shard struct {
+	id       string
+	parentID string
+	start    int64
+	end      int64 // Ending sequence number, zero while the shard is open
+	records  []*dynamodbstreams.Record
+}
+
+// stream records the item level changes of a table
+type stream struct {
+	arn       string
+	label     string
+	tableName string
+	keySchema []*dynamodb.KeySchemaElement
+	viewType  string
+	created   time.Time
+	enabled   bool
+	sequence  int64 // Last assigned sequence number
+	shards    []*shard
+	now       func() time.Time
+}
+
+func sequenceNumber(n int64) string {
+	return fmt.Sprintf("%021d", n)
+}
+
+// openShard returns the shard which the new records are appended to
+func (s *stream) openShard() *shard {
+	last := s.shards[len(s.shards)-1]
+	if last.end == 0 {
+		return last
+	}
+	sh := &shard{
+		id:       fmt.Sprintf("shardId-%020d-%08x", s.now().UnixNano()/int64(time.Millisecond), len(s.shards)+1),
+		parentID: last.id,
+		start:    s.sequence + 1,
+	}
+	s.shards = append(s.shards, sh)
+	return sh
+}
+
+// close closes the open shard, the records of the closed shards are still readable
+func (s *stream) close() {
+	last := s.shards[len(s.shards)-1]
+	if last.end == 0 {
+		last.end = s.sequence
+		if last.end < last.start {
+			last.end = last.start
+		}
+	}
+}
+
+// append records the change of the item, the old or new item is nil when it is inserted or removed
+// The identity is recorded for the deletions by the service, nil for the user changes
+func (s *stream) append(key, oldItem, newItem map[string]*dynamodb.AttributeValue, identity *dynamodbstreams.Identity) {
+	if !s.enabled {
+		return
+	}
+	eventName := dynamodbstreams.OperationTypeModify
+	switch {
+	case oldItem == nil:
+		eventName = dynamodbstreams.OperationTypeInsert
+	case newItem == nil:
+		eventName = dynamodbstreams.OperationTypeRemove
+	case equalValues(&dynamodb.AttributeValue{M: oldItem}, &dynamodb.AttributeValue{M: newItem}):
+		// Writes which do not change the item are not recorded
+		return
+	}
+	sh := s.openShard()
+	s.sequence++
+	seq := sequenceNumber(s.sequence)
+	rec := &dynamodbstreams.StreamRecord{
+		ApproximateCreationDateTime: aws.Time(s.now().Truncate(time.Second)),
+		Keys:                        copyItem(key),
+		SequenceNumber:              aws.String(seq),
+		StreamViewType:              aws.String(s.viewType),
+	}
+	size := calc.ItemSize(key)
+	if newItem != nil && (s.viewType == dynamodb.StreamViewTypeNewImage || s.viewType == dynamodb.StreamViewTypeNewAndOldImages) {
+		rec.NewImage = copyItem(newItem)
+		size += calc.ItemSize(newItem)
+	}
+	if oldItem != nil && (s.viewType == dynamodb.StreamViewTypeOldImage || s.viewType == dynamodb.StreamViewTypeNewAndOldImages) {
+		rec.OldImage = copyItem(oldItem)
+		size += calc.ItemSize(oldItem)
+	}
+	rec.SizeBytes = aws.Int64(size)
+	sh.records = append(sh.records, &dynamodbstreams.Record{
+		AwsRegion:    aws.String(mockRegion),
+		Dynamodb:     rec,
+		EventID:      aws.String(fmt.Sprintf("%x", md5.Sum([]byte(s.arn+seq)))),
+		EventName:    aws.String(eventName),
+		EventSource:  aws.String(streamEventSource),
+		EventVersion: aws.String(streamEventVersion),
+		UserIdentity: identity,
+	})
+	if len(sh.records) >= maxShardRecords {
+		s.close()
+	}
+}
+
+// shard returns the shard of the id
+func (s *stream) shard(id string) *shard {
+	for _, sh := range s.shards {
+		if sh.id == id {
+			return sh
+		}
+	}
+	return nil
+}
+
+// description returns the stream description with the shards after the exclusive start shard
+func (s *stream) description(exclusiveStartShardID *string, limit int64) *dynamodbstreams.StreamDescription {
+	status := dynamodbstreams.StreamStatusEnabled
+	if !s.enabled {
+		status = dynamodbstreams.StreamStatusDisabled
+	}
+	desc := &dynamodbstreams.StreamDescription{
+		CreationRequestDateTime: aws.Time(s.created),
+		KeySchema:               s.keySchema,
+		Shards:                  []*dynamodbstreams.Shard{},
+		StreamArn:               aws.String(s.arn),
+		StreamLabel:             aws.String(s.label),
+		StreamStatus:            aws.String(status),
+		StreamViewType:          aws.String(s.viewType),
+		TableName:               aws.String(s.tableName),
+	}
+	started := exclusiveStartShardID == nil
+	for _, sh := range s.shards {
+		if !started {
+			started = sh.id == *exclusiveStartShardID
+			continue
+		}
+		if int64(len(desc.Shards)) == limit {
+			desc.LastEvaluatedShardId = desc.Shards[limit-1].ShardId
+			break
+		}
+		r := &dynamodbstreams.SequenceNumberRange{StartingSequenceNumber: aws.String(sequenceNumber(sh.start))}
+		if sh.end != 0 {
+			r.EndingSequenceNumber = aws.String(sequenceNumber(sh.end))
+		}
+		ds := &dynamodbstreams.Shard{SequenceNumberRange: r, ShardId: aws.String(sh.id)}
+		if sh.parentID != "" {
+			ds.ParentShardId = aws.String(sh.parentID)
+		}
+		desc.Shards = append(desc.Shards, ds)
+	}
+	return awsutil.CopyOf(desc).(*dynamodbstreams.StreamDescription)
+}
+
+// validateStreamSpecification checks the stream specification of the table
+func validateStreamSpecification(spec *dynamodb.StreamSpecification) error {
+	if spec.StreamEnabled == nil {
+		return validationError("1 validation error detected: Value null at 'streamSpecification.streamEnabled' failed to satisfy constraint: Member must not be null")
+	}
+	if !*spec.StreamEnabled {
+		if spec.StreamViewType != nil {
+			return validationError("One or more parameter values were invalid: StreamViewType cannot be specified when StreamEnabled is false")
+		}
+		return nil
+	}
+	if spec.StreamViewType == nil {
+		return validationError("One or more parameter values were invalid: StreamViewType is required when StreamEnabled is true")
+	}
+	switch *spec.StreamViewType {
+	case dynamodb.StreamViewTypeKeysOnly, dynamodb.StreamViewTypeNewImage, dynamodb.StreamViewTypeOldImage, dynamodb.StreamViewTypeNewAndOldImages:
+		return nil
+	}
+	return validationError("1 validation error detected: Value '%s' at 'streamSpecification.streamViewType' failed to satisfy constraint: Member must satisfy enum value set: [NEW_IMAGE, OLD_IMAGE, NEW_AND_OLD_IMAGES, KEYS_ONLY]", *spec.StreamViewType)
+}
+
+// enableStream creates a new stream of the table with the view type
+func (d *DynamoDBClient) enableStream(t *table, viewType string) {
+	now := d.now()
+	label := now.UTC().Format("2006-01-02T15:04:05.000")
+	// The arn must be unique even for the streams enabled in the same millisecond
+	for d.hasStream(fmt.Sprintf("%s/stream/%s", *t.desc.TableArn, label)) {
+		now = now.Add(time.Millisecond)
+		label = now.UTC().Format("2006-01-02T15:04:05.000")
+	}
+	s := &stream{
+		arn:       fmt.Sprintf("%s/stream/%s", *t.desc.TableArn, label),
+		label:     label,
+		tableName: *t.desc.TableName,
+		keySchema: t.desc.KeySchema,
+		viewType:  viewType,
+		created:   now,
+		enabled:   true,
+		now:       d.now,
+	}
+	s.shards = []*shard{{
+		id:    fmt.Sprintf("shardId-%020d-%08x", now.UnixNano()/int64(time.Millisecond), 1),
+		start: 1,
+	}}
+	t.stream = s
+	t.desc.StreamSpecification = &dynamodb.StreamSpecification{
+		StreamEnabled:  aws.Bool(true),
+		StreamViewType: aws.String(viewType),
+	}
+	t.desc.LatestStreamArn = aws.String(s.arn)
+	t.desc.LatestStreamLabel = aws.String(label)
+	d.streams = append(d.streams, s)
+}
+
+// disableStream closes the stream of the table, the stream stays readable
+func (t *table) disableStream() {
+	if t.stream == nil {
+		return
+	}
+	t.stream.enabled = false
+	t.stream.close()
+	t.stream = nil
+	t.desc.StreamSpecification = nil
+}
+
+// updateStream enables or disables the stream of the table
+func (d *DynamoDBClient) updateStream(t *table, spec *dynamodb.StreamSpecification) error {
+	if err := validateStreamSpecification(spec); err != nil {
+		return err
+	}
+	if *spec.StreamEnabled {
+		if t.stream != nil {
+			return validationError("Table already has an enabled stream: %s", t.stream.arn)
+		}
+		d.enableStream(t, *spec.StreamViewType)
+		return nil
+	}
+	if t.stream == nil {
+		return validationError("Table does not have an enabled stream")
+	}
+	t.disableStream()
+	return nil
+}
+
+func (d *DynamoDBClient) hasStream(arn string) bool {
+	_, err := d.getStream(&arn)
+	return err == nil
+}
+
+// getStream returns the stream of the arn or the resource not found error
+func (d *DynamoDBClient) getStream(arn *string) (*stream, error) {
+	if arn == nil {
+		return nil, validationError("1 validation error detected: Value null at 'streamArn' failed to satisfy constraint: Member must not be null")
+	}
+	for _, s := range d.streams {
+		if s.arn == *arn {
+			return s, nil
+		}
+	}
+	return nil, streamNotFoundError(*arn)
+}
+
+// DynamoDBStreamsClient is mocking the dynamodb streams of the tables of the mocked dynamodb
+type DynamoDBStreamsClient struct {
+	dynamodbstreamsiface.DynamoDBStreamsAPI
+	client *DynamoDBClient
+}
+
+// NewDynamoDBStreamsClient creates a mocked dynamodb streams client reading the streams of the client
+func NewDynamoDBStreamsClient(client *DynamoDBClient) *DynamoDBStreamsClient {
+	return &DynamoDBStreamsClient{client: client}
+}
+
+// ListStreams is mocking the dynamodb streams ListStreams operation
+func (c *DynamoDBStreamsClient) ListStreams(input *dynamodbstreams.ListStreamsInput) (*dynamodbstreams.ListStreamsOutput, error) {
+	d := c.client
+	if err := d.faults.inject("ListStreams"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	limit := int64(maxListStreamsLimit)
+	if input.Limit != nil {
+		if *input.Limit < 1 || *input.Limit > maxListStreamsLimit {
+			return nil, validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value less than or equal to %d", *input.Limit, maxListStreamsLimit)
+		}
+		limit = *input.Limit
+	}
+	started := input.ExclusiveStartStreamArn == nil
+	output := &dynamodbstreams.ListStreamsOutput{Streams: []*dynamodbstreams.Stream{}}
+	for _, s := range d.streams {
+		if !started {
+			started = s.arn == *input.ExclusiveStartStreamArn
+			continue
+		}
+		if input.TableName != nil && s.tableName != *input.TableName {
+			continue
+		}
+		if int64(len(output.Streams)) == limit {
+			output.LastEvaluatedStreamArn = output.Streams[limit-1].StreamArn
+			break
+		}
+		output.Streams = append(output.Streams, &dynamodbstreams.Stream{
+			StreamArn:   aws.String(s.arn),
+			StreamLabel: aws.String(s.label),
+			TableName:   aws.String(s.tableName),
+		})
+	}
+	return output, nil
+}
+
+// DescribeStream is mocking the dynamodb streams DescribeStream operation
+func (c *DynamoDBStreamsClient) DescribeStream(input *dynamodbstreams.DescribeStreamInput) (*dynamodbstreams.DescribeStreamOutput, error) {
+	d := c.client
+	if err := d.faults.inject("DescribeStream"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	s, err := d.getStream(input.StreamArn)
+	if err != nil {
+		return nil, err
+	}
+	limit := int64(maxDescribeShardLimit)
+	if input.Limit != nil {
+		if *input.Limit < 1 || *input.Limit > maxDescribeShardLimit {
+			return nil, validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value less than or equal to %d", *input.Limit, maxDescribeShardLimit)
+		}
+		limit = *input.Limit
+	}
+	return &dynamodbstreams.DescribeStreamOutput{
+		StreamDescription: s.description(input.ExclusiveStartShardId, limit),
+	}, nil
+}
+
+// shardIterator is the position of the next record to read in a shard
+type shardIterator struct {
+	arn     string
+	shardID string
+	pos     int
+	expires time.Time
+}
+
+func (it *shardIterator) String() string {
+	s := fmt.Sprintf("%s|%s|%d|%d", it.arn, it.shardID, it.pos, it.expires.UnixNano())
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func parseShardIterator(s string) (*shardIterator, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, validationError("Invalid ShardIterator")
+	}
+	parts := strings.Split(string(b), "|")
+	if len(parts) != 4 {
+		return nil, validationError("Invalid ShardIterator")
+	}
+	pos, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, validationError("Invalid ShardIterator")
+	}
+	expires, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return nil, validationError("Invalid ShardIterator")
+	}
+	return &shardIterator{arn: parts[0], shardID: parts[1], pos: pos, expires: time.Unix(0, expires)}, nil
+}
+
+// GetShardIterator is mocking the dynamodb streams GetShardIterator operation
+func (c *DynamoDBStreamsClient) GetShardIterator(input *dynamodbstreams.GetShardIteratorInput) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	d := c.client
+	if err := d.faults.inject("GetShardIterator"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	s, err := d.getStream(input.StreamArn)
+	if err != nil {
+		return nil, err
+	}
+	sh := s.shard(aws.StringValue(input.ShardId))
+	if sh == nil {
+		return nil, shardNotFoundError(aws.StringValue(input.ShardId))
+	}
+	it := &shardIterator{arn: s.arn, shardID: sh.id, expires: time.Now().Add(shardIteratorLifetime)}
+	switch aws.StringValue(input.ShardIteratorType) {
+	case dynamodbstreams.ShardIteratorTypeTrimHorizon, dynamodbstreams.ShardIteratorTypeLatest:
+		if input.SequenceNumber != nil {
+			return nil, validationError("Sequence number must not be specified for iterator type %s", *input.ShardIteratorType)
+		}
+		if *input.ShardIteratorType == dynamodbstreams.ShardIteratorTypeLatest {
+			it.pos = len(sh.records)
+		}
+	case dynamodbstreams.ShardIteratorTypeAtSequenceNumber, dynamodbstreams.ShardIteratorTypeAfterSequenceNumber:
+		if input.SequenceNumber == nil {
+			return nil, validationError("Sequence number must be specified for iterator type %s", *input.ShardIteratorType)
+		}
+		n, err := strconv.ParseInt(*input.SequenceNumber, 10, 64)
+		last := s.sequence
+		if sh.end != 0 {
+			last = sh.end
+		}
+		if err != nil || n < sh.start || n > last {
+			return nil, validationError("Invalid SequenceNumber %s for shard %s", *input.SequenceNumber, sh.id)
+		}
+		if *input.ShardIteratorType == dynamodbstreams.ShardIteratorTypeAfterSequenceNumber {
+			n++
+		}
+		for it.pos < len(sh.records) && sequenceOf(sh.records[it.pos]) < n {
+			it.pos++
+		}
+	default:
+		return nil, validationError("1 validation error detected: Value '%s' at 'shardIteratorType' failed to satisfy constraint: Member must satisfy enum value set: [AFTER_SEQUENCE_NUMBER, LATEST, AT_SEQUENCE_NUMBER, TRIM_HORIZON]", aws.StringValue(input.ShardIteratorType))
+	}
+	return &dynamodbstreams.GetShardIteratorOutput{
+		ShardIterator: aws.String(it.String()),
+	}, nil
+}
+
+func sequenceOf(r *dynamodbstreams.Record) int64 {
+	n, _ := strconv.ParseInt(*r.Dynamodb.SequenceNumber, 10, 64)
+	return n
+}
+
+// GetRecords is mocking the dynamodb streams GetRecords operation
+// The next shard iterator is nil once all the records of a closed shard are read
+func (c *DynamoDBStreamsClient) GetRecords(input *dynamodbstreams.GetRecordsInput) (*dynamodbstreams.GetRecordsOutput, error) {
+	d := c.client
+	if err := d.faults.inject("GetRecords"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if input.ShardIterator == nil {
+		return nil, validationError("1 validation error detected: Value null at 'shardIterator' failed to satisfy constraint: Member must not be null")
+	}
+	limit := maxGetRecordsLimit
+	if input.Limit != nil {
+		if *input.Limit < 1 || *input.Limit > maxGetRecordsLimit {
+			return nil, validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value less than or equal to %d", *input.Limit, maxGetRecordsLimit)
+		}
+		limit = int(*input.Limit)
+	}
+	it, err := parseShardIterator(*input.ShardIterator)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(it.expires) {
+		return nil, expiredIteratorError()
+	}
+	s, err := d.getStream(&it.arn)
+	if err != nil {
+		return nil, err
+	}
+	sh := s.shard(it.shardID)
+	if sh == nil || it.pos > len(sh.records) {
+		return nil, validationError("Invalid ShardIterator")
+	}
+	end := it.pos + limit
+	if end > len(sh.records) {
+		end = len(sh.records)
+	}
+	output := &dynamodbstreams.GetRecordsOutput{
+		Records: make([]*dynamodbstreams.Record, end-it.pos),
+	}
+	for i, r := range sh.records[it.pos:end] {
+		output.Records[i] = awsutil.CopyOf(r).(*dynamodbstreams.Record)
+	}
+	if end < len(sh.records) || sh.end == 0 {
+		next := &shardIterator{arn: it.arn, shardID: it.shardID, pos: end, expires: time.Now().Add(shardIteratorLifetime)}
+		output.NextShardIterator = aws.String(next.String())
+	}
+	return output, nil
+}
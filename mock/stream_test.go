@@ -0,0 +1,303 @@
+package mock
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+func enableTestStream(t *testing.T, client *DynamoDBClient, name string, viewType string) string {
+	output, err := client.UpdateTable(&dynamodb.UpdateTableInput{
+		TableName: aws.String(name),
+		StreamSpecification: &dynamodb.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: aws.String(viewType),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *output.TableDescription.LatestStreamArn
+}
+
+// readShard returns all the records of the shard from the trim horizon
+func readShard(t *testing.T, streams *DynamoDBStreamsClient, arn string, shardID string) []*dynamodbstreams.Record {
+	it, err := streams.GetShardIterator(&dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         aws.String(arn),
+		ShardId:           aws.String(shardID),
+		ShardIteratorType: aws.String(dynamodbstreams.ShardIteratorTypeTrimHorizon),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []*dynamodbstreams.Record{}
+	next := it.ShardIterator
+	for next != nil {
+		output, err := streams.GetRecords(&dynamodbstreams.GetRecordsInput{ShardIterator: next, Limit: aws.Int64(100)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, output.Records...)
+		if len(output.Records) == 0 {
+			break
+		}
+		next = output.NextShardIterator
+	}
+	return records
+}
+
+func TestStreamRecords(t *testing.T) {
+	testCases := []struct {
+		viewType string
+		newImage bool
+		oldImage bool
+	}{
+		{viewType: dynamodb.StreamViewTypeKeysOnly},
+		{viewType: dynamodb.StreamViewTypeNewImage, newImage: true},
+		{viewType: dynamodb.StreamViewTypeOldImage, oldImage: true},
+		{viewType: dynamodb.StreamViewTypeNewAndOldImages, newImage: true, oldImage: true},
+	}
+	for i, tc := range testCases {
+		client := NewDynamoDBClient()
+		createTestTable(t, client, "user", "")
+		arn := enableTestStream(t, client, "user", tc.viewType)
+		item := map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}, "n": {N: aws.String("1")}}
+		key := map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}}
+		writes := []func() error{
+			func() error {
+				_, err := client.PutItem(&dynamodb.PutItemInput{TableName: aws.String("user"), Item: item})
+				return err
+			},
+			// The unchanged item is not recorded
+			func() error {
+				_, err := client.PutItem(&dynamodb.PutItemInput{TableName: aws.String("user"), Item: item})
+				return err
+			},
+			func() error {
+				_, err := client.UpdateItem(&dynamodb.UpdateItemInput{
+					TableName:                 aws.String("user"),
+					Key:                       key,
+					UpdateExpression:          aws.String("SET n = :n"),
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":n": {N: aws.String("2")}},
+				})
+				return err
+			},
+			func() error {
+				_, err := client.DeleteItem(&dynamodb.DeleteItemInput{TableName: aws.String("user"), Key: key})
+				return err
+			},
+			// The missing item is not recorded
+			func() error {
+				_, err := client.DeleteItem(&dynamodb.DeleteItemInput{TableName: aws.String("user"), Key: key})
+				return err
+			},
+		}
+		for _, w := range writes {
+			if err := w(); err != nil {
+				t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+			}
+		}
+
+		streams := NewDynamoDBStreamsClient(client)
+		desc, err := streams.DescribeStream(&dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(arn)})
+		if err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if len(desc.StreamDescription.Shards) != 1 || *desc.StreamDescription.StreamViewType != tc.viewType {
+			t.Fatalf("[%d] Expecting a shard of %s, got %v\n", i+1, tc.viewType, desc.StreamDescription)
+		}
+		records := readShard(t, streams, arn, *desc.StreamDescription.Shards[0].ShardId)
+		names := []string{}
+		for j, r := range records {
+			names = append(names, *r.EventName)
+			if *r.Dynamodb.Keys["pk"].S != "a" || *r.Dynamodb.SequenceNumber != sequenceNumber(int64(j+1)) {
+				t.Errorf("[%d] Expecting the key a with the sequence number %d, got %v\n", i+1, j+1, r.Dynamodb)
+			}
+			hasNew := *r.EventName != dynamodbstreams.OperationTypeRemove && tc.newImage
+			hasOld := *r.EventName != dynamodbstreams.OperationTypeInsert && tc.oldImage
+			if (r.Dynamodb.NewImage != nil) != hasNew || (r.Dynamodb.OldImage != nil) != hasOld {
+				t.Errorf("[%d] Expecting the new image %t and the old image %t for %s, got %v\n", i+1, hasNew, hasOld, *r.EventName, r.Dynamodb)
+			}
+		}
+		if strings.Join(names, ",") != "INSERT,MODIFY,REMOVE" {
+			t.Errorf("[%d] Expecting INSERT,MODIFY,REMOVE, got %s\n", i+1, strings.Join(names, ","))
+		}
+		if tc.newImage && *records[1].Dynamodb.NewImage["n"].N != "2" {
+			t.Errorf("[%d] Expecting the updated new image, got %v\n", i+1, records[1].Dynamodb.NewImage)
+		}
+	}
+}
+
+func TestStreamShards(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "event", "N")
+	arn := enableTestStream(t, client, "event", dynamodb.StreamViewTypeKeysOnly)
+	items := []map[string]*dynamodb.AttributeValue{}
+	for i := 0; i < maxShardRecords+10; i++ {
+		items = append(items, map[string]*dynamodb.AttributeValue{
+			"pk": {S: aws.String("a")},
+			"sk": {N: aws.String(strconv.Itoa(i))},
+		})
+	}
+	writeTestItems(t, client, "event", items)
+
+	streams := NewDynamoDBStreamsClient(client)
+	desc, err := streams.DescribeStream(&dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(arn)})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	shards := desc.StreamDescription.Shards
+	if len(shards) != 2 || shards[0].SequenceNumberRange.EndingSequenceNumber == nil || aws.StringValue(shards[1].ParentShardId) != *shards[0].ShardId {
+		t.Fatalf("Expecting the closed parent shard and its child, got %v\n", shards)
+	}
+	if got := len(readShard(t, streams, arn, *shards[0].ShardId)); got != maxShardRecords {
+		t.Errorf("Expecting %d records in the parent shard, got %d\n", maxShardRecords, got)
+	}
+
+	// The records after the sequence number of the child shard
+	it, err := streams.GetShardIterator(&dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         aws.String(arn),
+		ShardId:           shards[1].ShardId,
+		ShardIteratorType: aws.String(dynamodbstreams.ShardIteratorTypeAfterSequenceNumber),
+		SequenceNumber:    aws.String(sequenceNumber(maxShardRecords + 5)),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	output, err := streams.GetRecords(&dynamodbstreams.GetRecordsInput{ShardIterator: it.ShardIterator})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if len(output.Records) != 5 || output.NextShardIterator == nil {
+		t.Errorf("Expecting 5 records and the next iterator of the open shard, got %d records\n", len(output.Records))
+	}
+
+	// The disabled stream is closed and still readable
+	if _, err := client.UpdateTable(&dynamodb.UpdateTableInput{
+		TableName:           aws.String("event"),
+		StreamSpecification: &dynamodb.StreamSpecification{StreamEnabled: aws.Bool(false)},
+	}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	output, err = streams.GetRecords(&dynamodbstreams.GetRecordsInput{ShardIterator: output.NextShardIterator})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if len(output.Records) != 0 || output.NextShardIterator != nil {
+		t.Errorf("Expecting the end of the closed shard, got %d records\n", len(output.Records))
+	}
+	desc, _ = streams.DescribeStream(&dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(arn)})
+	if *desc.StreamDescription.StreamStatus != dynamodbstreams.StreamStatusDisabled {
+		t.Errorf("Expecting the disabled stream, got %s\n", *desc.StreamDescription.StreamStatus)
+	}
+
+	// A new stream is created when enabled again
+	enableTestStream(t, client, "event", dynamodb.StreamViewTypeNewImage)
+	list, err := streams.ListStreams(&dynamodbstreams.ListStreamsInput{TableName: aws.String("event")})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if len(list.Streams) != 2 || *list.Streams[0].StreamArn != arn || *list.Streams[1].StreamArn == arn {
+		t.Errorf("Expecting the old and new streams, got %v\n", list.Streams)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "")
+	arn := enableTestStream(t, client, "user", dynamodb.StreamViewTypeKeysOnly)
+	streams := NewDynamoDBStreamsClient(client)
+	desc, err := streams.DescribeStream(&dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(arn)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shardID := desc.StreamDescription.Shards[0].ShardId
+
+	testCases := []struct {
+		call func() error
+		code string
+		err  string
+	}{
+		{
+			call: func() error {
+				_, err := client.UpdateTable(&dynamodb.UpdateTableInput{
+					TableName:           aws.String("user"),
+					StreamSpecification: &dynamodb.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: aws.String(dynamodb.StreamViewTypeNewImage)},
+				})
+				return err
+			},
+			code: errCodeValidationException,
+			err:  "Table already has an enabled stream",
+		},
+		{
+			call: func() error {
+				_, err := client.UpdateTable(&dynamodb.UpdateTableInput{
+					TableName:           aws.String("user"),
+					StreamSpecification: &dynamodb.StreamSpecification{StreamEnabled: aws.Bool(true)},
+				})
+				return err
+			},
+			code: errCodeValidationException,
+			err:  "StreamViewType is required",
+		},
+		{
+			call: func() error {
+				_, err := streams.DescribeStream(&dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(arn + "x")})
+				return err
+			},
+			code: dynamodbstreams.ErrCodeResourceNotFoundException,
+			err:  "Stream",
+		},
+		{
+			call: func() error {
+				_, err := streams.GetShardIterator(&dynamodbstreams.GetShardIteratorInput{
+					StreamArn:         aws.String(arn),
+					ShardId:           aws.String("shardId-unknown"),
+					ShardIteratorType: aws.String(dynamodbstreams.ShardIteratorTypeLatest),
+				})
+				return err
+			},
+			code: dynamodbstreams.ErrCodeResourceNotFoundException,
+			err:  "Shard",
+		},
+		{
+			call: func() error {
+				_, err := streams.GetShardIterator(&dynamodbstreams.GetShardIteratorInput{
+					StreamArn:         aws.String(arn),
+					ShardId:           shardID,
+					ShardIteratorType: aws.String(dynamodbstreams.ShardIteratorTypeAtSequenceNumber),
+				})
+				return err
+			},
+			code: errCodeValidationException,
+			err:  "Sequence number must be specified",
+		},
+		{
+			call: func() error {
+				_, err := streams.GetRecords(&dynamodbstreams.GetRecordsInput{ShardIterator: aws.String("invalid")})
+				return err
+			},
+			code: errCodeValidationException,
+			err:  "Invalid ShardIterator",
+		},
+		{
+			call: func() error {
+				it := &shardIterator{arn: arn, shardID: *shardID}
+				_, err := streams.GetRecords(&dynamodbstreams.GetRecordsInput{ShardIterator: aws.String(it.String())})
+				return err
+			},
+			code: dynamodbstreams.ErrCodeExpiredIteratorException,
+			err:  "Iterator expired",
+		},
+	}
+	for i, tc := range testCases {
+		err := tc.call()
+		if errorCode(err) != tc.code || !strings.Contains(awsMessage(err), tc.err) {
+			t.Errorf("[%d] Expecting the error %s %q, got %v\n", i+1, tc.code, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package mock
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+)
+
+// keyAttribute is a key attribute of the table or index
+type keyAttribute struct {
+	name string
+	typ  string
+}
+
+// keyAttributesOf returns the hash and range (optional) key attributes of the key schema
+func keyAttributesOf(keySchema []*dynamodb.KeySchemaElement, defs []*dynamodb.AttributeDefinition) (keyAttribute, *keyAttribute, error) {
+	types := map[string]string{}
+	for _, d := range defs {
+		types[*d.AttributeName] = *d.AttributeType
+	}
+	var hash *keyAttribute
+	var rng *keyAttribute
+	for _, k := range keySchema {
+		typ, ok := types[*k.AttributeName]
+		if !ok {
+			return keyAttribute{}, nil, validationError("One or more parameter values were invalid: Some index key attributes are not defined in AttributeDefinitions. Keys: [%s], AttributeDefinitions: %s", *k.AttributeName, attributeNames(defs))
+		}
+		attr := &keyAttribute{name: *k.AttributeName, typ: typ}
+		switch *k.KeyType {
+		case dynamodb.KeyTypeHash:
+			if hash != nil {
+				return keyAttribute{}, nil, validationError("Invalid KeySchema: Too many hash key elements")
+			}
+			hash = attr
+		case dynamodb.KeyTypeRange:
+			rng = attr
+		}
+	}
+	if hash == nil {
+		return keyAttribute{}, nil, validationError("Invalid KeySchema: The first KeySchemaElement is not a HASH key type")
+	}
+	return *hash, rng, nil
+}
+
+// keyAttributeNames returns the names of the key attributes
+func keyAttributeNames(keySchema []*dynamodb.KeySchemaElement) []string {
+	names := []string{}
+	for _, k := range keySchema {
+		names = append(names, *k.AttributeName)
+	}
+	return names
+}
+
+func attributeNames(defs []*dynamodb.AttributeDefinition) []string {
+	names := []string{}
+	for _, d := range defs {
+		names = append(names, *d.AttributeName)
+	}
+	return names
+}
+
+// record is an item stored in the table or index with its position information
+type record struct {
+	token uint64 // Hash of the partition key which decides the scan order and segment
+	hash  string // Encoded partition key
+	key   string // Encoded primary key of the table item
+	item  map[string]*dynamodb.AttributeValue
+}
+
+// store keeps the records ordered by the partition key token, the sort key and the table primary key
+type store struct {
+	hashKey  keyAttribute
+	rangeKey *keyAttribute
+	records  []*record
+}
+
+// keyAttributes returns the key attributes of the store
+func (s *store) keyAttributes() []keyAttribute {
+	if s.rangeKey == nil {
+		return []keyAttribute{s.hashKey}
+	}
+	return []keyAttribute{s.hashKey, *s.rangeKey}
+}
+
+// keyString returns the encoded key of the item
+func (s *store) keyString(item map[string]*dynamodb.AttributeValue) string {
+	k := encodeValue(item[s.hashKey.name])
+	if s.rangeKey != nil {
+		k += "\x00" + encodeValue(item[s.rangeKey.name])
+	}
+	return k
+}
+
+func (s *store) newRecord(item map[string]*dynamodb.AttributeValue, key string) *record {
+	hash := encodeValue(item[s.hashKey.name])
+	sum := md5.Sum([]byte(hash))
+	return &record{token: binary.BigEndian.Uint64(sum[:8]), hash: hash, key: key, item: item}
+}
+
+// compare orders the records by the token, partition key, sort key and table primary key
+func (s *store) compare(a, b *record) int {
+	switch {
+	case a.token < b.token:
+		return -1
+	case a.token > b.token:
+		return 1
+	case a.hash < b.hash:
+		return -1
+	case a.hash > b.hash:
+		return 1
+	}
+	if s.rangeKey != nil {
+		if c := compareScalars(a.item[s.rangeKey.name], b.item[s.rangeKey.name]); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.key, b.key)
+}
+
+// search returns the position of the first record which is not less than the given record
+func (s *store) search(r *record) (int, bool) {
+	i := sort.Search(len(s.records), func(i int) bool {
+		return s.compare(s.records[i], r) >= 0
+	})
+	return i, i < len(s.records) && s.compare(s.records[i], r) == 0
+}
+
+func (s *store) insert(r *record) {
+	i, _ := s.search(r)
+	s.records = append(s.records, nil)
+	copy(s.records[i+1:], s.records[i:])
+	s.records[i] = r
+}
+
+func (s *store) remove(r *record) {
+	if i, found := s.search(r); found {
+		s.records = append(s.records[:i], s.records[i+1:]...)
+	}
+}
+
+// partition returns the range of the records which have the given partition key
+func (s *store) partition(hashKey *dynamodb.AttributeValue) (int, int) {
+	r := s.newRecord(map[string]*dynamodb.AttributeValue{s.hashKey.name: hashKey}, "")
+	lo := sort.Search(len(s.records), func(i int) bool {
+		o := s.records[i]
+		return o.token > r.token || (o.token == r.token && o.hash >= r.hash)
+	})
+	hi := lo
+	for hi < len(s.records) && s.records[hi].hash == r.hash {
+		hi++
+	}
+	return lo, hi
+}
+
+// table stores the items and maintains the secondary indexes of them
+type table struct {
+	desc *dynamodb.TableDescription
+	store
+	keys    map[string]*record
+	indexes []*index
+	stream  *stream                         // Enabled stream of the table, nil when disabled
+	ttl     *dynamodb.TimeToLiveDescription // Time to live of the table, nil when disabled
+}
+
+func newTable(desc *dynamodb.TableDescription) (*table, error) {
+	hash, rng, err := keyAttributesOf(desc.KeySchema, desc.AttributeDefinitions)
+	if err != nil {
+		return nil, err
+	}
+	t := &table{
+		desc:  desc,
+		store: store{hashKey: hash, rangeKey: rng, records: []*record{}},
+		keys:  map[string]*record{},
+	}
+	used := map[string]bool{}
+	for _, name := range keyAttributeNames(desc.KeySchema) {
+		used[name] = true
+	}
+	for _, gsi := range desc.GlobalSecondaryIndexes {
+		idx, err := newIndex(t, aws.StringValue(gsi.IndexName), gsi.KeySchema, gsi.Projection, false, gsi.ItemCount, gsi.IndexSizeBytes)
+		if err != nil {
+			return nil, err
+		}
+		t.indexes = append(t.indexes, idx)
+	}
+	for _, lsi := range desc.LocalSecondaryIndexes {
+		idx, err := newIndex(t, aws.StringValue(lsi.IndexName), lsi.KeySchema, lsi.Projection, true, lsi.ItemCount, lsi.IndexSizeBytes)
+		if err != nil {
+			return nil, err
+		}
+		t.indexes = append(t.indexes, idx)
+	}
+	for _, idx := range t.indexes {
+		for _, k := range idx.keyAttributes() {
+			used[k.name] = true
+		}
+	}
+	if len(used) != len(desc.AttributeDefinitions) {
+		return nil, validationError("One or more parameter values were invalid: Number of attributes in KeySchema does not exactly match number of attributes defined in AttributeDefinitions")
+	}
+	return t, nil
+}
+
+// index returns the secondary index of the name
+func (t *table) index(name string) (*index, error) {
+	for _, idx := range t.indexes {
+		if idx.name == name {
+			return idx, nil
+		}
+	}
+	return nil, validationError("The table does not have the specified index: %s", name)
+}
+
+// validateItem checks the item has the valid key attributes and values
+func (t *table) validateItem(item map[string]*dynamodb.AttributeValue) error {
+	for _, k := range t.keyAttributes() {
+		v, ok := item[k.name]
+		if !ok {
+			return validationError("One or more parameter values were invalid: Missing the key %s in the item", k.name)
+		}
+		if err := validateKeyValue(k, v); err != nil {
+			return err
+		}
+	}
+	for _, v := range item {
+		if err := validateValue(v); err != nil {
+			return err
+		}
+	}
+	if calc.ItemSize(item) > calc.MaxItemSize {
+		return validationError("Item size has exceeded the maximum allowed size")
+	}
+	for _, idx := range t.indexes {
+		if err := idx.validateItem(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateKey checks the key has exactly the key attributes of the table
+func (t *table) validateKey(key map[string]*dynamodb.AttributeValue) error {
+	attrs := t.keyAttributes()
+	if len(key) != len(attrs) {
+		return validationError("The provided key element does not match the schema")
+	}
+	for _, k := range attrs {
+		v, ok := key[k.name]
+		if !ok || typeOf(v) != k.typ {
+			return validationError("The provided key element does not match the schema")
+		}
+		if err := validateKeyValue(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateKeyValue(k keyAttribute, v *dynamodb.AttributeValue) error {
+	if err := validateValue(v); err != nil {
+		return err
+	}
+	if typ := typeOf(v); typ != k.typ {
+		return validationError("One or more parameter values were invalid: Type mismatch for key %s expected: %s actual: %s", k.name, k.typ, typ)
+	}
+	if (v.S != nil && len(*v.S) == 0) || (v.B != nil && len(v.B) == 0) {
+		return validationError("One or more parameter values are not valid. The AttributeValue for a key attribute cannot contain an empty %s value. Key: %s", map[string]string{typeS: "string", typeB: "binary"}[k.typ], k.name)
+	}
+	return nil
+}
+
+// keyOf returns the copy of the given key attributes of the item
+func keyOf(item map[string]*dynamodb.AttributeValue, attrs []keyAttribute) map[string]*dynamodb.AttributeValue {
+	key := map[string]*dynamodb.AttributeValue{}
+	for _, k := range attrs {
+		key[k.name] = copyValue(item[k.name])
+	}
+	return key
+}
+
+// get returns the stored item of the key, the caller must not modify it
+func (t *table) get(key map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if r, ok := t.keys[t.keyString(key)]; ok {
+		return r.item
+	}
+	return nil
+}
+
+// put stores the copy of the item and returns the replaced item if exists
+func (t *table) put(item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	item = copyItem(item)
+	key := t.keyString(item)
+	var old map[string]*dynamodb.AttributeValue
+	if r, ok := t.keys[key]; ok {
+		old = r.item
+		r.item = item
+		*t.desc.TableSizeBytes -= calc.ItemSize(old)
+	} else {
+		r := t.newRecord(item, key)
+		t.insert(r)
+		t.keys[key] = r
+		*t.desc.ItemCount++
+	}
+	*t.desc.TableSizeBytes += calc.ItemSize(item)
+	for _, idx := range t.indexes {
+		idx.removeItem(key)
+		idx.addItem(item, key)
+	}
+	if t.stream != nil {
+		t.stream.append(keyOf(item, t.keyAttributes()), old, item, nil)
+	}
+	return old
+}
+
+// delete removes the item of the key and returns the removed item if exists
+func (t *table) delete(key map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	return t.deleteBy(key, nil)
+}
+
+// deleteBy removes the item of the key with the identity recorded into the stream, nil for the user deletions
+func (t *table) deleteBy(key map[string]*dynamodb.AttributeValue, identity *dynamodbstreams.Identity) map[string]*dynamodb.AttributeValue {
+	k := t.keyString(key)
+	r, ok := t.keys[k]
+	if !ok {
+		return nil
+	}
+	t.store.remove(r)
+	delete(t.keys, k)
+	*t.desc.ItemCount--
+	*t.desc.TableSizeBytes -= calc.ItemSize(r.item)
+	for _, idx := range t.indexes {
+		idx.removeItem(k)
+	}
+	if t.stream != nil {
+		t.stream.append(keyOf(r.item, t.keyAttributes()), r.item, nil, identity)
+	}
+	return r.item
+}
+
+// segmentOf returns the parallel scan segment of the token
+func segmentOf(token uint64, totalSegments int64) int64 {
+	hi, _ := bits.Mul64(token, uint64(totalSegments))
+	return int64(hi)
+}
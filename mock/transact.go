@@ -0,0 +1,296 @@
+package mock
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+)
+
+const (
+	maxTransactWriteItems = 25
+	maxTransactGetItems   = 100
+	maxClientTokenLength  = 36
+
+	// The client request token is idempotent for 10 minutes
+	clientTokenLifetime = 10 * time.Minute
+
+	cancellationReasonNone                   = "None"
+	cancellationReasonConditionalCheckFailed = "ConditionalCheckFailed"
+	cancellationReasonValidationError        = "ValidationError"
+)
+
+// clientToken is the client request token of the succeeded transaction
+type clientToken struct {
+	request string
+	expires time.Time
+}
+
+// transactWrite is the parsed write operation of the transaction
+type transactWrite struct {
+	table     *table
+	key       map[string]*dynamodb.AttributeValue
+	item      map[string]*dynamodb.AttributeValue
+	actions   []*updateAction
+	cond      *condition
+	returnOld bool
+	delete    bool
+}
+
+// transactWriteOf parses and validates the write operation of the transaction
+func (d *DynamoDBClient) transactWriteOf(ti *dynamodb.TransactWriteItem) (*transactWrite, error) {
+	var (
+		name    *string
+		key     map[string]*dynamodb.AttributeValue
+		item    map[string]*dynamodb.AttributeValue
+		names   map[string]*string
+		values  map[string]*dynamodb.AttributeValue
+		condExp *string
+		updExp  *string
+		rv      *string
+		ops     int
+	)
+	w := &transactWrite{}
+	if c := ti.ConditionCheck; c != nil {
+		if c.ConditionExpression == nil {
+			return nil, validationError("1 validation error detected: Value null at 'conditionCheck.conditionExpression' failed to satisfy constraint: Member must not be null")
+		}
+		name, key, names, values, condExp, rv = c.TableName, c.Key, c.ExpressionAttributeNames, c.ExpressionAttributeValues, c.ConditionExpression, c.ReturnValuesOnConditionCheckFailure
+		ops++
+	}
+	if p := ti.Put; p != nil {
+		name, item, names, values, condExp, rv = p.TableName, p.Item, p.ExpressionAttributeNames, p.ExpressionAttributeValues, p.ConditionExpression, p.ReturnValuesOnConditionCheckFailure
+		ops++
+	}
+	if u := ti.Update; u != nil {
+		if u.UpdateExpression == nil {
+			return nil, validationError("1 validation error detected: Value null at 'update.updateExpression' failed to satisfy constraint: Member must not be null")
+		}
+		name, key, names, values, condExp, updExp, rv = u.TableName, u.Key, u.ExpressionAttributeNames, u.ExpressionAttributeValues, u.ConditionExpression, u.UpdateExpression, u.ReturnValuesOnConditionCheckFailure
+		ops++
+	}
+	if del := ti.Delete; del != nil {
+		name, key, names, values, condExp, rv = del.TableName, del.Key, del.ExpressionAttributeNames, del.ExpressionAttributeValues, del.ConditionExpression, del.ReturnValuesOnConditionCheckFailure
+		w.delete = true
+		ops++
+	}
+	if ops != 1 {
+		return nil, validationError("TransactItems can only contain one of Check, Put, Update or Delete")
+	}
+
+	t, err := d.getTable(name)
+	if err != nil {
+		return nil, err
+	}
+	w.table = t
+	ctx := newExpressionContext(names, values)
+	if w.actions, err = ctx.update(updExp); err != nil {
+		return nil, err
+	}
+	if w.cond, err = ctx.condition(conditionExpression, condExp); err != nil {
+		return nil, err
+	}
+	if err := ctx.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateReturnValuesOnConditionCheckFailure(rv); err != nil {
+		return nil, err
+	}
+	w.returnOld = rv != nil && *rv == dynamodb.ReturnValuesOnConditionCheckFailureAllOld
+	if item != nil {
+		if err := t.validateItem(item); err != nil {
+			return nil, err
+		}
+		w.key, w.item = item, item
+		return w, nil
+	}
+	if err := t.validateKey(key); err != nil {
+		return nil, err
+	}
+	if err := t.validateActions(w.actions); err != nil {
+		return nil, err
+	}
+	w.key = key
+	return w, nil
+}
+
+func validateReturnValuesOnConditionCheckFailure(rv *string) error {
+	if rv == nil || *rv == dynamodb.ReturnValuesOnConditionCheckFailureAllOld || *rv == dynamodb.ReturnValuesOnConditionCheckFailureNone {
+		return nil
+	}
+	return validationError("1 validation error detected: Value '%s' at 'returnValuesOnConditionCheckFailure' failed to satisfy constraint: Member must satisfy enum value set: [ALL_OLD, NONE]", *rv)
+}
+
+// TransactWriteItems is mocking the dynamodb TransactWriteItems operation
+// All the writes are applied only if every condition is satisfied
+func (d *DynamoDBClient) TransactWriteItems(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := d.faults.inject("TransactWriteItems"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(input.TransactItems) == 0 || len(input.TransactItems) > maxTransactWriteItems {
+		return nil, validationError("1 validation error detected: Value at 'transactItems' failed to satisfy constraint: Member must have length less than or equal to %d and greater than or equal to 1", maxTransactWriteItems)
+	}
+	if err := validateReturnConsumedCapacity(input.ReturnConsumedCapacity); err != nil {
+		return nil, err
+	}
+	request := awsutil.Prettify(input.TransactItems)
+	if token := input.ClientRequestToken; token != nil {
+		if len(*token) == 0 || len(*token) > maxClientTokenLength {
+			return nil, validationError("1 validation error detected: Value '%s' at 'clientRequestToken' failed to satisfy constraint: Member must have length less than or equal to %d", *token, maxClientTokenLength)
+		}
+		if ct, ok := d.clientTokens[*token]; ok && d.now().Before(ct.expires) {
+			if ct.request != request {
+				return nil, idempotentParameterMismatchError()
+			}
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		}
+	}
+
+	// Validate all the operations before evaluating any of them
+	writes := make([]*transactWrite, len(input.TransactItems))
+	keys := map[string]bool{}
+	for i, ti := range input.TransactItems {
+		w, err := d.transactWriteOf(ti)
+		if err != nil {
+			return nil, err
+		}
+		k := *w.table.desc.TableName + "/" + w.table.keyString(w.key)
+		if keys[k] {
+			return nil, validationError("Transaction request cannot include multiple operations on one item")
+		}
+		keys[k] = true
+		writes[i] = w
+	}
+
+	reasons := make([]*dynamodb.CancellationReason, len(writes))
+	canceled := false
+	for i, w := range writes {
+		reasons[i] = &dynamodb.CancellationReason{Code: aws.String(cancellationReasonNone)}
+		old := w.table.get(w.key)
+		if err := checkCondition(w.cond, old); err != nil {
+			reasons[i].Code = aws.String(cancellationReasonConditionalCheckFailed)
+			reasons[i].Message = aws.String(awsMessage(err))
+			if w.returnOld && old != nil {
+				reasons[i].Item = copyItem(old)
+			}
+			canceled = true
+			continue
+		}
+		if w.actions != nil {
+			updated, err := w.table.updatedItem(w.key, old, w.actions)
+			if err != nil {
+				reasons[i].Code = aws.String(cancellationReasonValidationError)
+				reasons[i].Message = aws.String(awsMessage(err))
+				canceled = true
+				continue
+			}
+			w.item = updated
+		}
+	}
+	if canceled {
+		return nil, transactionCanceledError(reasons)
+	}
+
+	// The transactional writes consume twice the capacity units of the standard writes
+	consumed := consumedCapacities{}
+	for _, w := range writes {
+		old := w.table.get(w.key)
+		switch {
+		case w.delete:
+			consumed.add(w.table.writeCapacity(old, nil).scale(2))
+		case w.item != nil:
+			consumed.add(w.table.writeCapacity(old, w.item).scale(2))
+		default:
+			c := newConsumedCapacity(w.table)
+			c.units = writeUnits(calc.ItemSize(old))
+			consumed.add(c.scale(2))
+		}
+	}
+	if err := d.faults.consumeAll(consumed, false, d.now()); err != nil {
+		return nil, err
+	}
+	for _, w := range writes {
+		switch {
+		case w.delete:
+			w.table.delete(w.key)
+		case w.item != nil:
+			w.table.put(w.item)
+		}
+	}
+	if input.ClientRequestToken != nil {
+		d.clientTokens[*input.ClientRequestToken] = &clientToken{
+			request: request,
+			expires: d.now().Add(clientTokenLifetime),
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{
+		ConsumedCapacity: consumed.output(input.ReturnConsumedCapacity),
+	}, nil
+}
+
+// TransactGetItems is mocking the dynamodb TransactGetItems operation
+func (d *DynamoDBClient) TransactGetItems(input *dynamodb.TransactGetItemsInput) (*dynamodb.TransactGetItemsOutput, error) {
+	if err := d.faults.inject("TransactGetItems"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(input.TransactItems) == 0 || len(input.TransactItems) > maxTransactGetItems {
+		return nil, validationError("1 validation error detected: Value at 'transactItems' failed to satisfy constraint: Member must have length less than or equal to %d and greater than or equal to 1", maxTransactGetItems)
+	}
+	if err := validateReturnConsumedCapacity(input.ReturnConsumedCapacity); err != nil {
+		return nil, err
+	}
+
+	tables := make([]*table, len(input.TransactItems))
+	projections := make([][]docPath, len(input.TransactItems))
+	keys := map[string]bool{}
+	for i, ti := range input.TransactItems {
+		if ti.Get == nil {
+			return nil, validationError("1 validation error detected: Value null at 'transactItems.%d.member.get' failed to satisfy constraint: Member must not be null", i+1)
+		}
+		t, err := d.getTable(ti.Get.TableName)
+		if err != nil {
+			return nil, err
+		}
+		ctx := newExpressionContext(ti.Get.ExpressionAttributeNames, nil)
+		projection, err := projectionOf(ctx, ti.Get.ProjectionExpression, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.validate(); err != nil {
+			return nil, err
+		}
+		if err := t.validateKey(ti.Get.Key); err != nil {
+			return nil, err
+		}
+		k := *t.desc.TableName + "/" + t.keyString(ti.Get.Key)
+		if keys[k] {
+			return nil, validationError("Transaction request cannot include multiple operations on one item")
+		}
+		keys[k] = true
+		tables[i], projections[i] = t, projection
+	}
+
+	output := &dynamodb.TransactGetItemsOutput{
+		Responses: make([]*dynamodb.ItemResponse, len(input.TransactItems)),
+	}
+	consumed := consumedCapacities{}
+	for i, ti := range input.TransactItems {
+		output.Responses[i] = &dynamodb.ItemResponse{}
+		item := tables[i].get(ti.Get.Key)
+		consumed.add(readCapacity(tables[i], nil, calc.ItemSize(item), aws.Bool(true)).scale(2))
+		if item != nil {
+			output.Responses[i].Item = projectItem(item, projections[i])
+		}
+	}
+	if err := d.faults.consumeAll(consumed, true, d.now()); err != nil {
+		return nil, err
+	}
+	output.ConsumedCapacity = consumed.output(input.ReturnConsumedCapacity)
+	return output, nil
+}
@@ -0,0 +1,181 @@
+package mock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestTransactWriteItems(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "account", "")
+	writeTestItems(t, client, "account", []map[string]*dynamodb.AttributeValue{
+		{"pk": {S: aws.String("a")}, "balance": {N: aws.String("100")}},
+		{"pk": {S: aws.String("b")}, "balance": {N: aws.String("0")}},
+	})
+	transfer := func(amount string) *dynamodb.TransactWriteItemsInput {
+		values := map[string]*dynamodb.AttributeValue{":amount": {N: aws.String(amount)}}
+		return &dynamodb.TransactWriteItemsInput{
+			TransactItems: []*dynamodb.TransactWriteItem{
+				{
+					Update: &dynamodb.Update{
+						TableName:                           aws.String("account"),
+						Key:                                 map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}},
+						UpdateExpression:                    aws.String("SET balance = balance - :amount"),
+						ConditionExpression:                 aws.String("balance >= :amount"),
+						ExpressionAttributeValues:           values,
+						ReturnValuesOnConditionCheckFailure: aws.String(dynamodb.ReturnValuesOnConditionCheckFailureAllOld),
+					},
+				},
+				{
+					Update: &dynamodb.Update{
+						TableName:                 aws.String("account"),
+						Key:                       map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("b")}},
+						UpdateExpression:          aws.String("SET balance = balance + :amount"),
+						ExpressionAttributeValues: values,
+					},
+				},
+				{
+					Put: &dynamodb.Put{
+						TableName: aws.String("account"),
+						Item:      map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("log-" + amount)}},
+					},
+				},
+			},
+		}
+	}
+	balances := func() (string, string) {
+		output, err := client.TransactGetItems(&dynamodb.TransactGetItemsInput{
+			TransactItems: []*dynamodb.TransactGetItem{
+				{Get: &dynamodb.Get{TableName: aws.String("account"), Key: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}}}},
+				{Get: &dynamodb.Get{TableName: aws.String("account"), Key: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("b")}}}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("There should be no errors, Got %s\n", err.Error())
+		}
+		return *output.Responses[0].Item["balance"].N, *output.Responses[1].Item["balance"].N
+	}
+
+	if _, err := client.TransactWriteItems(transfer("70")); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if a, b := balances(); a != "30" || b != "70" {
+		t.Errorf("Expecting %s and %s, got %s and %s\n", "30", "70", a, b)
+	}
+
+	// Nothing is written if any condition fails
+	_, err := client.TransactWriteItems(transfer("50"))
+	canceled, ok := err.(*dynamodb.TransactionCanceledException)
+	if !ok {
+		t.Fatalf("Expecting the transaction to be canceled, got %v\n", err)
+	}
+	codes := []string{}
+	for _, r := range canceled.CancellationReasons {
+		codes = append(codes, *r.Code)
+	}
+	if len(codes) != 3 || codes[0] != "ConditionalCheckFailed" || codes[1] != "None" || codes[2] != "None" {
+		t.Errorf("Expecting the cancellation reasons, got %v\n", codes)
+	}
+	if *canceled.CancellationReasons[0].Item["balance"].N != "30" {
+		t.Errorf("Expecting the old item in the cancellation reason, got %v\n", canceled.CancellationReasons[0].Item)
+	}
+	if a, b := balances(); a != "30" || b != "70" {
+		t.Errorf("Expecting %s and %s, got %s and %s\n", "30", "70", a, b)
+	}
+	got, _ := client.GetItem(&dynamodb.GetItemInput{TableName: aws.String("account"), Key: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("log-50")}}})
+	if got.Item != nil {
+		t.Errorf("The item should not be written, got %v\n", got.Item)
+	}
+}
+
+func TestTransactWriteItemsIdempotency(t *testing.T) {
+	client := NewDynamoDBClient()
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	client.SetClock(clock.Now)
+	createTestTable(t, client, "counter", "")
+	increment := func(token string, by string) error {
+		_, err := client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+			ClientRequestToken: aws.String(token),
+			TransactItems: []*dynamodb.TransactWriteItem{
+				{
+					Update: &dynamodb.Update{
+						TableName:                 aws.String("counter"),
+						Key:                       map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}},
+						UpdateExpression:          aws.String("ADD n :by"),
+						ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":by": {N: aws.String(by)}},
+					},
+				},
+			},
+		})
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := increment("token", "1"); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+	}
+	if err := increment("token", "2"); errorCode(err) != dynamodb.ErrCodeIdempotentParameterMismatchException {
+		t.Errorf("Expecting the idempotent parameter mismatch, got %v\n", err)
+	}
+	got, _ := client.GetItem(&dynamodb.GetItemInput{TableName: aws.String("counter"), Key: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}}})
+	if *got.Item["n"].N != "1" {
+		t.Errorf("Expecting %s, got %s\n", "1", *got.Item["n"].N)
+	}
+
+	// The token is accepted again with the other request after it expires
+	clock.Advance(clientTokenLifetime)
+	if err := increment("token", "2"); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	got, _ = client.GetItem(&dynamodb.GetItemInput{TableName: aws.String("counter"), Key: map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}}})
+	if *got.Item["n"].N != "3" {
+		t.Errorf("Expecting %s, got %s\n", "3", *got.Item["n"].N)
+	}
+}
+
+func TestTransactErrors(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "user", "")
+	key := map[string]*dynamodb.AttributeValue{"pk": {S: aws.String("a")}}
+	tooMany := []*dynamodb.TransactWriteItem{}
+	for i := 0; i <= maxTransactWriteItems; i++ {
+		tooMany = append(tooMany, &dynamodb.TransactWriteItem{
+			Put: &dynamodb.Put{TableName: aws.String("user"), Item: map[string]*dynamodb.AttributeValue{"pk": {N: aws.String("1")}}},
+		})
+	}
+	testCases := []struct {
+		items []*dynamodb.TransactWriteItem
+		gets  []*dynamodb.TransactGetItem
+	}{
+		{items: tooMany},
+		{items: []*dynamodb.TransactWriteItem{
+			{Delete: &dynamodb.Delete{TableName: aws.String("user"), Key: key}},
+			{ConditionCheck: &dynamodb.ConditionCheck{TableName: aws.String("user"), Key: key, ConditionExpression: aws.String("attribute_exists(pk)")}},
+		}},
+		{items: []*dynamodb.TransactWriteItem{
+			{Delete: &dynamodb.Delete{TableName: aws.String("user"), Key: key}, Put: &dynamodb.Put{TableName: aws.String("user"), Item: key}},
+		}},
+		{items: []*dynamodb.TransactWriteItem{
+			{Update: &dynamodb.Update{TableName: aws.String("user"), Key: key, UpdateExpression: aws.String("REMOVE pk")}},
+		}},
+		{gets: []*dynamodb.TransactGetItem{
+			{Get: &dynamodb.Get{TableName: aws.String("user"), Key: key}},
+			{Get: &dynamodb.Get{TableName: aws.String("user"), Key: key}},
+		}},
+	}
+	for i, tc := range testCases {
+		var err error
+		if tc.gets != nil {
+			_, err = client.TransactGetItems(&dynamodb.TransactGetItemsInput{TransactItems: tc.gets})
+		} else {
+			_, err = client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: tc.items})
+		}
+		if errorCode(err) != errCodeValidationException {
+			t.Errorf("[%d] Expecting the validation error, got %v\n", i+1, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package mock
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+// maxExpiredAge is the age of the expiration time which is too old to be deleted
+// The items expired more than five years ago are not deleted by the service
+const maxExpiredAge = 5 * 365 * 24 * time.Hour
+
+// serviceIdentity is the identity of the stream records of the items deleted by the time to live
+var serviceIdentity = &dynamodbstreams.Identity{
+	PrincipalId: aws.String("dynamodb.amazonaws.com"),
+	Type:        aws.String("Service"),
+}
+
+// Clock returns the current time of the mock client
+type Clock func() time.Time
+
+// ManualClock is the clock which moves only when it is set or advanced
+type ManualClock struct {
+	now   time.Time
+	mutex *sync.Mutex
+}
+
+// NewManualClock creates a manual clock at the given time
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now, mutex: new(sync.Mutex)}
+}
+
+// Now returns the current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// Set moves the clock to the given time
+func (c *ManualClock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock forward by the duration
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SetClock replaces the clock of the client, nil restores the system clock
+// The clock decides the expiration of the items and the creation time of the stream records
+func (d *DynamoDBClient) SetClock(clock Clock) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if clock == nil {
+		clock = time.Now
+	}
+	d.clock = clock
+}
+
+func (d *DynamoDBClient) now() time.Time {
+	return d.clock()
+}
+
+// timeToLive returns the time to live description of the table
+func (t *table) timeToLive() *dynamodb.TimeToLiveDescription {
+	if t.ttl == nil {
+		return &dynamodb.TimeToLiveDescription{TimeToLiveStatus: aws.String(dynamodb.TimeToLiveStatusDisabled)}
+	}
+	return awsutil.CopyOf(t.ttl).(*dynamodb.TimeToLiveDescription)
+}
+
+// DescribeTimeToLive is mocking the dynamodb DescribeTimeToLive operation
+func (d *DynamoDBClient) DescribeTimeToLive(input *dynamodb.DescribeTimeToLiveInput) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	if err := d.faults.inject("DescribeTimeToLive"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t, err := d.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.DescribeTimeToLiveOutput{
+		TimeToLiveDescription: t.timeToLive(),
+	}, nil
+}
+
+// UpdateTimeToLive is mocking the dynamodb UpdateTimeToLive operation
+// The change is applied immediately without the cooldown of the service
+func (d *DynamoDBClient) UpdateTimeToLive(input *dynamodb.UpdateTimeToLiveInput) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	if err := d.faults.inject("UpdateTimeToLive"); err != nil {
+		return nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	t, err := d.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	spec := input.TimeToLiveSpecification
+	if spec == nil {
+		return nil, validationError("1 validation error detected: Value null at 'timeToLiveSpecification' failed to satisfy constraint: Member must not be null")
+	}
+	if spec.Enabled == nil {
+		return nil, validationError("1 validation error detected: Value null at 'timeToLiveSpecification.enabled' failed to satisfy constraint: Member must not be null")
+	}
+	if aws.StringValue(spec.AttributeName) == "" {
+		return nil, validationError("1 validation error detected: Value at 'timeToLiveSpecification.attributeName' failed to satisfy constraint: Member must have length greater than or equal to 1")
+	}
+	if *spec.Enabled {
+		if t.ttl != nil {
+			return nil, validationError("TimeToLive is already enabled")
+		}
+		t.ttl = &dynamodb.TimeToLiveDescription{
+			AttributeName:    aws.String(*spec.AttributeName),
+			TimeToLiveStatus: aws.String(dynamodb.TimeToLiveStatusEnabled),
+		}
+	} else {
+		if t.ttl == nil {
+			return nil, validationError("TimeToLive is already disabled")
+		}
+		if *t.ttl.AttributeName != *spec.AttributeName {
+			return nil, validationError("TimeToLive is active on a different AttributeName: current AttributeName is %s", *t.ttl.AttributeName)
+		}
+		t.ttl = nil
+	}
+	return &dynamodb.UpdateTimeToLiveOutput{
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			AttributeName: aws.String(*spec.AttributeName),
+			Enabled:       aws.Bool(*spec.Enabled),
+		},
+	}, nil
+}
+
+// expired reports whether the time to live attribute of the item is before the given time
+// Only the number attributes of the epoch seconds are honored like the service
+func expired(v *dynamodb.AttributeValue, now time.Time) bool {
+	if v == nil || v.N == nil {
+		return false
+	}
+	f, ok := new(big.Float).SetString(*v.N)
+	if !ok {
+		return false
+	}
+	sec, _ := f.Int64()
+	return sec < now.Unix() && sec >= now.Add(-maxExpiredAge).Unix()
+}
+
+// ExpireNow deletes the expired items of all the tables enabling the time to live and returns the number of them
+// The deletions are recorded into the streams as the service deletions
+func (d *DynamoDBClient) ExpireNow() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	now := d.now()
+	count := 0
+	for _, t := range d.tables {
+		if t.ttl == nil {
+			continue
+		}
+		keys := []map[string]*dynamodb.AttributeValue{}
+		for _, r := range t.records {
+			if expired(r.item[*t.ttl.AttributeName], now) {
+				keys = append(keys, keyOf(r.item, t.keyAttributes()))
+			}
+		}
+		for _, key := range keys {
+			t.deleteBy(key, serviceIdentity)
+		}
+		count += len(keys)
+	}
+	return count
+}
@@ -0,0 +1,128 @@
+package mock
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+func TestTimeToLive(t *testing.T) {
+	client := NewDynamoDBClient()
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	client.SetClock(clock.Now)
+	createTestTable(t, client, "session", "")
+	arn := enableTestStream(t, client, "session", dynamodb.StreamViewTypeOldImage)
+	if _, err := client.UpdateTimeToLive(&dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String("session"),
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			AttributeName: aws.String("expires"),
+			Enabled:       aws.Bool(true),
+		},
+	}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	desc, err := client.DescribeTimeToLive(&dynamodb.DescribeTimeToLiveInput{TableName: aws.String("session")})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if *desc.TimeToLiveDescription.TimeToLiveStatus != dynamodb.TimeToLiveStatusEnabled || *desc.TimeToLiveDescription.AttributeName != "expires" {
+		t.Errorf("Expecting the time to live enabled on expires, got %v\n", desc.TimeToLiveDescription)
+	}
+
+	now := clock.Now().Unix()
+	items := []map[string]*dynamodb.AttributeValue{
+		{"pk": {S: aws.String("past")}, "expires": {N: aws.String(strconv.FormatInt(now-10, 10))}},
+		{"pk": {S: aws.String("future")}, "expires": {N: aws.String(strconv.FormatInt(now+60, 10))}},
+		{"pk": {S: aws.String("string")}, "expires": {S: aws.String(strconv.FormatInt(now-10, 10))}},
+		{"pk": {S: aws.String("ancient")}, "expires": {N: aws.String("1")}},
+		{"pk": {S: aws.String("none")}},
+	}
+	writeTestItems(t, client, "session", items)
+
+	testCases := []struct {
+		advance time.Duration
+		expired int
+		remain  int
+	}{
+		{advance: 0, expired: 1, remain: 4},
+		{advance: 0, expired: 0, remain: 4},
+		{advance: 2 * time.Minute, expired: 1, remain: 3},
+	}
+	for i, tc := range testCases {
+		clock.Advance(tc.advance)
+		if got := client.ExpireNow(); got != tc.expired {
+			t.Errorf("[%d] Expecting %d expired items, got %d\n", i+1, tc.expired, got)
+		}
+		if items, _ := scanAll(t, client, &dynamodb.ScanInput{TableName: aws.String("session")}); len(items) != tc.remain {
+			t.Errorf("[%d] Expecting %d remaining items, got %d\n", i+1, tc.remain, len(items))
+		}
+	}
+
+	streams := NewDynamoDBStreamsClient(client)
+	stream, err := streams.DescribeStream(&dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(arn)})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	records := readShard(t, streams, arn, *stream.StreamDescription.Shards[0].ShardId)
+	removes := []string{}
+	for _, r := range records {
+		if *r.EventName != dynamodbstreams.OperationTypeRemove {
+			if r.UserIdentity != nil {
+				t.Errorf("Expecting no user identity for %s, got %v\n", *r.EventName, r.UserIdentity)
+			}
+			continue
+		}
+		removes = append(removes, *r.Dynamodb.OldImage["pk"].S)
+		if r.UserIdentity == nil || *r.UserIdentity.Type != "Service" || *r.UserIdentity.PrincipalId != "dynamodb.amazonaws.com" {
+			t.Errorf("Expecting the service identity, got %v\n", r.UserIdentity)
+		}
+	}
+	// The records are created at the time of the clock
+	last := records[len(records)-1].Dynamodb.ApproximateCreationDateTime
+	if !last.Equal(clock.Now()) {
+		t.Errorf("Expecting the last record created at %s, got %s\n", clock.Now(), last)
+	}
+	if strings.Join(removes, ",") != "past,future" {
+		t.Errorf("Expecting the removes of past,future, got %s\n", strings.Join(removes, ","))
+	}
+}
+
+func TestUpdateTimeToLiveErrors(t *testing.T) {
+	client := NewDynamoDBClient()
+	createTestTable(t, client, "session", "")
+	testCases := []struct {
+		name    string
+		enabled bool
+		err     string
+	}{
+		{name: "expires", enabled: false, err: "TimeToLive is already disabled"},
+		{name: "expires", enabled: true},
+		{name: "ttl", enabled: true, err: "TimeToLive is already enabled"},
+		{name: "ttl", enabled: false, err: "TimeToLive is active on a different AttributeName: current AttributeName is expires"},
+		{name: "", enabled: false, err: "attributeName"},
+		{name: "expires", enabled: false},
+	}
+	for i, tc := range testCases {
+		_, err := client.UpdateTimeToLive(&dynamodb.UpdateTimeToLiveInput{
+			TableName: aws.String("session"),
+			TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+				AttributeName: aws.String(tc.name),
+				Enabled:       aws.Bool(tc.enabled),
+			},
+		})
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("[%d] Expecting the error %q, got %v\n", i+1, tc.err, err)
+		}
+	}
+}
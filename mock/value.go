@@ -0,0 +1,399 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/base64"
+	"math/big"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Attribute types of the dynamodb
+const (
+	typeS    = "S"
+	typeN    = "N"
+	typeB    = "B"
+	typeBOOL = "BOOL"
+	typeNULL = "NULL"
+	typeSS   = "SS"
+	typeNS   = "NS"
+	typeBS   = "BS"
+	typeM    = "M"
+	typeL    = "L"
+)
+
+const (
+	maxNumberDigits   = 38
+	maxNumberExponent = 126
+	minNumberExponent = -130
+)
+
+// number is an exact decimal number of coef * 10^exp
+type number struct {
+	coef *big.Int
+	exp  int
+}
+
+var bigTen = big.NewInt(10)
+
+// parseNumber parses the dynamodb number string
+func parseNumber(s string) (*number, bool) {
+	s = strings.TrimSpace(s)
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa = s[:i]
+		e, ok := new(big.Int).SetString(strings.TrimPrefix(s[i+1:], "+"), 10)
+		if !ok || !e.IsInt64() || e.Int64() > 1000 || e.Int64() < -1000 {
+			return nil, false
+		}
+		exp = int(e.Int64())
+	}
+	sign := ""
+	if strings.HasPrefix(mantissa, "-") || strings.HasPrefix(mantissa, "+") {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	if i := strings.Index(mantissa, "."); i >= 0 {
+		exp -= len(mantissa) - i - 1
+		mantissa = mantissa[:i] + mantissa[i+1:]
+	}
+	if mantissa == "" || strings.IndexFunc(mantissa, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return nil, false
+	}
+	coef, _ := new(big.Int).SetString(sign+mantissa, 10)
+	return (&number{coef: coef, exp: exp}).normalize(), true
+}
+
+// normalize strips the trailing zeros of the coefficient
+func (n *number) normalize() *number {
+	if n.coef.Sign() == 0 {
+		n.exp = 0
+		return n
+	}
+	q, r := new(big.Int), new(big.Int)
+	for {
+		q.QuoRem(n.coef, bigTen, r)
+		if r.Sign() != 0 {
+			return n
+		}
+		n.coef.Set(q)
+		n.exp++
+	}
+}
+
+// align returns the coefficients of the numbers scaled to the same exponent
+func align(a, b *number) (*big.Int, *big.Int, int) {
+	ac, bc := new(big.Int).Set(a.coef), new(big.Int).Set(b.coef)
+	exp := a.exp
+	if a.exp > b.exp {
+		ac.Mul(ac, new(big.Int).Exp(bigTen, big.NewInt(int64(a.exp-b.exp)), nil))
+		exp = b.exp
+	} else if b.exp > a.exp {
+		bc.Mul(bc, new(big.Int).Exp(bigTen, big.NewInt(int64(b.exp-a.exp)), nil))
+	}
+	return ac, bc, exp
+}
+
+func (n *number) cmp(o *number) int {
+	ac, bc, _ := align(n, o)
+	return ac.Cmp(bc)
+}
+
+func (n *number) add(o *number) *number {
+	ac, bc, exp := align(n, o)
+	return (&number{coef: ac.Add(ac, bc), exp: exp}).normalize()
+}
+
+func (n *number) sub(o *number) *number {
+	ac, bc, exp := align(n, o)
+	return (&number{coef: ac.Sub(ac, bc), exp: exp}).normalize()
+}
+
+// valid reports whether the number fits in the dynamodb number precision and magnitude
+func (n *number) valid() bool {
+	if n.coef.Sign() == 0 {
+		return true
+	}
+	digits := len(new(big.Int).Abs(n.coef).String())
+	magnitude := n.exp + digits
+	return digits <= maxNumberDigits && magnitude <= maxNumberExponent && magnitude > minNumberExponent
+}
+
+// String returns the canonical decimal representation
+func (n *number) String() string {
+	digits := new(big.Int).Abs(n.coef).String()
+	sign := ""
+	if n.coef.Sign() < 0 {
+		sign = "-"
+	}
+	switch {
+	case n.exp >= 0:
+		return sign + digits + strings.Repeat("0", n.exp)
+	case -n.exp < len(digits):
+		i := len(digits) + n.exp
+		return sign + digits[:i] + "." + digits[i:]
+	}
+	return sign + "0." + strings.Repeat("0", -n.exp-len(digits)) + digits
+}
+
+// typeOf returns the type of the attribute value
+func typeOf(v *dynamodb.AttributeValue) string {
+	switch {
+	case v == nil:
+		return ""
+	case v.S != nil:
+		return typeS
+	case v.N != nil:
+		return typeN
+	case v.B != nil:
+		return typeB
+	case v.BOOL != nil:
+		return typeBOOL
+	case v.NULL != nil:
+		return typeNULL
+	case v.SS != nil:
+		return typeSS
+	case v.NS != nil:
+		return typeNS
+	case v.BS != nil:
+		return typeBS
+	case v.M != nil:
+		return typeM
+	case v.L != nil:
+		return typeL
+	}
+	return ""
+}
+
+// countTypes returns the number of the types set in the attribute value
+func countTypes(v *dynamodb.AttributeValue) int {
+	count := 0
+	for _, set := range []bool{v.S != nil, v.N != nil, v.B != nil, v.BOOL != nil, v.NULL != nil, v.SS != nil, v.NS != nil, v.BS != nil, v.M != nil, v.L != nil} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
+
+// compareScalars compares the values of the same scalar type (S, N or B)
+func compareScalars(a, b *dynamodb.AttributeValue) int {
+	switch {
+	case a.S != nil:
+		return strings.Compare(*a.S, *b.S)
+	case a.N != nil:
+		an, _ := parseNumber(*a.N)
+		bn, _ := parseNumber(*b.N)
+		return an.cmp(bn)
+	}
+	return bytes.Compare(a.B, b.B)
+}
+
+// equalValues reports whether the attribute values are equal by value
+// Sets are compared regardless of the order of the elements
+func equalValues(a, b *dynamodb.AttributeValue) bool {
+	ta := typeOf(a)
+	if ta != typeOf(b) {
+		return false
+	}
+	switch ta {
+	case typeS, typeN, typeB:
+		return compareScalars(a, b) == 0
+	case typeBOOL:
+		return *a.BOOL == *b.BOOL
+	case typeNULL:
+		return true
+	case typeSS, typeNS, typeBS:
+		ea, eb := setElements(a), setElements(b)
+		if len(ea) != len(eb) {
+			return false
+		}
+		keys := map[string]bool{}
+		for _, e := range ea {
+			keys[encodeValue(e)] = true
+		}
+		for _, e := range eb {
+			if !keys[encodeValue(e)] {
+				return false
+			}
+		}
+		return true
+	case typeM:
+		if len(a.M) != len(b.M) {
+			return false
+		}
+		for k, v := range a.M {
+			if w, ok := b.M[k]; !ok || !equalValues(v, w) {
+				return false
+			}
+		}
+		return true
+	case typeL:
+		if len(a.L) != len(b.L) {
+			return false
+		}
+		for i := range a.L {
+			if !equalValues(a.L[i], b.L[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// setElements returns the elements of the set as scalar attribute values
+func setElements(v *dynamodb.AttributeValue) []*dynamodb.AttributeValue {
+	elems := []*dynamodb.AttributeValue{}
+	for _, s := range v.SS {
+		elems = append(elems, &dynamodb.AttributeValue{S: s})
+	}
+	for _, n := range v.NS {
+		elems = append(elems, &dynamodb.AttributeValue{N: n})
+	}
+	for _, b := range v.BS {
+		elems = append(elems, &dynamodb.AttributeValue{B: b})
+	}
+	return elems
+}
+
+// encodeValue returns the canonical string of the scalar value, equal values have the same string
+func encodeValue(v *dynamodb.AttributeValue) string {
+	switch {
+	case v.S != nil:
+		return "S:" + *v.S
+	case v.N != nil:
+		if n, ok := parseNumber(*v.N); ok {
+			return "N:" + n.String()
+		}
+		return "N:" + *v.N
+	case v.B != nil:
+		return "B:" + base64.StdEncoding.EncodeToString(v.B)
+	}
+	return ""
+}
+
+// copyValue returns a deep copy of the attribute value
+func copyValue(v *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if v == nil {
+		return nil
+	}
+	c := &dynamodb.AttributeValue{}
+	if v.S != nil {
+		s := *v.S
+		c.S = &s
+	}
+	if v.N != nil {
+		n := *v.N
+		c.N = &n
+	}
+	if v.B != nil {
+		c.B = append([]byte{}, v.B...)
+	}
+	if v.BOOL != nil {
+		b := *v.BOOL
+		c.BOOL = &b
+	}
+	if v.NULL != nil {
+		b := *v.NULL
+		c.NULL = &b
+	}
+	if v.SS != nil {
+		c.SS = make([]*string, len(v.SS))
+		for i, s := range v.SS {
+			e := *s
+			c.SS[i] = &e
+		}
+	}
+	if v.NS != nil {
+		c.NS = make([]*string, len(v.NS))
+		for i, n := range v.NS {
+			e := *n
+			c.NS[i] = &e
+		}
+	}
+	if v.BS != nil {
+		c.BS = make([][]byte, len(v.BS))
+		for i, b := range v.BS {
+			c.BS[i] = append([]byte{}, b...)
+		}
+	}
+	if v.M != nil {
+		c.M = copyItem(v.M)
+	}
+	if v.L != nil {
+		c.L = make([]*dynamodb.AttributeValue, len(v.L))
+		for i, e := range v.L {
+			c.L[i] = copyValue(e)
+		}
+	}
+	return c
+}
+
+// copyItem returns a deep copy of the item
+func copyItem(item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if item == nil {
+		return nil
+	}
+	c := make(map[string]*dynamodb.AttributeValue, len(item))
+	for k, v := range item {
+		c[k] = copyValue(v)
+	}
+	return c
+}
+
+// validateValue checks the attribute value like the dynamodb does
+func validateValue(v *dynamodb.AttributeValue) error {
+	if v == nil || countTypes(v) != 1 {
+		return validationError("Supplied AttributeValue is empty, must contain exactly one of the supported datatypes")
+	}
+	switch typeOf(v) {
+	case typeN:
+		if n, ok := parseNumber(*v.N); !ok || !n.valid() {
+			return validationError("A value provided cannot be converted into a number")
+		}
+	case typeSS, typeNS, typeBS:
+		elems := setElements(v)
+		if len(elems) == 0 {
+			return validationError("One or more parameter values were invalid: An %s set  may not be empty", setTypeNames[typeOf(v)])
+		}
+		seen := map[string]bool{}
+		for _, e := range elems {
+			if err := validateValue(e); err != nil {
+				return err
+			}
+			key := encodeValue(e)
+			if seen[key] {
+				return validationError("Input collection %s contains duplicates.", setString(v))
+			}
+			seen[key] = true
+		}
+	case typeM:
+		for _, e := range v.M {
+			if err := validateValue(e); err != nil {
+				return err
+			}
+		}
+	case typeL:
+		for _, e := range v.L {
+			if err := validateValue(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var setTypeNames = map[string]string{
+	typeSS: "string",
+	typeNS: "number",
+	typeBS: "binary",
+}
+
+func setString(v *dynamodb.AttributeValue) string {
+	elems := []string{}
+	for _, e := range setElements(v) {
+		elems = append(elems, strings.SplitN(encodeValue(e), ":", 2)[1])
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
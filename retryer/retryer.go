@@ -3,11 +3,18 @@ package retryer
 import (
 	"math"
 	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
 const (
 	minRetryBackoff = 64 * time.Millisecond
 	maxRetryBackoff = 5 * time.Second
+
+	// MaxAttempts is the maximum number of attempts of the failed request
+	MaxAttempts = 10
 )
 
 // RetryBackoff returns exponential retry backoff duration
@@ -18,3 +25,14 @@ func RetryBackoff(attempts int) time.Duration {
 	}
 	return backoff
 }
+
+// IsRetryable reports whether the failed request may succeed on retry such as the throttled requests
+func IsRetryable(err error) bool {
+	if request.IsErrorThrottle(err) || request.IsErrorRetryable(err) {
+		return true
+	}
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == dynamodb.ErrCodeInternalServerError
+	}
+	return false
+}
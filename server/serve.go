@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/mingrammer/cfmt"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+const (
+	snapshotFile = "snapshot.json"
+	// expiryInterval is the interval of deleting the expired items of the tables enabling the time to live
+	expiryInterval = time.Minute
+)
+
+// Options configures the local server
+type Options struct {
+	Port             int
+	DataDir          string        // Directory of the snapshot. The data is kept in memory only if empty
+	SnapshotInterval time.Duration // Interval of the periodic snapshots. 0 means only on shutdown
+}
+
+// Serve serves the in-memory dynamodb until interrupted
+// The tables are loaded from the data directory at startup and saved into it periodically and on shutdown
+// The expired items are deleted every minute
+func Serve(opts Options) error {
+	client := mock.NewDynamoDBClient()
+	path := ""
+	if opts.DataDir != "" {
+		if err := os.MkdirAll(opts.DataDir, 0755); err != nil {
+			return err
+		}
+		path = filepath.Join(opts.DataDir, snapshotFile)
+		if _, err := os.Stat(path); err == nil {
+			if err := client.LoadFile(path); err != nil {
+				return fmt.Errorf("Failed to load the snapshot '%s', got %s", path, err.Error())
+			}
+			cfmt.Successf("Loaded the snapshot '%s'.\n", path)
+		}
+	}
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", opts.Port),
+		Handler: NewHandler(client).WithStreams(mock.NewDynamoDBStreamsClient(client)),
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	cfmt.Infof("Serving the in-memory dynamodb on %s...\n", srv.Addr)
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+	var tick <-chan time.Time
+	if path != "" && opts.SnapshotInterval > 0 {
+		ticker := time.NewTicker(opts.SnapshotInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	expiry := time.NewTicker(expiryInterval)
+	defer expiry.Stop()
+	for {
+		select {
+		case err := <-errc:
+			return err
+		case <-expiry.C:
+			client.ExpireNow()
+		case <-tick:
+			if err := client.SaveFile(path); err != nil {
+				cfmt.Warningf("Failed to save the snapshot '%s', got %s\n", path, err.Error())
+			}
+		case <-sigc:
+			cfmt.Infof("Shutting down the server...\n")
+			srv.Shutdown(context.Background())
+			if path == "" {
+				return nil
+			}
+			if err := client.SaveFile(path); err != nil {
+				return fmt.Errorf("Failed to save the snapshot '%s', got %s", path, err.Error())
+			}
+			cfmt.Successf("Saved the snapshot '%s'.\n", path)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+	"github.com/mingrammer/dynamodb-toolkit/awsjson"
+)
+
+const (
+	// targetPrefix is the prefix of the X-Amz-Target header of the dynamodb operations
+	targetPrefix = "DynamoDB_20120810."
+	// streamsTargetPrefix is the prefix of the X-Amz-Target header of the dynamodb streams operations
+	streamsTargetPrefix = "DynamoDBStreams_20120810."
+	// errorTypePrefix is the prefix of the error type in the error response
+	errorTypePrefix = "com.amazonaws.dynamodb.v20120810#"
+	contentType     = "application/x-amz-json-1.0"
+
+	errCodeUnknownOperation = "UnknownOperationException"
+	errCodeSerialization    = "SerializationException"
+)
+
+// operations are the dynamodb operations served by the handler
+var operations = []string{
+	"BatchGetItem",
+	"BatchWriteItem",
+	"CreateTable",
+	"DeleteItem",
+	"DeleteTable",
+	"DescribeTable",
+	"DescribeTimeToLive",
+	"GetItem",
+	"ListTables",
+	"PutItem",
+	"Query",
+	"Scan",
+	"TransactGetItems",
+	"TransactWriteItems",
+	"UpdateItem",
+	"UpdateTable",
+	"UpdateTimeToLive",
+}
+
+// streamsOperations are the dynamodb streams operations served by the handler
+var streamsOperations = []string{
+	"DescribeStream",
+	"GetRecords",
+	"GetShardIterator",
+	"ListStreams",
+}
+
+// Handler serves the dynamodb JSON protocol requests with the dynamodb client
+// The request signatures are not verified
+type Handler struct {
+	methods map[string]reflect.Value // Operation methods by the target
+}
+
+// NewHandler creates a handler serving the operations of the dynamodb client
+func NewHandler(client dynamodbiface.DynamoDBAPI) *Handler {
+	h := &Handler{methods: map[string]reflect.Value{}}
+	h.register(targetPrefix, operations, client)
+	return h
+}
+
+// WithStreams makes the handler serve the operations of the dynamodb streams client too
+func (h *Handler) WithStreams(streams dynamodbstreamsiface.DynamoDBStreamsAPI) *Handler {
+	h.register(streamsTargetPrefix, streamsOperations, streams)
+	return h
+}
+
+func (h *Handler) register(prefix string, ops []string, client interface{}) {
+	v := reflect.ValueOf(client)
+	for _, op := range ops {
+		h.methods[prefix+op] = v.MethodByName(op)
+	}
+}
+
+// ServeHTTP calls the operation of the X-Amz-Target header with the request body
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method, ok := h.methods[r.Header.Get("X-Amz-Target")]
+	if r.Method != http.MethodPost || !ok {
+		writeError(w, awserr.New(errCodeUnknownOperation, "", nil))
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, awserr.New(errCodeSerialization, err.Error(), nil))
+		return
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		body = []byte("{}")
+	}
+	input := reflect.New(method.Type().In(0).Elem())
+	if err := awsjson.Unmarshal(body, input.Interface()); err != nil {
+		writeError(w, awserr.New(errCodeSerialization, err.Error(), nil))
+		return
+	}
+	results := method.Call([]reflect.Value{input})
+	if err, ok := results[1].Interface().(error); ok && err != nil {
+		writeError(w, err)
+		return
+	}
+	output, err := awsjson.Marshal(results[0].Interface())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(output)
+}
+
+// writeError writes the error response with the error type and message
+// The modeled exceptions like TransactionCanceledException keep their members
+func writeError(w http.ResponseWriter, err error) {
+	code, message := "InternalFailure", err.Error()
+	if aerr, ok := err.(awserr.Error); ok {
+		code, message = aerr.Code(), aerr.Message()
+	}
+	body := map[string]interface{}{}
+	if reflect.TypeOf(err).Kind() == reflect.Ptr && reflect.TypeOf(err).Elem().Kind() == reflect.Struct {
+		if b, e := awsjson.Marshal(err); e == nil {
+			json.Unmarshal(b, &body)
+		}
+	}
+	body["__type"] = errorTypePrefix + code
+	body["message"] = message
+
+	status := http.StatusBadRequest
+	if code == dynamodb.ErrCodeInternalServerError || code == "InternalFailure" {
+		status = http.StatusInternalServerError
+	}
+	b, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(b)
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+func newTestSession(t *testing.T, srv *httptest.Server) *session.Session {
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		Endpoint:    aws.String(srv.URL),
+		Region:      aws.String("us-east-1"),
+		MaxRetries:  aws.Int(0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sess
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server) *dynamodb.DynamoDB {
+	return dynamodb.New(newTestSession(t, srv))
+}
+
+func TestHandler(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(mock.NewDynamoDBClient()))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: aws.String("S")},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		TableName: aws.String("user"),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	item := map[string]*dynamodb.AttributeValue{
+		"id":   {S: aws.String("a")},
+		"data": {B: []byte{0, 1, 2}},
+		"tags": {M: map[string]*dynamodb.AttributeValue{"n": {NS: aws.StringSlice([]string{"1", "2.5"})}}},
+	}
+	if _, err := client.PutItem(&dynamodb.PutItemInput{TableName: aws.String("user"), Item: item}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	got, err := client.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String("user"),
+		Key:       map[string]*dynamodb.AttributeValue{"id": {S: aws.String("a")}},
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if got.Item == nil || string(got.Item["data"].B) != string([]byte{0, 1, 2}) || len(got.Item["tags"].M["n"].NS) != 2 {
+		t.Errorf("Expecting %v, got %v\n", item, got.Item)
+	}
+	desc, err := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String("user")})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if desc.Table.CreationDateTime == nil || desc.Table.CreationDateTime.IsZero() || *desc.Table.ItemCount != 1 {
+		t.Errorf("Expecting the table description, got %v\n", desc.Table)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(mock.NewDynamoDBClient()))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	_, err := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String("nope")})
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != dynamodb.ErrCodeResourceNotFoundException {
+		t.Errorf("Expecting the resource not found error, got %v\n", err)
+	}
+
+	client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: aws.String("S")},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		TableName: aws.String("user"),
+	})
+	_, err = client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				ConditionCheck: &dynamodb.ConditionCheck{
+					TableName:           aws.String("user"),
+					Key:                 map[string]*dynamodb.AttributeValue{"id": {S: aws.String("a")}},
+					ConditionExpression: aws.String("attribute_exists(id)"),
+				},
+			},
+		},
+	})
+	canceled, ok := err.(*dynamodb.TransactionCanceledException)
+	if !ok || len(canceled.CancellationReasons) != 1 || *canceled.CancellationReasons[0].Code != "ConditionalCheckFailed" {
+		t.Errorf("Expecting the transaction canceled error with the reasons, got %v\n", err)
+	}
+
+	_, err = client.DescribeLimits(&dynamodb.DescribeLimitsInput{})
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != errCodeUnknownOperation {
+		t.Errorf("Expecting the unknown operation error, got %v\n", err)
+	}
+}
+
+func TestHandlerStreams(t *testing.T) {
+	mocked := mock.NewDynamoDBClient()
+	srv := httptest.NewServer(NewHandler(mocked).WithStreams(mock.NewDynamoDBStreamsClient(mocked)))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	streams := dynamodbstreams.New(newTestSession(t, srv))
+	created, err := client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: aws.String("S")},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		StreamSpecification: &dynamodb.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: aws.String(dynamodb.StreamViewTypeNewImage),
+		},
+		TableName: aws.String("user"),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if _, err := client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String("user"),
+		Item:      map[string]*dynamodb.AttributeValue{"id": {S: aws.String("a")}},
+	}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+
+	desc, err := streams.DescribeStream(&dynamodbstreams.DescribeStreamInput{StreamArn: created.TableDescription.LatestStreamArn})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	it, err := streams.GetShardIterator(&dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         desc.StreamDescription.StreamArn,
+		ShardId:           desc.StreamDescription.Shards[0].ShardId,
+		ShardIteratorType: aws.String(dynamodbstreams.ShardIteratorTypeTrimHorizon),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	records, err := streams.GetRecords(&dynamodbstreams.GetRecordsInput{ShardIterator: it.ShardIterator})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if len(records.Records) != 1 || *records.Records[0].EventName != dynamodbstreams.OperationTypeInsert || *records.Records[0].Dynamodb.NewImage["id"].S != "a" {
+		t.Errorf("Expecting the INSERT record of the item, got %v\n", records.Records)
+	}
+
+	_, err = streams.DescribeStream(&dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(*created.TableDescription.LatestStreamArn + "0")})
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != dynamodbstreams.ErrCodeResourceNotFoundException {
+		t.Errorf("Expecting the resource not found error, got %v\n", err)
+	}
+}
@@ -3,16 +3,56 @@ package service
 import (
 	"errors"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
 	"github.com/mingrammer/cfmt"
 	"github.com/mingrammer/dynamodb-toolkit/config"
 )
 
 // NewDynamoDBClient creates a dynamodb client
 func NewDynamoDBClient() (*dynamodb.DynamoDB, error) {
-	awsConf := config.GetAWSConfig()
-	profile := config.GetProfile()
+	return newDynamoDBClient(config.GetAWSConfig(), config.GetProfile())
+}
+
+// NewTargetDynamoDBClient creates a dynamodb client overriding the profile, region and endpoint of the global configuration
+func NewTargetDynamoDBClient(profile, region, endpoint string) (*dynamodb.DynamoDB, error) {
+	awsConf := config.GetAWSConfig().Copy()
+	if profile == "" {
+		profile = config.GetProfile()
+	} else {
+		// The static credentials take precedence over the profile
+		awsConf.Credentials = nil
+	}
+	if region != "" {
+		awsConf.WithRegion(region)
+	}
+	if endpoint != "" {
+		awsConf.WithEndpoint(endpoint)
+	}
+	return newDynamoDBClient(awsConf, profile)
+}
+
+// NewDynamoDBStreamsClient creates a dynamodb streams client
+// The endpoint of the global configuration is shared with the dynamodb client
+func NewDynamoDBStreamsClient() (*dynamodbstreams.DynamoDBStreams, error) {
+	sess, err := newSession(config.GetAWSConfig(), config.GetProfile())
+	if err != nil {
+		return nil, err
+	}
+	return dynamodbstreams.New(sess), nil
+}
+
+func newDynamoDBClient(awsConf *aws.Config, profile string) (*dynamodb.DynamoDB, error) {
+	sess, err := newSession(awsConf, profile)
+	if err != nil {
+		return nil, err
+	}
+	return dynamodb.New(sess), nil
+}
+
+func newSession(awsConf *aws.Config, profile string) (*session.Session, error) {
 	sess, err := session.NewSessionWithOptions(session.Options{
 		Config:            *awsConf,
 		Profile:           profile,
@@ -21,5 +61,5 @@ func NewDynamoDBClient() (*dynamodb.DynamoDB, error) {
 	if err != nil {
 		return nil, errors.New(cfmt.Serror(err.Error()))
 	}
-	return dynamodb.New(sess), nil
+	return sess, nil
 }
@@ -0,0 +1,280 @@
+package toolkit
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+const defaultPageSize = 50
+
+// BrowseOptions holds the options of the browser
+type BrowseOptions struct {
+	PageSize int64 // Number of the items of a page. Defaults to 50
+	ReadOnly bool  // Disable the edit and delete actions
+}
+
+// Browser holds dynamodb client
+type Browser struct {
+	client dynamodbiface.DynamoDBAPI
+}
+
+// NewBrowser creates a browser of the tables with the dynamodb client
+func NewBrowser(client dynamodbiface.DynamoDBAPI) *Browser {
+	return &Browser{client: client}
+}
+
+// tableSummary is a row of the table list
+type tableSummary struct {
+	name        string
+	status      string
+	billingMode string
+	itemCount   int64
+	sizeBytes   int64
+}
+
+// summarizeTables describes all the tables
+func summarizeTables(client dynamodbiface.DynamoDBAPI) ([]*tableSummary, error) {
+	names, err := listTables(client)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]*tableSummary, len(names))
+	for i, name := range names {
+		meta, err := readMeta(client, name)
+		if err != nil {
+			return nil, err
+		}
+		desc := meta.Table
+		billingMode := dynamodb.BillingModeProvisioned
+		if desc.BillingModeSummary != nil && desc.BillingModeSummary.BillingMode != nil {
+			billingMode = *desc.BillingModeSummary.BillingMode
+		}
+		if billingMode == dynamodb.BillingModeProvisioned && desc.ProvisionedThroughput != nil {
+			billingMode += fmt.Sprintf(" (%d RCU, %d WCU)", aws.Int64Value(desc.ProvisionedThroughput.ReadCapacityUnits), aws.Int64Value(desc.ProvisionedThroughput.WriteCapacityUnits))
+		}
+		summaries[i] = &tableSummary{
+			name:        name,
+			status:      aws.StringValue(desc.TableStatus),
+			billingMode: billingMode,
+			itemCount:   aws.Int64Value(desc.ItemCount),
+			sizeBytes:   aws.Int64Value(desc.TableSizeBytes),
+		}
+	}
+	return summaries, nil
+}
+
+// humanBytes returns the size in the binary units
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// itemPager reads the items of a table page by page with the scan, or the query if there is the key condition
+type itemPager struct {
+	finder   *Finder
+	desc     *dynamodb.TableDescription
+	opts     FindOptions
+	pageSize int64
+	starts   []map[string]*dynamodb.AttributeValue // Start keys of the pages read so far
+	page     int
+	items    []map[string]*dynamodb.AttributeValue
+	lastKey  map[string]*dynamodb.AttributeValue
+}
+
+func newItemPager(client dynamodbiface.DynamoDBAPI, desc *dynamodb.TableDescription, pageSize int64) *itemPager {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	return &itemPager{finder: NewFinder(client), desc: desc, pageSize: pageSize}
+}
+
+// reset reads the first page of the options
+func (p *itemPager) reset(opts FindOptions) error {
+	p.opts = opts
+	p.starts = []map[string]*dynamodb.AttributeValue{nil}
+	p.page = 0
+	return p.load()
+}
+
+func (p *itemPager) load() error {
+	read := p.finder.scanReader(*p.desc.TableName, p.opts)
+	if p.opts.KeyCondition != "" {
+		read = p.finder.queryReader(*p.desc.TableName, p.opts)
+	}
+	page, err := readItems(read, p.starts[p.page], p.pageSize)
+	if err != nil {
+		return err
+	}
+	p.items, p.lastKey = page.items, page.lastKey
+	return nil
+}
+
+func (p *itemPager) hasNext() bool {
+	return len(p.lastKey) > 0
+}
+
+func (p *itemPager) hasPrev() bool {
+	return p.page > 0
+}
+
+// next reads the next page, if any
+func (p *itemPager) next() error {
+	if !p.hasNext() {
+		return nil
+	}
+	p.starts = append(p.starts[:p.page+1], p.lastKey)
+	p.page++
+	return p.load()
+}
+
+// prev reads the previous page again, if any
+func (p *itemPager) prev() error {
+	if !p.hasPrev() {
+		return nil
+	}
+	p.page--
+	return p.load()
+}
+
+func (p *itemPager) keyNames() []string {
+	return keyNamesOf(p.desc, p.opts.Index)
+}
+
+// guardedKey returns the key of the item and the condition that the item still exists
+// It prevents the edit from creating the item deleted in the meantime
+func (p *itemPager) guardedKey(item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, *string, map[string]*string) {
+	hash := *p.desc.KeySchema[0].AttributeName
+	return keyOf(item, p.desc.KeySchema), aws.String("attribute_exists(#key)"), map[string]*string{"#key": aws.String(hash)}
+}
+
+// setAttribute sets the top level attribute of the i-th item to the plain JSON value
+func (p *itemPager) setAttribute(i int, name, value string) error {
+	for _, k := range p.desc.KeySchema {
+		if *k.AttributeName == name {
+			return fmt.Errorf("Key attribute '%s' can not be changed", name)
+		}
+	}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return fmt.Errorf("Invalid JSON value '%s', got %s", value, err.Error())
+	}
+	av, err := typedItem(map[string]*dynamodb.AttributeValue{name: attributeValueOf(v)}, p.desc.AttributeDefinitions)
+	if err != nil {
+		return err
+	}
+	key, cond, names := p.guardedKey(p.items[i])
+	names["#name"] = aws.String(name)
+	output, err := p.finder.client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 p.desc.TableName,
+		Key:                       key,
+		UpdateExpression:          aws.String("SET #name = :value"),
+		ConditionExpression:       cond,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":value": av[name]},
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllNew),
+	})
+	if err != nil {
+		return err
+	}
+	p.items[i] = output.Attributes
+	return nil
+}
+
+// deleteItem deletes the i-th item
+func (p *itemPager) deleteItem(i int) error {
+	key, cond, names := p.guardedKey(p.items[i])
+	_, err := p.finder.client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName:                p.desc.TableName,
+		Key:                      key,
+		ConditionExpression:      cond,
+		ExpressionAttributeNames: names,
+	})
+	if err != nil {
+		return err
+	}
+	p.items = append(p.items[:i], p.items[i+1:]...)
+	return nil
+}
+
+// describeItem returns the lines of the attributes and their types, indenting the nested attributes
+func describeItem(item map[string]*dynamodb.AttributeValue) []string {
+	lines := []string{}
+	var describe func(label string, v *dynamodb.AttributeValue, depth int)
+	describe = func(label string, v *dynamodb.AttributeValue, depth int) {
+		indent := strings.Repeat("  ", depth)
+		switch {
+		case v.M != nil:
+			lines = append(lines, fmt.Sprintf("%s%s (M)", indent, label))
+			names := make([]string, 0, len(v.M))
+			for name := range v.M {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				describe(name, v.M[name], depth+1)
+			}
+		case v.L != nil:
+			lines = append(lines, fmt.Sprintf("%s%s (L)", indent, label))
+			for i, e := range v.L {
+				describe(fmt.Sprintf("[%d]", i), e, depth+1)
+			}
+		case v.B != nil:
+			lines = append(lines, fmt.Sprintf("%s%s (B): %s", indent, label, base64.StdEncoding.EncodeToString(v.B)))
+		default:
+			lines = append(lines, fmt.Sprintf("%s%s (%s): %s", indent, label, attributeType(v), plainValueJSON(v)))
+		}
+	}
+	names := make([]string, 0, len(item))
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		describe(name, item[name], 0)
+	}
+	return lines
+}
+
+// attributeType returns the type descriptor of the attribute value
+func attributeType(v *dynamodb.AttributeValue) string {
+	switch {
+	case v.S != nil:
+		return dynamodb.ScalarAttributeTypeS
+	case v.N != nil:
+		return dynamodb.ScalarAttributeTypeN
+	case v.B != nil:
+		return dynamodb.ScalarAttributeTypeB
+	case v.BOOL != nil:
+		return "BOOL"
+	case v.NULL != nil:
+		return "NULL"
+	case v.SS != nil:
+		return "SS"
+	case v.NS != nil:
+		return "NS"
+	case v.BS != nil:
+		return "BS"
+	case v.L != nil:
+		return "L"
+	case v.M != nil:
+		return "M"
+	}
+	return ""
+}
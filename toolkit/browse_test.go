@@ -0,0 +1,211 @@
+package toolkit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/gdamore/tcell/v2"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+func TestSummarizeTables(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createFindTestTable(t, client)
+	client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(5), WriteCapacityUnits: aws.Int64(10)},
+		TableName:             aws.String("config"),
+	})
+	summaries, err := summarizeTables(client)
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	testCases := []struct {
+		name        string
+		billingMode string
+		itemCount   int64
+	}{
+		{name: "config", billingMode: "PROVISIONED (5 RCU, 10 WCU)", itemCount: 0},
+		{name: "order", billingMode: "PAY_PER_REQUEST", itemCount: 12},
+	}
+	if len(summaries) != len(testCases) {
+		t.Fatalf("Expecting %d tables, got %d\n", len(testCases), len(summaries))
+	}
+	for i, tc := range testCases {
+		s := summaries[i]
+		if s.name != tc.name || s.billingMode != tc.billingMode || s.itemCount != tc.itemCount || s.status != dynamodb.TableStatusActive {
+			t.Errorf("[%d] Expecting %s %s %d, got %+v\n", i+1, tc.name, tc.billingMode, tc.itemCount, s)
+		}
+	}
+	if summaries[1].sizeBytes == 0 {
+		t.Errorf("Expecting the size of the order table\n")
+	}
+
+	sizes := []struct {
+		n    int64
+		want string
+	}{
+		{n: 0, want: "0 B"},
+		{n: 1023, want: "1023 B"},
+		{n: 1536, want: "1.5 KiB"},
+		{n: 3 << 30, want: "3.0 GiB"},
+	}
+	for i, tc := range sizes {
+		if got := humanBytes(tc.n); got != tc.want {
+			t.Errorf("[%d] Expecting %s, got %s\n", i+1, tc.want, got)
+		}
+	}
+}
+
+func TestItemPager(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createFindTestTable(t, client)
+	meta, _ := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String("order")})
+	pager := newItemPager(client, meta.Table, 5)
+	if err := pager.reset(FindOptions{}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+
+	testCases := []struct {
+		move  func() error
+		page  int
+		items int
+		next  bool
+	}{
+		{move: pager.next, page: 1, items: 5, next: true},
+		{move: pager.next, page: 2, items: 2, next: false},
+		{move: pager.next, page: 2, items: 2, next: false},
+		{move: pager.prev, page: 1, items: 5, next: true},
+		{move: pager.prev, page: 0, items: 5, next: true},
+		{move: pager.prev, page: 0, items: 5, next: true},
+		{
+			move: func() error {
+				return pager.reset(FindOptions{
+					KeyCondition: "#u = :u",
+					Filter:       "price > :p",
+					Names:        map[string]*string{"#u": aws.String("user")},
+					Values:       map[string]*dynamodb.AttributeValue{":u": {S: aws.String("user2")}, ":p": {N: aws.String("201")}},
+				})
+			},
+			page:  0,
+			items: 2,
+			next:  false,
+		},
+	}
+	for i, tc := range testCases {
+		if err := tc.move(); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if pager.page != tc.page || len(pager.items) != tc.items || pager.hasNext() != tc.next {
+			t.Errorf("[%d] Expecting the page %d of %d items with next %v, got the page %d of %d items with next %v\n", i+1, tc.page, tc.items, tc.next, pager.page, len(pager.items), pager.hasNext())
+		}
+	}
+
+	// The query reads the items by the sort key order
+	if err := pager.setAttribute(0, "note", `{"tags": ["gift"], "rush": true}`); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if got, _ := plainJSON(pager.items[0]); got != `{"id":2,"note":{"rush":true,"tags":["gift"]},"price":202,"user":"user2"}` {
+		t.Errorf("Expecting the updated item, got %s\n", got)
+	}
+	// The index key attribute is typed by the definition
+	if err := pager.setAttribute(1, "status", `"open"`); err != nil || *pager.items[1]["status"].S != "open" {
+		t.Errorf("Expecting the status updated, got %v, %v\n", pager.items[1]["status"], err)
+	}
+	deleted := pager.items[1]
+	if err := pager.deleteItem(1); err != nil || len(pager.items) != 1 {
+		t.Fatalf("Expecting the item deleted, got %d items, %v\n", len(pager.items), err)
+	}
+
+	pager.items = append(pager.items, deleted)
+	errCases := []struct {
+		err error
+		msg string
+	}{
+		{err: pager.setAttribute(0, "id", "1"), msg: "Key attribute 'id' can not be changed"},
+		{err: pager.setAttribute(0, "status", "true"), msg: "Attribute 'status' must be the type S"},
+		{err: pager.setAttribute(0, "note", "{"), msg: "Invalid JSON value"},
+		// The items deleted in the meantime are not recreated
+		{err: pager.setAttribute(1, "note", "1"), msg: "ConditionalCheckFailedException"},
+		{err: pager.deleteItem(1), msg: "ConditionalCheckFailedException"},
+	}
+	for i, tc := range errCases {
+		if tc.err == nil || !strings.Contains(tc.err.Error(), tc.msg) {
+			t.Errorf("[%d] Expecting the error %q, got %v\n", i+1, tc.msg, tc.err)
+		}
+	}
+}
+
+func TestDescribeItem(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"id":   {N: aws.String("1")},
+		"big":  {N: aws.String("12345678901234567891")},
+		"data": {B: []byte("hi")},
+		"tags": {SS: []*string{aws.String("a"), aws.String("b")}},
+		"profile": {M: map[string]*dynamodb.AttributeValue{
+			"name":   {S: aws.String("kim")},
+			"active": {BOOL: aws.Bool(true)},
+			"links":  {L: []*dynamodb.AttributeValue{{S: aws.String("x")}, {NULL: aws.Bool(true)}}},
+		}},
+	}
+	want := []string{
+		"big (N): 12345678901234567891",
+		"data (B): aGk=",
+		"id (N): 1",
+		"profile (M)",
+		"  active (BOOL): true",
+		"  links (L)",
+		`    [0] (S): "x"`,
+		"    [1] (NULL): null",
+		`  name (S): "kim"`,
+		`tags (SS): ["a","b"]`,
+	}
+	if got := describeItem(item); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expecting\n%s\ngot\n%s\n", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestBrowseApp(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createFindTestTable(t, client)
+	a := NewBrowser(client).newBrowseApp(BrowseOptions{PageSize: 10, ReadOnly: true})
+	if err := a.loadTables(); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if got := a.tables.GetCell(1, 0).Text; got != "order" {
+		t.Errorf("Expecting the order table, got %s\n", got)
+	}
+
+	a.openTable("order")
+	if got := a.items.GetCell(0, 0).Text + "," + a.items.GetCell(0, 1).Text; got != "user,id" {
+		t.Errorf("Expecting the key columns first, got %s\n", got)
+	}
+	if title := a.items.GetTitle(); !strings.Contains(title, "order, page 1") || !strings.Contains(title, "more") {
+		t.Errorf("Expecting the first page title, got %s\n", title)
+	}
+	a.items.GetInputCapture()(tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone))
+	if a.items.GetRowCount() != 3 || !strings.Contains(a.items.GetTitle(), "page 2") {
+		t.Errorf("Expecting the second page of 2 items, got %d rows of %s\n", a.items.GetRowCount()-1, a.items.GetTitle())
+	}
+
+	a.openItem(0)
+	if name, _ := a.pages.GetFrontPage(); name != itemPage || !strings.Contains(a.detail.GetText(true), "price (N):") {
+		t.Errorf("Expecting the item detail, got %s %s\n", name, a.detail.GetText(true))
+	}
+	// The read only browser does not delete
+	a.detail.GetInputCapture()(tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone))
+	if a.pages.HasPage(dialogPage) || !strings.Contains(a.status.GetText(true), "read only") {
+		t.Errorf("Expecting the read only error, got %s\n", a.status.GetText(true))
+	}
+	a.detail.GetInputCapture()(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone))
+	if name, _ := a.pages.GetFrontPage(); name != itemsPage {
+		t.Errorf("Expecting the items page, got %s\n", name)
+	}
+}
@@ -0,0 +1,378 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Page names of the browser
+const (
+	tablesPage  = "tables"
+	itemsPage   = "items"
+	itemPage    = "item"
+	dialogPage  = "dialog"
+	browseTitle = " dynamotk "
+)
+
+// Help lines of the pages
+const (
+	tablesHelp = "[yellow]Enter[-] open  [yellow]r[-] refresh  [yellow]q[-] quit"
+	itemsHelp  = "[yellow]Enter[-] detail  [yellow]n/p[-] next/prev page  [yellow]/[-] filter  [yellow]r[-] reload  [yellow]Esc[-] tables  [yellow]q[-] quit"
+	itemHelp   = "[yellow]e[-] edit attribute  [yellow]d[-] delete  [yellow]Esc[-] items  [yellow]q[-] quit"
+)
+
+// browseApp is the terminal UI of the browser
+type browseApp struct {
+	client   dynamodbiface.DynamoDBAPI
+	opts     BrowseOptions
+	app      *tview.Application
+	pages    *tview.Pages
+	tables   *tview.Table
+	items    *tview.Table
+	detail   *tview.TextView
+	status   *tview.TextView
+	pager    *itemPager
+	selected int // Index of the item shown in the detail
+}
+
+// Browse runs the terminal UI browsing the tables and their items until it quits
+func (b *Browser) Browse(opts BrowseOptions) error {
+	a := b.newBrowseApp(opts)
+	if err := a.loadTables(); err != nil {
+		return err
+	}
+	return a.app.Run()
+}
+
+func (b *Browser) newBrowseApp(opts BrowseOptions) *browseApp {
+	a := &browseApp{
+		client: b.client,
+		opts:   opts,
+		app:    tview.NewApplication(),
+		pages:  tview.NewPages(),
+		tables: tview.NewTable().SetSelectable(true, false).SetFixed(1, 0),
+		items:  tview.NewTable().SetSelectable(true, false).SetFixed(1, 0),
+		detail: tview.NewTextView().SetDynamicColors(true),
+		status: tview.NewTextView().SetDynamicColors(true),
+	}
+	a.tables.SetBorder(true).SetTitle(browseTitle + "tables ")
+	a.items.SetBorder(true)
+	a.detail.SetBorder(true)
+
+	a.tables.SetSelectedFunc(func(row, column int) {
+		if row > 0 {
+			a.openTable(a.tables.GetCell(row, 0).Text)
+		}
+	})
+	a.tables.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'r':
+			a.report(a.loadTables())
+			return nil
+		case 'q':
+			a.app.Stop()
+			return nil
+		}
+		return event
+	})
+	a.items.SetSelectedFunc(func(row, column int) {
+		if row > 0 {
+			a.openItem(row - 1)
+		}
+	})
+	a.items.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			a.showPage(tablesPage, tablesHelp)
+			return nil
+		}
+		switch event.Rune() {
+		case 'n':
+			a.report(a.pager.next())
+			a.renderItems()
+		case 'p':
+			a.report(a.pager.prev())
+			a.renderItems()
+		case 'r':
+			a.report(a.pager.load())
+			a.renderItems()
+		case '/':
+			a.showFilter()
+		case 'q':
+			a.app.Stop()
+		default:
+			return event
+		}
+		return nil
+	})
+	a.detail.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			a.renderItems()
+			a.showPage(itemsPage, itemsHelp)
+			return nil
+		}
+		switch event.Rune() {
+		case 'e':
+			a.showEdit()
+		case 'd':
+			a.confirmDelete()
+		case 'q':
+			a.app.Stop()
+		default:
+			return event
+		}
+		return nil
+	})
+
+	a.pages.AddPage(tablesPage, a.tables, true, true)
+	a.pages.AddPage(itemsPage, a.items, true, false)
+	a.pages.AddPage(itemPage, a.detail, true, false)
+	root := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(a.pages, 0, 1, true).
+		AddItem(a.status, 1, 0, false)
+	a.app.SetRoot(root, true)
+	a.status.SetText(tablesHelp)
+	return a
+}
+
+// report shows the error on the status line, if any
+func (a *browseApp) report(err error) {
+	if err != nil {
+		a.status.SetText("[red]" + tview.Escape(err.Error()))
+	}
+}
+
+func (a *browseApp) showPage(name, help string) {
+	a.pages.SwitchToPage(name)
+	a.status.SetText(help)
+}
+
+// closeDialog removes the dialog and focuses the page under it
+func (a *browseApp) closeDialog() {
+	a.pages.RemovePage(dialogPage)
+	if name, _ := a.pages.GetFrontPage(); name == itemPage {
+		a.app.SetFocus(a.detail)
+	} else {
+		a.app.SetFocus(a.items)
+	}
+}
+
+// showDialog shows the primitive over the current page at the center
+func (a *browseApp) showDialog(p tview.Primitive, width, height int) {
+	grid := tview.NewGrid().
+		SetColumns(0, width, 0).
+		SetRows(0, height, 0).
+		AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	a.pages.AddPage(dialogPage, grid, true, true)
+	a.app.SetFocus(p)
+}
+
+func (a *browseApp) loadTables() error {
+	summaries, err := summarizeTables(a.client)
+	if err != nil {
+		return err
+	}
+	a.tables.Clear()
+	for i, header := range []string{"Table", "Status", "Billing mode", "Items", "Size"} {
+		a.tables.SetCell(0, i, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+	for i, s := range summaries {
+		a.tables.SetCell(i+1, 0, tview.NewTableCell(s.name))
+		a.tables.SetCell(i+1, 1, tview.NewTableCell(s.status))
+		a.tables.SetCell(i+1, 2, tview.NewTableCell(s.billingMode))
+		a.tables.SetCell(i+1, 3, tview.NewTableCell(strconv.FormatInt(s.itemCount, 10)).SetAlign(tview.AlignRight))
+		a.tables.SetCell(i+1, 4, tview.NewTableCell(humanBytes(s.sizeBytes)).SetAlign(tview.AlignRight))
+	}
+	a.tables.Select(1, 0)
+	return nil
+}
+
+// openTable shows the first page of the items of the table
+func (a *browseApp) openTable(name string) {
+	meta, err := readMeta(a.client, name)
+	if err != nil {
+		a.report(err)
+		return
+	}
+	a.pager = newItemPager(a.client, meta.Table, a.opts.PageSize)
+	if err := a.pager.reset(FindOptions{}); err != nil {
+		a.report(err)
+		return
+	}
+	a.renderItems()
+	a.showPage(itemsPage, itemsHelp)
+	a.app.SetFocus(a.items)
+}
+
+func (a *browseApp) renderItems() {
+	p := a.pager
+	title := fmt.Sprintf("%s%s, page %d ", browseTitle, *p.desc.TableName, p.page+1)
+	if p.opts.Index != "" {
+		title += fmt.Sprintf("of %s ", p.opts.Index)
+	}
+	if p.opts.KeyCondition != "" || p.opts.Filter != "" {
+		title += "(filtered) "
+	}
+	if p.hasNext() {
+		title += "more... "
+	}
+	a.items.SetTitle(title)
+	a.items.Clear()
+	columns := tableColumns(p.items, p.keyNames())
+	for i, name := range columns {
+		a.items.SetCell(0, i, tview.NewTableCell(name).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+	for r, item := range p.items {
+		for c, name := range columns {
+			a.items.SetCell(r+1, c, tview.NewTableCell(cellString(item[name])).SetMaxWidth(maxCellWidth))
+		}
+	}
+	a.items.Select(1, 0).ScrollToBeginning()
+}
+
+// openItem shows the nested attributes of the item of the page
+func (a *browseApp) openItem(i int) {
+	if i < 0 || i >= len(a.pager.items) {
+		return
+	}
+	a.selected = i
+	keyJSON, _ := plainJSON(keyOf(a.pager.items[i], a.pager.desc.KeySchema))
+	a.detail.SetTitle(fmt.Sprintf("%s%s %s ", browseTitle, *a.pager.desc.TableName, tview.Escape(keyJSON)))
+	lines := describeItem(a.pager.items[i])
+	for j, line := range lines {
+		lines[j] = tview.Escape(line)
+	}
+	a.detail.SetText(strings.Join(lines, "\n")).ScrollToBeginning()
+	a.showPage(itemPage, itemHelp)
+	a.app.SetFocus(a.detail)
+}
+
+// showFilter shows the form of the query or scan options of the items
+func (a *browseApp) showFilter() {
+	opts := a.pager.opts
+	names, _ := jsonString(opts.Names)
+	values := ""
+	if opts.Values != nil {
+		values, _ = plainJSON(opts.Values)
+	}
+	form := tview.NewForm().
+		AddInputField("Index", opts.Index, 40, nil, nil).
+		AddInputField("Key condition", opts.KeyCondition, 40, nil, nil).
+		AddInputField("Filter", opts.Filter, 40, nil, nil).
+		AddInputField("Names", names, 40, nil, nil).
+		AddInputField("Values", values, 40, nil, nil)
+	text := func(label string) string {
+		return strings.TrimSpace(form.GetFormItemByLabel(label).(*tview.InputField).GetText())
+	}
+	form.AddButton("Apply", func() {
+		filtered := FindOptions{Index: text("Index"), KeyCondition: text("Key condition"), Filter: text("Filter")}
+		if s := text("Names"); s != "" {
+			m := map[string]string{}
+			if err := json.Unmarshal([]byte(s), &m); err != nil {
+				a.report(fmt.Errorf("Invalid expression attribute names '%s', got %s", s, err.Error()))
+				return
+			}
+			filtered.Names = aws.StringMap(m)
+		}
+		if s := text("Values"); s != "" {
+			v, err := ParsePlainJSON(s)
+			if err != nil {
+				a.report(err)
+				return
+			}
+			filtered.Values = v
+		}
+		if err := a.pager.reset(filtered); err != nil {
+			a.report(err)
+			return
+		}
+		a.closeDialog()
+		a.renderItems()
+		a.status.SetText(itemsHelp)
+	})
+	form.AddButton("Cancel", a.closeDialog)
+	form.SetCancelFunc(a.closeDialog)
+	form.SetBorder(true).SetTitle(" Filter, query if the key condition is set ")
+	a.showDialog(form, 60, 15)
+}
+
+// showEdit shows the form setting an attribute of the item after the confirmation
+func (a *browseApp) showEdit() {
+	if a.opts.ReadOnly {
+		a.report(fmt.Errorf("Browser is read only"))
+		return
+	}
+	form := tview.NewForm().
+		AddInputField("Attribute", "", 40, nil, nil).
+		AddInputField("Value (JSON)", "", 40, nil, nil)
+	text := func(label string) string {
+		return strings.TrimSpace(form.GetFormItemByLabel(label).(*tview.InputField).GetText())
+	}
+	form.AddButton("Set", func() {
+		name, value := text("Attribute"), text("Value (JSON)")
+		if name == "" || value == "" {
+			a.report(fmt.Errorf("Attribute and value are required"))
+			return
+		}
+		a.closeDialog()
+		a.confirm(fmt.Sprintf("Set %s to %s?", name, value), func() error {
+			return a.pager.setAttribute(a.selected, name, value)
+		}, func() {
+			a.openItem(a.selected)
+		})
+	})
+	form.AddButton("Cancel", a.closeDialog)
+	form.SetCancelFunc(a.closeDialog)
+	form.SetBorder(true).SetTitle(" Edit attribute ")
+	a.showDialog(form, 60, 9)
+}
+
+func (a *browseApp) confirmDelete() {
+	if a.opts.ReadOnly {
+		a.report(fmt.Errorf("Browser is read only"))
+		return
+	}
+	keyJSON, _ := plainJSON(keyOf(a.pager.items[a.selected], a.pager.desc.KeySchema))
+	a.confirm(fmt.Sprintf("Delete the item %s?", keyJSON), func() error {
+		return a.pager.deleteItem(a.selected)
+	}, func() {
+		a.renderItems()
+		a.showPage(itemsPage, itemsHelp)
+		a.app.SetFocus(a.items)
+	})
+}
+
+// confirm runs the action if it is confirmed, and calls done after the action succeeds
+func (a *browseApp) confirm(text string, action func() error, done func()) {
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons([]string{"Cancel", "OK"}).
+		SetDoneFunc(func(index int, label string) {
+			a.closeDialog()
+			if label != "OK" {
+				return
+			}
+			if err := action(); err != nil {
+				a.report(err)
+				return
+			}
+			done()
+		})
+	a.pages.AddPage(dialogPage, modal, true, true)
+	a.app.SetFocus(modal)
+}
+
+// jsonString returns the JSON of the value, or the empty string for nil
+func jsonString(m map[string]*string) (string, error) {
+	if m == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(m)
+	return string(b), err
+}
@@ -0,0 +1,192 @@
+package toolkit
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/mingrammer/cfmt"
+)
+
+const defaultCopyWorkers = 4
+
+// CopyOptions holds the options of the copy
+type CopyOptions struct {
+	Workers     int             // Number of concurrent writers
+	WCU         float64         // Write capacity units per second shared by all writers, 0 means unlimited
+	Transformer ItemTransformer // Transformer applied before the write, optional
+}
+
+// Copier holds the source and target dynamodb clients
+type Copier struct {
+	source dynamodbiface.DynamoDBAPI
+	target dynamodbiface.DynamoDBAPI
+}
+
+// NewCopier creates a copier with the source and target dynamodb clients
+func NewCopier(source, target dynamodbiface.DynamoDBAPI) *Copier {
+	return &Copier{source: source, target: target}
+}
+
+func (c *Copier) write(table string, keySchema []*dynamodb.KeySchemaElement, itemc <-chan map[string]*dynamodb.AttributeValue, opts CopyOptions, limiter *capacityLimiter, copied, filtered *int64) error {
+	batch := []map[string]*dynamodb.AttributeValue{}
+	keys := map[string]bool{}
+	flush := func() error {
+		units := float64(0)
+		for _, it := range batch {
+			units += writeUnits(it)
+		}
+		limiter.Wait(units)
+		if err := putItems(c.target, table, batch); err != nil {
+			return err
+		}
+		atomic.AddInt64(copied, int64(len(batch)))
+		batch = []map[string]*dynamodb.AttributeValue{}
+		keys = map[string]bool{}
+		return nil
+	}
+	var werr error
+	for item := range itemc {
+		// Keep draining the channel so that the scanners are not blocked
+		if werr != nil {
+			continue
+		}
+		items := []map[string]*dynamodb.AttributeValue{item}
+		if opts.Transformer != nil {
+			if items, werr = opts.Transformer.Transform(item); werr != nil {
+				continue
+			}
+			if len(items) == 0 {
+				atomic.AddInt64(filtered, 1)
+				continue
+			}
+		}
+		for _, it := range items {
+			key := keyString(it, keySchema)
+			if keys[key] {
+				if werr = flush(); werr != nil {
+					break
+				}
+			}
+			batch = append(batch, it)
+			keys[key] = true
+			if len(batch) == writeChunk {
+				if werr = flush(); werr != nil {
+					break
+				}
+			}
+		}
+	}
+	if werr == nil && len(batch) > 0 {
+		werr = flush()
+	}
+	return werr
+}
+
+// run scans the source table and writes the items into the target table
+// It returns the number of the written items and the filtered out items
+func (c *Copier) run(source, target string, opts CopyOptions) (int64, int64, error) {
+	meta, err := readMeta(c.source, source)
+	if err != nil {
+		return 0, 0, err
+	}
+	targetMeta, err := readMeta(c.target, target)
+	if err != nil {
+		return 0, 0, err
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultCopyWorkers
+	}
+	limiter := newCapacityLimiter(opts.WCU)
+	totalSegments := totalSegmentsOf(meta.Table)
+
+	itemc := make(chan map[string]*dynamodb.AttributeValue, writeChunk*workers)
+	scanc := make(chan error, 1)
+	go func() {
+		scanc <- scanSegments(c.source, source, totalSegments, 0, itemc)
+		close(itemc)
+	}()
+	copied, filtered := int64(0), int64(0)
+	errc := make(chan error, workers)
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			if err := c.write(target, targetMeta.Table.KeySchema, itemc, opts, limiter, &copied, &filtered); err != nil {
+				errc <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errc)
+	if err := <-scanc; err != nil {
+		return copied, filtered, err
+	}
+	if err := <-errc; err != nil {
+		return copied, filtered, err
+	}
+	return copied, filtered, nil
+}
+
+// Copy copies all items of the source table into the target table
+func (c *Copier) Copy(source, target string, opts CopyOptions) error {
+	cfmt.Successf("Copying the table '%s' into the table '%s'...\n", source, target)
+	copied, filtered, err := c.run(source, target, opts)
+	if err != nil {
+		return err
+	}
+	cfmt.Successf("Table '%s' was copied into the table '%s' with %d items, %d items were filtered out.\n", source, target, copied, filtered)
+	return nil
+}
+
+// Backfiller holds dynamodb client
+type Backfiller struct {
+	copier *Copier
+}
+
+// NewBackfiller creates a backfiller with the dynamodb client
+func NewBackfiller(client dynamodbiface.DynamoDBAPI) *Backfiller {
+	return &Backfiller{copier: NewCopier(client, client)}
+}
+
+// Backfill rewrites all items of the table through the transformer
+// The transformer must keep the keys of the items, otherwise the new items could be scanned and transformed again
+func (b *Backfiller) Backfill(table string, opts CopyOptions) error {
+	if opts.Transformer == nil {
+		return fmt.Errorf("Backfill requires the transformer")
+	}
+	meta, err := readMeta(b.copier.source, table)
+	if err != nil {
+		return err
+	}
+	opts.Transformer = keepKeys(opts.Transformer, meta.Table.KeySchema)
+	cfmt.Successf("Backfilling the table '%s'...\n", table)
+	written, filtered, err := b.copier.run(table, table, opts)
+	if err != nil {
+		return err
+	}
+	cfmt.Successf("Table '%s' was backfilled with %d items, %d items were left untouched.\n", table, written, filtered)
+	return nil
+}
+
+// keepKeys wraps the transformer to reject the transformed items whose keys differ from the original item
+func keepKeys(transformer ItemTransformer, keySchema []*dynamodb.KeySchemaElement) ItemTransformer {
+	return ItemTransformerFunc(func(item map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+		items, err := transformer.Transform(item)
+		if err != nil {
+			return nil, err
+		}
+		key := keyString(item, keySchema)
+		for _, it := range items {
+			if keyString(it, keySchema) != key {
+				keyJSON, _ := plainJSON(keyOf(item, keySchema))
+				return nil, fmt.Errorf("Backfill can not change the key of the item %s", keyJSON)
+			}
+		}
+		return items, nil
+	})
+}
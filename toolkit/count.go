@@ -0,0 +1,165 @@
+package toolkit
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/mingrammer/cfmt"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+	"github.com/mingrammer/dynamodb-toolkit/retryer"
+)
+
+// CountOptions holds the options of the count
+type CountOptions struct {
+	Index    string // Secondary index to count instead of the table
+	Filter   string
+	Names    map[string]*string
+	Values   map[string]*dynamodb.AttributeValue
+	Segments int64 // Number of the parallel scan segments. One segment per megabyte of the table if 0
+}
+
+// Counter holds dynamodb client
+type Counter struct {
+	client dynamodbiface.DynamoDBAPI
+	out    io.Writer
+}
+
+// NewCounter creates a counter of the items with the dynamodb client
+func NewCounter(client dynamodbiface.DynamoDBAPI) *Counter {
+	return &Counter{client: client, out: os.Stdout}
+}
+
+// tableCount is the exact count of a table next to the described one
+type tableCount struct {
+	table       string
+	count       int64
+	scanned     int64
+	approximate int64
+	consumed    float64
+}
+
+// approximateCount returns the item count of the table or the index, which is updated about every 6 hours
+func approximateCount(desc *dynamodb.TableDescription, index string) int64 {
+	if index == "" {
+		return aws.Int64Value(desc.ItemCount)
+	}
+	for _, idx := range desc.GlobalSecondaryIndexes {
+		if aws.StringValue(idx.IndexName) == index {
+			return aws.Int64Value(idx.ItemCount)
+		}
+	}
+	for _, idx := range desc.LocalSecondaryIndexes {
+		if aws.StringValue(idx.IndexName) == index {
+			return aws.Int64Value(idx.ItemCount)
+		}
+	}
+	return 0
+}
+
+// count counts the items of the table with the parallel segmented scans
+func (c *Counter) count(table string, opts CountOptions) (*tableCount, error) {
+	meta, err := readMeta(c.client, table)
+	if err != nil {
+		return nil, err
+	}
+	totalSegments := opts.Segments
+	if totalSegments <= 0 {
+		totalSegments = totalSegmentsOf(meta.Table)
+	}
+	totalSegments = calc.Min(totalSegments, maxTotalSegments)
+	cfmt.Infof("Counting the table '%s' with %d segments...\n", table, totalSegments)
+
+	// Each segment has its own result, so they are summed without the lock
+	results := make([]tableCount, totalSegments)
+	errc := make(chan error, 1)
+	wg := sync.WaitGroup{}
+	wg.Add(int(totalSegments))
+	for i := int64(0); i < totalSegments; i++ {
+		go func(segment int64) {
+			defer wg.Done()
+			result := &results[segment]
+			var startKey map[string]*dynamodb.AttributeValue
+			attempts := 0
+			for {
+				scanned, err := c.client.Scan(&dynamodb.ScanInput{
+					TableName:                 aws.String(table),
+					IndexName:                 optionalString(opts.Index),
+					FilterExpression:          optionalString(opts.Filter),
+					ExpressionAttributeNames:  opts.Names,
+					ExpressionAttributeValues: opts.Values,
+					Select:                    aws.String(dynamodb.SelectCount),
+					ExclusiveStartKey:         startKey,
+					Segment:                   aws.Int64(segment),
+					TotalSegments:             aws.Int64(totalSegments),
+					ReturnConsumedCapacity:    aws.String(dynamodb.ReturnConsumedCapacityTotal),
+				})
+				if err != nil {
+					if attempts++; retryer.IsRetryable(err) && attempts <= maxScanAttempts {
+						time.Sleep(retryer.RetryBackoff(attempts))
+						continue
+					}
+					sendError(errc, err)
+					return
+				}
+				attempts = 0
+				result.count += aws.Int64Value(scanned.Count)
+				result.scanned += aws.Int64Value(scanned.ScannedCount)
+				result.consumed += consumedUnits(scanned.ConsumedCapacity)
+				startKey = scanned.LastEvaluatedKey
+				if len(startKey) == 0 {
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errc)
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+
+	total := &tableCount{table: table, approximate: approximateCount(meta.Table, opts.Index)}
+	for _, r := range results {
+		total.count += r.count
+		total.scanned += r.scanned
+		total.consumed += r.consumed
+	}
+	return total, nil
+}
+
+// Count prints the exact item counts of the tables with the described approximate counts and the consumed read capacity
+// The tables failed to count are reported and skipped
+func (c *Counter) Count(tables []string, opts CountOptions) error {
+	counts := []*tableCount{}
+	failed := 0
+	for _, table := range tables {
+		tc, err := c.count(table, opts)
+		if err != nil {
+			cfmt.Warningf("Table '%s' was not counted, got %s\n", table, err.Error())
+			failed++
+			continue
+		}
+		counts = append(counts, tc)
+	}
+	if len(counts) > 0 {
+		w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "table\tcount\tscanned\tapproximate\tconsumed RCU")
+		for _, tc := range counts {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.1f\n", tc.table, tc.count, tc.scanned, tc.approximate, tc.consumed)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tables were not counted", failed, len(tables))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package toolkit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+func TestCount(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createFindTestTable(t, client)
+	testCases := []struct {
+		opts    CountOptions
+		count   int64
+		scanned int64
+	}{
+		{opts: CountOptions{}, count: 12, scanned: 12},
+		{opts: CountOptions{Segments: 4}, count: 12, scanned: 12},
+		{
+			opts: CountOptions{
+				Filter:   "price > :p",
+				Values:   map[string]*dynamodb.AttributeValue{":p": {N: aws.String("200")}},
+				Segments: 3,
+			},
+			count:   3,
+			scanned: 12,
+		},
+		{opts: CountOptions{Index: "status-index", Segments: 2}, count: 3, scanned: 3},
+	}
+	counter := NewCounter(client)
+	for i, tc := range testCases {
+		got, err := counter.count("order", tc.opts)
+		if err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if got.count != tc.count || got.scanned != tc.scanned || got.approximate != tc.scanned || got.consumed <= 0 {
+			t.Errorf("[%d] Expecting %d of %d scanned, got %+v\n", i+1, tc.count, tc.scanned, got)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	counter.out = out
+	err := counter.Count([]string{"order", "unknown"}, CountOptions{})
+	if err == nil || err.Error() != "1 of 2 tables were not counted" {
+		t.Errorf("Expecting the unknown table error, got %v\n", err)
+	}
+	want := "table  count  scanned  approximate  consumed RCU\norder  12     12       12           0.5\n"
+	if out.String() != want {
+		t.Errorf("Expecting %q, got %q\n", want, out.String())
+	}
+}
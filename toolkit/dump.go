@@ -0,0 +1,243 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/mingrammer/cfmt"
+	"github.com/mingrammer/dynamodb-toolkit/awsjson"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+	"github.com/mingrammer/dynamodb-toolkit/retryer"
+)
+
+const (
+	dumpManifestFile = "manifest.json"
+	maxScanAttempts  = 5
+)
+
+// DumpOptions holds the options of the dump
+type DumpOptions struct {
+	Compression string
+	PartItems   int64 // Rotate to a new part after this many items, 0 means unlimited
+	PartBytes   int64 // Rotate to a new part after this many uncompressed bytes, 0 means unlimited
+}
+
+// DumpPart describes a part file of the dump
+type DumpPart struct {
+	Name      string `json:"name"`
+	ItemCount int64  `json:"itemCount"`
+	RawBytes  int64  `json:"rawBytes"`
+	Bytes     int64  `json:"bytes"`
+	SHA256    string `json:"sha256"`
+}
+
+// DumpManifest describes the dump parts and the source table
+type DumpManifest struct {
+	Table       string          `json:"table"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	Compression string          `json:"compression"`
+	ItemCount   int64           `json:"itemCount"`
+	Parts       []*DumpPart     `json:"parts"`
+	Description json.RawMessage `json:"description"`
+}
+
+// TableDescription returns the description of the source table
+func (m *DumpManifest) TableDescription() (*dynamodb.TableDescription, error) {
+	desc := &dynamodb.TableDescription{}
+	if err := awsjson.Unmarshal(m.Description, desc); err != nil {
+		return nil, err
+	}
+	return desc, nil
+}
+
+// ReadDumpManifest reads the manifest of the dump in the directory
+func ReadDumpManifest(dir string) (*DumpManifest, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, dumpManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("Can not read the dump manifest, got %s", err.Error())
+	}
+	manifest := &DumpManifest{}
+	if err := json.Unmarshal(b, manifest); err != nil {
+		return nil, fmt.Errorf("Invalid dump manifest, got %s", err.Error())
+	}
+	if _, ok := partExtensions[manifest.Compression]; !ok {
+		return nil, fmt.Errorf("Unsupported compression '%s'", manifest.Compression)
+	}
+	return manifest, nil
+}
+
+// Dumper holds dynamodb client
+type Dumper struct {
+	client dynamodbiface.DynamoDBAPI
+}
+
+// NewDumper creates a dumper with the dynamodb client
+func NewDumper(client dynamodbiface.DynamoDBAPI) *Dumper {
+	return &Dumper{client: client}
+}
+
+// totalSegmentsOf returns the number of scan segments, one segment per megabyte of the table
+func totalSegmentsOf(desc *dynamodb.TableDescription) int64 {
+	totalSegments := int64(math.Ceil(float64(*desc.TableSizeBytes) / megabyte))
+	return calc.Max(calc.Min(totalSegments, maxTotalSegments), 1)
+}
+
+// scanSegments reads the items of the table with parallel segmented scans and sends them to the channel
+// Each segment stops after segmentLimit items, and 0 means all the items
+func scanSegments(client dynamodbiface.DynamoDBAPI, table string, totalSegments, segmentLimit int64, itemc chan<- map[string]*dynamodb.AttributeValue) error {
+	errc := make(chan error, totalSegments)
+	wg := sync.WaitGroup{}
+	wg.Add(int(totalSegments))
+	for i := int64(0); i < totalSegments; i++ {
+		go func(segment int64) {
+			defer wg.Done()
+			var startKey map[string]*dynamodb.AttributeValue
+			attempts := 0
+			read := int64(0)
+			for {
+				input := &dynamodb.ScanInput{
+					TableName:         aws.String(table),
+					ExclusiveStartKey: startKey,
+					Segment:           aws.Int64(segment),
+					TotalSegments:     aws.Int64(totalSegments),
+				}
+				if segmentLimit > 0 {
+					input.Limit = aws.Int64(segmentLimit - read)
+				}
+				scanned, err := client.Scan(input)
+				if err != nil {
+					if attempts++; retryer.IsRetryable(err) && attempts <= maxScanAttempts {
+						time.Sleep(retryer.RetryBackoff(attempts))
+						continue
+					}
+					errc <- err
+					return
+				}
+				attempts = 0
+				for _, it := range scanned.Items {
+					itemc <- it
+				}
+				read += int64(len(scanned.Items))
+				startKey = scanned.LastEvaluatedKey
+				if len(startKey) == 0 || (segmentLimit > 0 && read >= segmentLimit) {
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errc)
+	return <-errc
+}
+
+// Dump writes all items of the table into the part files and the manifest in the directory
+func (d *Dumper) Dump(table, dir string, opts DumpOptions) error {
+	if opts.Compression == "" {
+		opts.Compression = CompressionNone
+	}
+	ext, ok := partExtensions[opts.Compression]
+	if !ok {
+		return fmt.Errorf("Unsupported compression '%s'", opts.Compression)
+	}
+	meta, err := readMeta(d.client, table)
+	if err != nil {
+		return err
+	}
+	desc, err := awsjson.Marshal(meta.Table)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	manifest := &DumpManifest{
+		Table:       table,
+		CreatedAt:   time.Now().UTC(),
+		Compression: opts.Compression,
+		Parts:       []*DumpPart{},
+		Description: desc,
+	}
+	totalSegments := totalSegmentsOf(meta.Table)
+
+	cfmt.Successf("Dumping the table '%s' with %d segments...\n", table, totalSegments)
+	itemc := make(chan map[string]*dynamodb.AttributeValue, writeChunk)
+	scanc := make(chan error, 1)
+	go func() {
+		scanc <- scanSegments(d.client, table, totalSegments, 0, itemc)
+		close(itemc)
+	}()
+
+	var part *partWriter
+	// The part being written is incomplete if the dump fails
+	defer func() {
+		if part != nil {
+			part.Abort()
+		}
+	}()
+	closePart := func() error {
+		name := filepath.Base(part.file.Name())
+		sum, err := part.Close()
+		if err != nil {
+			return err
+		}
+		manifest.Parts = append(manifest.Parts, &DumpPart{
+			Name:      name,
+			ItemCount: part.items,
+			RawBytes:  part.RawBytes(),
+			Bytes:     part.compressed.n,
+			SHA256:    sum,
+		})
+		manifest.ItemCount += part.items
+		cfmt.Infof("%s was written with %d items.\n", name, part.items)
+		part = nil
+		return nil
+	}
+	var werr error
+	for it := range itemc {
+		if werr != nil {
+			continue
+		}
+		if part == nil {
+			name := filepath.Join(dir, fmt.Sprintf("%s-%05d%s", table, len(manifest.Parts), ext))
+			if part, werr = createPart(name, opts.Compression); werr != nil {
+				continue
+			}
+		}
+		if werr = part.Write(it); werr != nil {
+			continue
+		}
+		if (opts.PartItems > 0 && part.items >= opts.PartItems) || (opts.PartBytes > 0 && part.RawBytes() >= opts.PartBytes) {
+			werr = closePart()
+		}
+	}
+	if err := <-scanc; err != nil {
+		return err
+	}
+	if werr != nil {
+		return werr
+	}
+	if part != nil {
+		if err := closePart(); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, dumpManifestFile), b, 0644); err != nil {
+		return err
+	}
+	cfmt.Successf("Table '%s' was dumped with %d items in %d parts.\n", table, manifest.ItemCount, len(manifest.Parts))
+	return nil
+}
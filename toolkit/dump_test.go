@@ -0,0 +1,149 @@
+package toolkit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+	"github.com/mingrammer/dynamodb-toolkit/retryer"
+)
+
+func putTestItems(t *testing.T, client *mock.DynamoDBClient, table string, n int) {
+	items := []map[string]*dynamodb.AttributeValue{}
+	for i := 0; i < n; i++ {
+		items = append(items, map[string]*dynamodb.AttributeValue{
+			"id":   {S: aws.String(strconv.Itoa(i + 1))},
+			"name": {S: aws.String("user-" + strconv.Itoa(i+1))},
+			"age":  {N: aws.String(strconv.Itoa(20 + i%50))},
+		})
+	}
+	if err := putItems(client, table, items); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDump(t *testing.T) {
+	testCases := []struct {
+		opts  DumpOptions
+		parts int
+	}{
+		{opts: DumpOptions{Compression: CompressionNone}, parts: 1},
+		{opts: DumpOptions{Compression: CompressionGzip, PartItems: 30}, parts: 4},
+		{opts: DumpOptions{Compression: CompressionZstd, PartBytes: 2048}, parts: 4},
+	}
+	for i, tc := range testCases {
+		client := mock.NewDynamoDBClient()
+		createImportTable(client, "user")
+		putTestItems(t, client, "user", 100)
+
+		dir, err := ioutil.TempDir("", "dynamotk-dump")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		if err := NewDumper(client).Dump("user", dir, tc.opts); err != nil {
+			t.Errorf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+			continue
+		}
+
+		manifest, err := ReadDumpManifest(dir)
+		if err != nil {
+			t.Errorf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+			continue
+		}
+		if manifest.ItemCount != 100 || len(manifest.Parts) != tc.parts {
+			t.Errorf("[%d] Expecting 100 items in %d parts, got %d items in %d parts\n", i+1, tc.parts, manifest.ItemCount, len(manifest.Parts))
+		}
+		desc, err := manifest.TableDescription()
+		if err != nil || *desc.TableName != "user" {
+			t.Errorf("[%d] Expecting the description of the source table, got %v\n", i+1, desc)
+		}
+		total := int64(0)
+		for _, p := range manifest.Parts {
+			b, _ := ioutil.ReadFile(filepath.Join(dir, p.Name))
+			sum := sha256.Sum256(b)
+			if hex.EncodeToString(sum[:]) != p.SHA256 || int64(len(b)) != p.Bytes {
+				t.Errorf("[%d] Checksum or size of the part %s does not match\n", i+1, p.Name)
+			}
+			r, err := openPart(filepath.Join(dir, p.Name), manifest.Compression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := int64(0)
+			s := bufio.NewScanner(r)
+			for s.Scan() {
+				lines++
+			}
+			r.Close()
+			if lines != p.ItemCount {
+				t.Errorf("[%d] Expecting %d lines in the part %s, got %d\n", i+1, p.ItemCount, p.Name, lines)
+			}
+			total += lines
+		}
+		if total != 100 {
+			t.Errorf("[%d] Expecting 100 items in the parts, got %d\n", i+1, total)
+		}
+	}
+}
+
+func TestPartAbort(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynamotk-part")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for i, compression := range []string{CompressionNone, CompressionGzip, CompressionZstd} {
+		name := filepath.Join(dir, "part-"+strconv.Itoa(i)+partExtensions[compression])
+		part, err := createPart(name, compression)
+		if err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if err := part.Write(map[string]*dynamodb.AttributeValue{"id": {S: aws.String("1")}}); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if err := part.Abort(); err != nil {
+			t.Errorf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("[%d] Expecting the incomplete part removed, got %v\n", i+1, err)
+		}
+	}
+}
+
+func TestScanSegmentsRetries(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createImportTable(client, "user")
+	putTestItems(t, client, "user", 100)
+	scan := func(table string) (int, error) {
+		itemc := make(chan map[string]*dynamodb.AttributeValue, 100)
+		err := scanSegments(client, table, 4, 0, itemc)
+		close(itemc)
+		return len(itemc), err
+	}
+
+	// The throttled scans are retried
+	client.SetFaults(&mock.Faults{Seed: 1, ThrottleRates: map[string]float64{"Scan": 0.3}})
+	if n, err := scan("user"); err != nil || n != 100 {
+		t.Errorf("Expecting 100 items, got %d items, %v\n", n, err)
+	}
+	client.SetFaults(nil)
+
+	// The errors which are not retryable are returned without the backoff
+	start := time.Now()
+	if _, err := scan("unknown"); err == nil || !strings.Contains(err.Error(), dynamodb.ErrCodeResourceNotFoundException) {
+		t.Errorf("Expecting the resource not found error, got %v\n", err)
+	}
+	if elapsed := time.Since(start); elapsed >= retryer.RetryBackoff(1) {
+		t.Errorf("Expecting no retries, took %s\n", elapsed)
+	}
+}
@@ -0,0 +1,126 @@
+package toolkit_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+	"github.com/mingrammer/dynamodb-toolkit/toolkit"
+)
+
+type user struct {
+	ID    string `dynamodbav:"id"`
+	Name  string `dynamodbav:"name"`
+	Email string `dynamodbav:"email,omitempty"`
+}
+
+func newExampleClient(tables ...string) *mock.DynamoDBClient {
+	client := mock.NewDynamoDBClient()
+	for _, table := range tables {
+		client.CreateTable(&dynamodb.CreateTableInput{
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{
+				{AttributeName: aws.String("id"), AttributeType: aws.String("S")},
+			},
+			BillingMode: aws.String("PAY_PER_REQUEST"),
+			KeySchema: []*dynamodb.KeySchemaElement{
+				{AttributeName: aws.String("id"), KeyType: aws.String("HASH")},
+			},
+			TableName: aws.String(table),
+		})
+	}
+	return client
+}
+
+func printUsers(client *mock.DynamoDBClient, table string) {
+	output, _ := client.Scan(&dynamodb.ScanInput{
+		TableName:     aws.String(table),
+		Segment:       aws.Int64(0),
+		TotalSegments: aws.Int64(1),
+	})
+	users := []user{}
+	for _, it := range output.Items {
+		u := user{}
+		toolkit.UnmarshalItem(it, &u)
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	for _, u := range users {
+		fmt.Println(strings.TrimSpace(u.ID + " " + u.Name + " " + u.Email))
+	}
+}
+
+func ExampleNewStructTransformer() {
+	client := newExampleClient("user")
+	for _, u := range []user{{ID: "1", Name: "foo"}, {ID: "2", Name: "bar"}} {
+		item, _ := toolkit.MarshalItem(u)
+		client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]*dynamodb.WriteRequest{
+				"user": {{PutRequest: &dynamodb.PutRequest{Item: item}}},
+			},
+		})
+	}
+
+	// Copy the users while deriving the email from the name
+	target := newExampleClient("user")
+	transformer := toolkit.NewStructTransformer(user{}, func(v interface{}) ([]interface{}, error) {
+		u := v.(*user)
+		u.Email = u.Name + "@example.com"
+		return []interface{}{u}, nil
+	})
+	copier := toolkit.NewCopier(client, target)
+	if err := copier.Copy("user", "user", toolkit.CopyOptions{Transformer: transformer}); err != nil {
+		fmt.Println(err)
+	}
+	printUsers(target, "user")
+	// Output:
+	// 1 foo foo@example.com
+	// 2 bar bar@example.com
+}
+
+func ExampleItemTransformerFunc() {
+	client := newExampleClient("user", "archive")
+	for _, id := range []string{"1", "2", "3"} {
+		client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]*dynamodb.WriteRequest{
+				"user": {{PutRequest: &dynamodb.PutRequest{Item: map[string]*dynamodb.AttributeValue{
+					"id":   {S: aws.String(id)},
+					"name": {S: aws.String("user" + id)},
+				}}}},
+			},
+		})
+	}
+
+	// Drop the first user and fan out the others into the current and the archived items
+	split := toolkit.ItemTransformerFunc(func(item map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+		if *item["id"].S == "1" {
+			return nil, nil
+		}
+		archived := map[string]*dynamodb.AttributeValue{
+			"id":   {S: aws.String("archived#" + *item["id"].S)},
+			"name": item["name"],
+		}
+		return []map[string]*dynamodb.AttributeValue{item, archived}, nil
+	})
+	upper := toolkit.ItemTransformerFunc(func(item map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+		// Do not modify the given item in place, it may be shared with the source
+		out := map[string]*dynamodb.AttributeValue{
+			"id":   item["id"],
+			"name": {S: aws.String(strings.ToUpper(*item["name"].S))},
+		}
+		return []map[string]*dynamodb.AttributeValue{out}, nil
+	})
+	copier := toolkit.NewCopier(client, client)
+	opts := toolkit.CopyOptions{Transformer: toolkit.ChainTransformers(split, upper)}
+	if err := copier.Copy("user", "archive", opts); err != nil {
+		fmt.Println(err)
+	}
+	printUsers(client, "archive")
+	// Output:
+	// 2 USER2
+	// 3 USER3
+	// archived#2 USER2
+	// archived#3 USER3
+}
@@ -0,0 +1,337 @@
+package toolkit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/mingrammer/cfmt"
+	"github.com/mingrammer/dynamodb-toolkit/awsjson"
+)
+
+// Output formats of the found items
+const (
+	FormatTable    = "table"
+	FormatDynamoDB = "dynamodb"
+	FormatJSON     = "json"
+)
+
+const maxCellWidth = 40
+
+// FindOptions holds the options of the query and scan
+type FindOptions struct {
+	Index        string                              // Secondary index to read instead of the table
+	KeyCondition string                              // Key condition expression, only for the query
+	Filter       string                              // Filter expression applied to the read items
+	Projection   string                              // Projection expression of the attributes to read, all if empty
+	Names        map[string]*string                  // Expression attribute names
+	Values       map[string]*dynamodb.AttributeValue // Expression attribute values
+	Descending   bool                                // Read in the descending sort key order, only for the query
+	Limit        int64                               // Maximum number of the items to print, all if zero
+	Format       string                              // table, dynamodb or json. Defaults to table
+}
+
+// findPage is a page of the query or scan
+type findPage struct {
+	items    []map[string]*dynamodb.AttributeValue
+	scanned  int64
+	consumed float64
+	lastKey  map[string]*dynamodb.AttributeValue
+}
+
+// Finder holds dynamodb client
+type Finder struct {
+	client dynamodbiface.DynamoDBAPI
+	out    io.Writer
+}
+
+// NewFinder creates a finder with the dynamodb client
+func NewFinder(client dynamodbiface.DynamoDBAPI) *Finder {
+	return &Finder{client: client, out: os.Stdout}
+}
+
+// optionalString returns nil for the empty string not to send the empty expressions
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
+// consumedUnits returns the capacity units of the consumed capacity, or 0 if it is not returned
+func consumedUnits(c *dynamodb.ConsumedCapacity) float64 {
+	if c == nil {
+		return 0
+	}
+	return aws.Float64Value(c.CapacityUnits)
+}
+
+// pageReader reads a page from the start key with the limit of the evaluated items
+type pageReader func(startKey map[string]*dynamodb.AttributeValue, limit *int64) (*findPage, error)
+
+// scanReader returns the reader of the scan pages
+func (f *Finder) scanReader(table string, opts FindOptions) pageReader {
+	return func(startKey map[string]*dynamodb.AttributeValue, limit *int64) (*findPage, error) {
+		output, err := f.client.Scan(&dynamodb.ScanInput{
+			TableName:                 aws.String(table),
+			IndexName:                 optionalString(opts.Index),
+			FilterExpression:          optionalString(opts.Filter),
+			ProjectionExpression:      optionalString(opts.Projection),
+			ExpressionAttributeNames:  opts.Names,
+			ExpressionAttributeValues: opts.Values,
+			ExclusiveStartKey:         startKey,
+			Limit:                     limit,
+			ReturnConsumedCapacity:    aws.String(dynamodb.ReturnConsumedCapacityTotal),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &findPage{
+			items:    output.Items,
+			scanned:  aws.Int64Value(output.ScannedCount),
+			consumed: consumedUnits(output.ConsumedCapacity),
+			lastKey:  output.LastEvaluatedKey,
+		}, nil
+	}
+}
+
+// queryReader returns the reader of the query pages
+func (f *Finder) queryReader(table string, opts FindOptions) pageReader {
+	return func(startKey map[string]*dynamodb.AttributeValue, limit *int64) (*findPage, error) {
+		output, err := f.client.Query(&dynamodb.QueryInput{
+			TableName:                 aws.String(table),
+			IndexName:                 optionalString(opts.Index),
+			KeyConditionExpression:    aws.String(opts.KeyCondition),
+			FilterExpression:          optionalString(opts.Filter),
+			ProjectionExpression:      optionalString(opts.Projection),
+			ExpressionAttributeNames:  opts.Names,
+			ExpressionAttributeValues: opts.Values,
+			ScanIndexForward:          aws.Bool(!opts.Descending),
+			ExclusiveStartKey:         startKey,
+			Limit:                     limit,
+			ReturnConsumedCapacity:    aws.String(dynamodb.ReturnConsumedCapacityTotal),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &findPage{
+			items:    output.Items,
+			scanned:  aws.Int64Value(output.ScannedCount),
+			consumed: consumedUnits(output.ConsumedCapacity),
+			lastKey:  output.LastEvaluatedKey,
+		}, nil
+	}
+}
+
+// readItems reads the pages from the start key until the limit or the last page
+// The returned page has all the items read, and the key to continue from
+func readItems(read pageReader, startKey map[string]*dynamodb.AttributeValue, limit int64) (*findPage, error) {
+	all := &findPage{items: []map[string]*dynamodb.AttributeValue{}, lastKey: startKey}
+	for {
+		var pageLimit *int64
+		if limit > 0 {
+			pageLimit = aws.Int64(limit - int64(len(all.items)))
+		}
+		page, err := read(all.lastKey, pageLimit)
+		if err != nil {
+			return nil, err
+		}
+		all.items = append(all.items, page.items...)
+		all.scanned += page.scanned
+		all.consumed += page.consumed
+		all.lastKey = page.lastKey
+		if len(all.lastKey) == 0 || (limit > 0 && int64(len(all.items)) >= limit) {
+			return all, nil
+		}
+	}
+}
+
+// Scan prints the items of the table or index which match the filter
+func (f *Finder) Scan(table string, opts FindOptions) error {
+	return f.find(table, opts, f.scanReader(table, opts))
+}
+
+// Query prints the items of the table or index which match the key condition and filter
+func (f *Finder) Query(table string, opts FindOptions) error {
+	if opts.KeyCondition == "" {
+		return fmt.Errorf("Query requires the key condition expression")
+	}
+	return f.find(table, opts, f.queryReader(table, opts))
+}
+
+// validateFormat returns the output format, or table if it is empty
+func validateFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return FormatTable, nil
+	case FormatTable, FormatDynamoDB, FormatJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("Invalid output format '%s', it must be table, dynamodb or json", format)
+}
+
+// find reads the pages until the limit or the last page, and prints the items in the format
+func (f *Finder) find(table string, opts FindOptions, read pageReader) error {
+	format, err := validateFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	meta, err := readMeta(f.client, table)
+	if err != nil {
+		return err
+	}
+
+	page, err := readItems(read, nil, opts.Limit)
+	if err != nil {
+		return err
+	}
+	if err := printItems(f.out, page.items, keyNamesOf(meta.Table, opts.Index), format); err != nil {
+		return err
+	}
+	if format == FormatTable {
+		cfmt.Infof("%d items, %d scanned, %.1f capacity units consumed.\n", len(page.items), page.scanned, page.consumed)
+		if len(page.lastKey) > 0 {
+			cfmt.Warningf("There are more items beyond the limit of %d items.\n", opts.Limit)
+		}
+	}
+	return nil
+}
+
+// printItems prints the items in the format
+// The DynamoDB JSON lines have the same form as the dump parts
+func printItems(out io.Writer, items []map[string]*dynamodb.AttributeValue, keyNames []string, format string) error {
+	switch format {
+	case FormatDynamoDB:
+		for _, item := range items {
+			line, err := awsjson.Marshal(&dynamodb.PutRequest{Item: item})
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(out, "%s\n", line); err != nil {
+				return err
+			}
+		}
+	case FormatJSON:
+		for _, item := range items {
+			line, err := plainJSON(item)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintln(out, line); err != nil {
+				return err
+			}
+		}
+	default:
+		return printTable(out, items, keyNames)
+	}
+	return nil
+}
+
+// keyNamesOf returns the key attribute names of the index followed by the ones of the table
+func keyNamesOf(desc *dynamodb.TableDescription, index string) []string {
+	keySchema := []*dynamodb.KeySchemaElement{}
+	for _, gsi := range desc.GlobalSecondaryIndexes {
+		if *gsi.IndexName == index {
+			keySchema = append(keySchema, gsi.KeySchema...)
+		}
+	}
+	for _, lsi := range desc.LocalSecondaryIndexes {
+		if *lsi.IndexName == index {
+			keySchema = append(keySchema, lsi.KeySchema...)
+		}
+	}
+	keySchema = append(keySchema, desc.KeySchema...)
+	names := []string{}
+	seen := map[string]bool{}
+	for _, k := range keySchema {
+		if !seen[*k.AttributeName] {
+			seen[*k.AttributeName] = true
+			names = append(names, *k.AttributeName)
+		}
+	}
+	return names
+}
+
+// tableColumns returns the key attributes which the items have, followed by the other attributes by name
+func tableColumns(items []map[string]*dynamodb.AttributeValue, keyNames []string) []string {
+	columns := []string{}
+	seen := map[string]bool{}
+	for _, name := range keyNames {
+		seen[name] = true
+		for _, item := range items {
+			if item[name] != nil {
+				columns = append(columns, name)
+				break
+			}
+		}
+	}
+	others := []string{}
+	for _, item := range items {
+		for name := range item {
+			if !seen[name] {
+				seen[name] = true
+				others = append(others, name)
+			}
+		}
+	}
+	sort.Strings(others)
+	return append(columns, others...)
+}
+
+// printTable prints the items as a table of the columns
+func printTable(out io.Writer, items []map[string]*dynamodb.AttributeValue, keyNames []string) error {
+	columns := tableColumns(items, keyNames)
+	if len(columns) == 0 {
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(columns, "\t"))
+	for _, item := range items {
+		cells := make([]string, len(columns))
+		for i, name := range columns {
+			cells[i] = cellString(item[name])
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	// The missing attributes of the last columns leave the trailing paddings
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		if _, err := fmt.Fprintln(out, strings.TrimRight(line, " \n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cellString returns the attribute value as a single line cell
+// The strings are printed without the quotes and the others as the plain JSON
+func cellString(v *dynamodb.AttributeValue) string {
+	s := ""
+	switch {
+	case v == nil:
+		return ""
+	case v.S != nil:
+		s = *v.S
+	default:
+		s = plainValueJSON(v)
+	}
+	s = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+	if runes := []rune(s); len(runes) > maxCellWidth {
+		s = string(runes[:maxCellWidth-3]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,221 @@
+package toolkit
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+// createFindTestTable creates the order table of 3 users with 4 orders each, indexed by the status
+func createFindTestTable(t *testing.T, client *mock.DynamoDBClient) {
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("user"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("id"), AttributeType: aws.String("N")},
+			{AttributeName: aws.String("status"), AttributeType: aws.String("S")},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("user"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+			{AttributeName: aws.String("id"), KeyType: aws.String(dynamodb.KeyTypeRange)},
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String("status-index"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("status"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)},
+			},
+		},
+		TableName: aws.String("order"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for u := 0; u < 3; u++ {
+		for i := 0; i < 4; i++ {
+			item := map[string]*dynamodb.AttributeValue{
+				"user":  {S: aws.String("user" + strconv.Itoa(u))},
+				"id":    {N: aws.String(strconv.Itoa(i))},
+				"price": {N: aws.String(strconv.Itoa(u*100 + i))},
+			}
+			if i == 3 {
+				item["status"] = &dynamodb.AttributeValue{S: aws.String("canceled")}
+			}
+			if _, err := client.PutItem(&dynamodb.PutItemInput{TableName: aws.String("order"), Item: item}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createFindTestTable(t, client)
+	values := func(s string) map[string]*dynamodb.AttributeValue {
+		v, err := ParsePlainJSON(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	testCases := []struct {
+		query bool
+		opts  FindOptions
+		want  []string
+	}{
+		{
+			query: true,
+			opts: FindOptions{
+				KeyCondition: "#u = :u AND id >= :id",
+				Names:        map[string]*string{"#u": aws.String("user")},
+				Values:       values(`{":u": "user1", ":id": 2}`),
+				Descending:   true,
+			},
+			want: []string{`{"id":3,"price":103,"status":"canceled","user":"user1"}`, `{"id":2,"price":102,"user":"user1"}`},
+		},
+		{
+			query: true,
+			opts: FindOptions{
+				Index:        "status-index",
+				KeyCondition: "#s = :s",
+				Filter:       "price > :t",
+				Projection:   "#u",
+				Names:        map[string]*string{"#s": aws.String("status"), "#u": aws.String("user")},
+				Values:       values(`{":s": "canceled", ":t": 100}`),
+			},
+			want: []string{`{"user":"user1"}`, `{"user":"user2"}`},
+		},
+		{
+			opts: FindOptions{Filter: "attribute_exists(#s)", Names: map[string]*string{"#s": aws.String("status")}, Projection: "price"},
+			want: []string{`{"price":3}`, `{"price":103}`, `{"price":203}`},
+		},
+		{
+			// The pages are read until the limit
+			opts: FindOptions{Projection: "id", Limit: 5},
+			want: []string{`{"id":0}`, `{"id":1}`, `{"id":2}`, `{"id":3}`, `{"id":0}`},
+		},
+	}
+	for i, tc := range testCases {
+		out := &bytes.Buffer{}
+		finder := NewFinder(client)
+		finder.out = out
+		tc.opts.Format = FormatJSON
+		var err error
+		if tc.query {
+			err = finder.Query("order", tc.opts)
+		} else {
+			err = finder.Scan("order", tc.opts)
+		}
+		if err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		got := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if tc.query {
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("[%d] Expecting %v, got %v\n", i+1, tc.want, got)
+			}
+			continue
+		}
+		// The scan order depends on the hash of the keys
+		if len(got) != len(tc.want) {
+			t.Errorf("[%d] Expecting %d items, got %v\n", i+1, len(tc.want), got)
+		}
+		for _, w := range tc.want {
+			if !strings.Contains(out.String(), w) {
+				t.Errorf("[%d] Expecting %s in %v\n", i+1, w, got)
+			}
+		}
+	}
+}
+
+func TestFindFormats(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createFindTestTable(t, client)
+	opts := FindOptions{
+		KeyCondition: "#u = :u AND id > :id",
+		Names:        map[string]*string{"#u": aws.String("user")},
+		Values:       map[string]*dynamodb.AttributeValue{":u": {S: aws.String("user0")}, ":id": {N: aws.String("1")}},
+	}
+	testCases := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: FormatTable,
+			want:   "user   id  price  status\nuser0  2   2\nuser0  3   3      canceled\n",
+		},
+		{
+			format: FormatDynamoDB,
+			want:   `{"Item":{"id":{"N":"2"},"price":{"N":"2"},"user":{"S":"user0"}}}` + "\n" + `{"Item":{"id":{"N":"3"},"price":{"N":"3"},"status":{"S":"canceled"},"user":{"S":"user0"}}}` + "\n",
+		},
+	}
+	for i, tc := range testCases {
+		out := &bytes.Buffer{}
+		finder := NewFinder(client)
+		finder.out = out
+		opts.Format = tc.format
+		if err := finder.Query("order", opts); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if out.String() != tc.want {
+			t.Errorf("[%d] Expecting\n%s\ngot\n%s\n", i+1, tc.want, out.String())
+		}
+	}
+
+	finder := NewFinder(client)
+	errCases := []struct {
+		err error
+		msg string
+	}{
+		{err: finder.Scan("order", FindOptions{Format: "yaml"}), msg: "Invalid output format 'yaml'"},
+		{err: finder.Query("order", FindOptions{}), msg: "requires the key condition"},
+		{err: finder.Scan("nope", FindOptions{}), msg: "Table 'nope' is not found"},
+	}
+	for i, tc := range errCases {
+		if tc.err == nil || !strings.Contains(tc.err.Error(), tc.msg) {
+			t.Errorf("[%d] Expecting the error %q, got %v\n", i+1, tc.msg, tc.err)
+		}
+	}
+	if _, err := ParsePlainJSON(`[1]`); err == nil {
+		t.Errorf("Expecting the error for the non object JSON\n")
+	}
+}
+
+func TestFindLargeNumbers(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createStreamTestTable(t, client, "user")
+	// The numbers over 2^53 are not exact as float64
+	ids := []string{"12345678901234567891", "12345678901234567892"}
+	for _, id := range ids {
+		_, err := client.PutItem(&dynamodb.PutItemInput{
+			TableName: aws.String("user"),
+			Item: map[string]*dynamodb.AttributeValue{
+				"id":    {N: aws.String(id)},
+				"sizes": {NS: aws.StringSlice([]string{id})},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := &bytes.Buffer{}
+	finder := NewFinder(client)
+	finder.out = out
+	if err := finder.Scan("user", FindOptions{Format: FormatJSON}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	for _, id := range ids {
+		want := `{"id":` + id + `,"sizes":[` + id + `]}`
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expecting %s in %s\n", want, out.String())
+		}
+	}
+}
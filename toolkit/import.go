@@ -0,0 +1,249 @@
+package toolkit
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/mingrammer/cfmt"
+)
+
+// Output formats of the dynamodb export to s3
+const (
+	ExportFormatDynamoDBJSON = "DYNAMODB_JSON"
+	ExportFormatIon          = "ION"
+)
+
+const (
+	manifestSummaryFile = "manifest-summary.json"
+	manifestFilesFile   = "manifest-files.json"
+	exportDataDir       = "data"
+
+	importWorkers = 8
+)
+
+// ExportSummary is the manifest summary of the dynamodb export to s3
+type ExportSummary struct {
+	Version            string `json:"version"`
+	ExportArn          string `json:"exportArn"`
+	TableArn           string `json:"tableArn"`
+	ItemCount          int64  `json:"itemCount"`
+	OutputFormat       string `json:"outputFormat"`
+	ManifestFilesS3Key string `json:"manifestFilesS3Key"`
+}
+
+// ExportFile is an entry of the manifest files of the dynamodb export to s3
+type ExportFile struct {
+	ItemCount     int64  `json:"itemCount"`
+	MD5Checksum   string `json:"md5Checksum"`
+	ETag          string `json:"etag"`
+	DataFileS3Key string `json:"dataFileS3Key"`
+}
+
+// Importer holds dynamodb client
+type Importer struct {
+	client dynamodbiface.DynamoDBAPI
+}
+
+// NewImporter creates an importer with the dynamodb client
+func NewImporter(client dynamodbiface.DynamoDBAPI) *Importer {
+	return &Importer{client: client}
+}
+
+func readExportSummary(dir string) (*ExportSummary, error) {
+	f, err := os.Open(filepath.Join(dir, manifestSummaryFile))
+	if err != nil {
+		return nil, fmt.Errorf("Can not open the export manifest summary, got %s", err.Error())
+	}
+	defer f.Close()
+	summary := &ExportSummary{}
+	if err := json.NewDecoder(f).Decode(summary); err != nil {
+		return nil, fmt.Errorf("Invalid export manifest summary, got %s", err.Error())
+	}
+	switch summary.OutputFormat {
+	case ExportFormatDynamoDBJSON, ExportFormatIon:
+	default:
+		return nil, fmt.Errorf("Unsupported export format '%s'", summary.OutputFormat)
+	}
+	return summary, nil
+}
+
+func readExportFiles(dir string) ([]*ExportFile, error) {
+	f, err := os.Open(filepath.Join(dir, manifestFilesFile))
+	if err != nil {
+		return nil, fmt.Errorf("Can not open the export manifest files, got %s", err.Error())
+	}
+	defer f.Close()
+	files := []*ExportFile{}
+	dec := json.NewDecoder(f)
+	for {
+		file := &ExportFile{}
+		if err := dec.Decode(file); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("Invalid export manifest files, got %s", err.Error())
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// verifyChecksum compares the md5 checksum of the data file with the manifest
+func verifyChecksum(name string, file *ExportFile) error {
+	if file.MD5Checksum == "" {
+		return nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if sum := base64.StdEncoding.EncodeToString(h.Sum(nil)); sum != file.MD5Checksum {
+		return fmt.Errorf("Checksum mismatch for '%s', expected %s but got %s", name, file.MD5Checksum, sum)
+	}
+	return nil
+}
+
+// itemDecoder reads the items of the export data file one by one
+type itemDecoder func() (map[string]*dynamodb.AttributeValue, error)
+
+func newItemDecoder(r io.Reader, format string) itemDecoder {
+	if format == ExportFormatIon {
+		dec := newIonDecoder(r)
+		return func() (map[string]*dynamodb.AttributeValue, error) {
+			v, err := dec.Decode()
+			if err != nil {
+				return nil, err
+			}
+			if v.M == nil || v.M["Item"] == nil || v.M["Item"].M == nil {
+				return nil, fmt.Errorf("Ion record does not have an item")
+			}
+			return v.M["Item"].M, nil
+		}
+	}
+	dec := json.NewDecoder(bufio.NewReader(r))
+	return func() (map[string]*dynamodb.AttributeValue, error) {
+		record := struct {
+			Item map[string]*dynamodb.AttributeValue
+		}{}
+		if err := dec.Decode(&record); err != nil {
+			return nil, err
+		}
+		if record.Item == nil {
+			return nil, fmt.Errorf("Json record does not have an item")
+		}
+		return record.Item, nil
+	}
+}
+
+func (im *Importer) importFile(dir, table, format string, file *ExportFile) error {
+	name := filepath.Join(dir, exportDataDir, path.Base(file.DataFileS3Key))
+	if err := verifyChecksum(name, file); err != nil {
+		return err
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("Can not decompress '%s', got %s", name, err.Error())
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	decode := newItemDecoder(r, format)
+	count := int64(0)
+	items := []map[string]*dynamodb.AttributeValue{}
+	for {
+		item, err := decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Can not decode '%s', got %s", name, err.Error())
+		}
+		items = append(items, item)
+		count++
+		if len(items) == writeChunk {
+			if err := putItems(im.client, table, items); err != nil {
+				return err
+			}
+			items = []map[string]*dynamodb.AttributeValue{}
+		}
+	}
+	if err := putItems(im.client, table, items); err != nil {
+		return err
+	}
+	if count != file.ItemCount {
+		return fmt.Errorf("Item count mismatch for '%s', expected %d but got %d", name, file.ItemCount, count)
+	}
+	return nil
+}
+
+// Import writes the items of the dynamodb s3 export in the directory into the table
+func (im *Importer) Import(dir, table string) error {
+	summary, err := readExportSummary(dir)
+	if err != nil {
+		return err
+	}
+	files, err := readExportFiles(dir)
+	if err != nil {
+		return err
+	}
+	total := int64(0)
+	for _, file := range files {
+		total += file.ItemCount
+	}
+	if total != summary.ItemCount {
+		return fmt.Errorf("Item count mismatch between the manifests, expected %d but got %d", summary.ItemCount, total)
+	}
+
+	cfmt.Successf("[%d/%d] Importing %d items into the table '%s'...\n", 0, len(files), summary.ItemCount, table)
+	errc := make(chan error, len(files))
+	filec := make(chan *ExportFile)
+	wg := sync.WaitGroup{}
+	wg.Add(importWorkers)
+	for i := 0; i < importWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for file := range filec {
+				if err := im.importFile(dir, table, summary.OutputFormat, file); err != nil {
+					errc <- err
+					continue
+				}
+				cfmt.Infof("%s was imported into the table '%s'.\n", path.Base(file.DataFileS3Key), table)
+			}
+		}()
+	}
+	for _, file := range files {
+		filec <- file
+	}
+	close(filec)
+	wg.Wait()
+	close(errc)
+	if err := <-errc; err != nil {
+		return err
+	}
+	cfmt.Successf("[%d/%d] Table '%s' was imported successfully.\n", len(files), len(files), table)
+	return nil
+}
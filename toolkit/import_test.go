@@ -0,0 +1,159 @@
+package toolkit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+func writeTestExport(t *testing.T, format string, records [][]string) string {
+	dir, err := ioutil.TempDir("", "dynamotk-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, exportDataDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := bytes.Buffer{}
+	total := 0
+	for i, lines := range records {
+		buf := bytes.Buffer{}
+		gz := gzip.NewWriter(&buf)
+		for _, line := range lines {
+			fmt.Fprintln(gz, line)
+		}
+		gz.Close()
+		name := fmt.Sprintf("part-%d.gz", i)
+		if err := ioutil.WriteFile(filepath.Join(dir, exportDataDir, name), buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sum := md5.Sum(buf.Bytes())
+		entry, _ := json.Marshal(&ExportFile{
+			ItemCount:     int64(len(lines)),
+			MD5Checksum:   base64.StdEncoding.EncodeToString(sum[:]),
+			DataFileS3Key: "prefix/AWSDynamoDB/01234-abcd/data/" + name,
+		})
+		files.Write(append(entry, '\n'))
+		total += len(lines)
+	}
+	summary, _ := json.Marshal(&ExportSummary{
+		Version:      "2020-06-30",
+		ItemCount:    int64(total),
+		OutputFormat: format,
+	})
+	ioutil.WriteFile(filepath.Join(dir, manifestSummaryFile), summary, 0644)
+	ioutil.WriteFile(filepath.Join(dir, manifestFilesFile), files.Bytes(), 0644)
+	return dir
+}
+
+func createImportTable(client *mock.DynamoDBClient, name string) {
+	client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("id"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("id"),
+				KeyType:       aws.String("HASH"),
+			},
+		},
+		TableName: aws.String(name),
+	})
+}
+
+func TestImport(t *testing.T) {
+	testCases := []struct {
+		format  string
+		records [][]string
+	}{
+		{
+			format: ExportFormatDynamoDBJSON,
+			records: [][]string{
+				{
+					`{"Item":{"id":{"S":"1"},"age":{"N":"20"},"tags":{"SS":["a","b"]}}}`,
+					`{"Item":{"id":{"S":"2"},"data":{"B":"aGVsbG8="},"active":{"BOOL":true}}}`,
+				},
+				{
+					`{"Item":{"id":{"S":"3"},"profile":{"M":{"name":{"S":"foo"},"scores":{"L":[{"N":"1"},{"NULL":true}]}}}}}`,
+				},
+			},
+		},
+		{
+			format: ExportFormatIon,
+			records: [][]string{
+				{
+					`$ion_1_0 {Item:{id:"1",age:20.,tags:$dynamodb_SS::["a","b"]}}`,
+					`$ion_1_0 {Item:{id:"2",data:{{aGVsbG8=}},active:true,nums:$dynamodb_NS::[1.5d0,2]}}`,
+				},
+				{
+					`$ion_1_0 {Item:{'id':"3",profile:{name:'''fo''' '''o''',scores:[1,null]}}} // comment`,
+				},
+			},
+		},
+	}
+	for i, tc := range testCases {
+		dir := writeTestExport(t, tc.format, tc.records)
+		defer os.RemoveAll(dir)
+
+		client := mock.NewDynamoDBClient()
+		createImportTable(client, "user")
+		if err := NewImporter(client).Import(dir, "user"); err != nil {
+			t.Errorf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+			continue
+		}
+		output, _ := client.Scan(&dynamodb.ScanInput{
+			TableName:       aws.String("user"),
+			AttributesToGet: aws.StringSlice([]string{"id", "profile", "tags"}),
+			Segment:         aws.Int64(0),
+			TotalSegments:   aws.Int64(1),
+		})
+		if *output.Count != 3 {
+			t.Errorf("[%d] Expecting 3 items, got %d\n", i+1, *output.Count)
+		}
+		for _, it := range output.Items {
+			switch *it["id"].S {
+			case "1":
+				if len(it["tags"].SS) != 2 {
+					t.Errorf("[%d] Expecting the string set of 2 elements, got %v\n", i+1, it["tags"])
+				}
+			case "3":
+				profile := it["profile"].M
+				if *profile["name"].S != "foo" || len(profile["scores"].L) != 2 || !*profile["scores"].L[1].NULL {
+					t.Errorf("[%d] Unexpected nested attributes, got %v\n", i+1, profile)
+				}
+			}
+		}
+	}
+}
+
+func TestImportCountMismatch(t *testing.T) {
+	dir := writeTestExport(t, ExportFormatDynamoDBJSON, [][]string{
+		{`{"Item":{"id":{"S":"1"}}}`},
+	})
+	defer os.RemoveAll(dir)
+	entry, _ := json.Marshal(&ExportFile{ItemCount: 2, DataFileS3Key: "data/part-0.gz"})
+	summary, _ := json.Marshal(&ExportSummary{ItemCount: 2, OutputFormat: ExportFormatDynamoDBJSON})
+	ioutil.WriteFile(filepath.Join(dir, manifestFilesFile), entry, 0644)
+	ioutil.WriteFile(filepath.Join(dir, manifestSummaryFile), summary, 0644)
+
+	client := mock.NewDynamoDBClient()
+	createImportTable(client, "user")
+	if err := NewImporter(client).Import(dir, "user"); err == nil {
+		t.Errorf("There should be an item count mismatch error\n")
+	}
+}
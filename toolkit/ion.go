@@ -0,0 +1,501 @@
+package toolkit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Annotations used by the dynamodb ion export for the set types
+const (
+	ionStringSet = "$dynamodb_SS"
+	ionNumberSet = "$dynamodb_NS"
+	ionBinarySet = "$dynamodb_BS"
+
+	ionVersionMarker = "$ion_1_0"
+)
+
+// ionDecoder decodes the subset of the ion text format that is used by the dynamodb export
+type ionDecoder struct {
+	r *bufio.Reader
+}
+
+func newIonDecoder(r io.Reader) *ionDecoder {
+	return &ionDecoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next top level value and converts it to the attribute value
+// It returns io.EOF if there are no more values
+func (d *ionDecoder) Decode() (*dynamodb.AttributeValue, error) {
+	for {
+		c, err := d.skipSpace()
+		if err != nil {
+			return nil, err
+		}
+		if c == '$' {
+			d.r.UnreadByte()
+			sym, err := d.readSymbol()
+			if err != nil {
+				return nil, err
+			}
+			if sym == ionVersionMarker {
+				continue
+			}
+			return d.decodeAnnotated(sym)
+		}
+		d.r.UnreadByte()
+		return d.decodeValue()
+	}
+}
+
+func (d *ionDecoder) decodeValue() (*dynamodb.AttributeValue, error) {
+	c, err := d.skipSpace()
+	if err != nil {
+		return nil, d.unexpected(err)
+	}
+	switch {
+	case c == '{':
+		next, err := d.r.Peek(1)
+		if err == nil && next[0] == '{' {
+			d.r.ReadByte()
+			return d.decodeBlob()
+		}
+		return d.decodeStruct()
+	case c == '[':
+		l, err := d.decodeList()
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.AttributeValue{L: l}, nil
+	case c == '"':
+		s, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.AttributeValue{S: aws.String(s)}, nil
+	case c == '\'':
+		d.r.UnreadByte()
+		s, quoted, err := d.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		if !quoted {
+			return &dynamodb.AttributeValue{S: aws.String(s)}, nil
+		}
+		return d.decodeSymbolValue(s)
+	case c == '-' || c == '+' || (c >= '0' && c <= '9'):
+		d.r.UnreadByte()
+		n, err := d.readNumber()
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.AttributeValue{N: aws.String(n)}, nil
+	case isIdentStart(c):
+		d.r.UnreadByte()
+		sym, err := d.readSymbol()
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeSymbolValue(sym)
+	}
+	return nil, fmt.Errorf("Unexpected character '%c' in ion data", c)
+}
+
+// decodeSymbolValue handles the keywords and the annotations starting with a symbol
+func (d *ionDecoder) decodeSymbolValue(sym string) (*dynamodb.AttributeValue, error) {
+	if d.peekAnnotation() {
+		return d.decodeAnnotated(sym)
+	}
+	switch {
+	case sym == "true":
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(true)}, nil
+	case sym == "false":
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(false)}, nil
+	case sym == "null" || strings.HasPrefix(sym, "null."):
+		return &dynamodb.AttributeValue{NULL: aws.Bool(true)}, nil
+	}
+	return nil, fmt.Errorf("Unsupported ion symbol value '%s'", sym)
+}
+
+func (d *ionDecoder) decodeAnnotated(annotation string) (*dynamodb.AttributeValue, error) {
+	if !d.peekAnnotation() {
+		return nil, fmt.Errorf("Unexpected ion symbol '%s'", annotation)
+	}
+	d.r.Discard(2)
+	c, err := d.skipSpace()
+	if err != nil {
+		return nil, d.unexpected(err)
+	}
+	if c != '[' {
+		return nil, fmt.Errorf("Expected a list after the annotation '%s'", annotation)
+	}
+	l, err := d.decodeList()
+	if err != nil {
+		return nil, err
+	}
+	av := &dynamodb.AttributeValue{}
+	for _, v := range l {
+		switch {
+		case annotation == ionStringSet && v.S != nil:
+			av.SS = append(av.SS, v.S)
+		case annotation == ionNumberSet && v.N != nil:
+			av.NS = append(av.NS, v.N)
+		case annotation == ionBinarySet && v.B != nil:
+			av.BS = append(av.BS, v.B)
+		default:
+			return nil, fmt.Errorf("Invalid element for the annotation '%s'", annotation)
+		}
+	}
+	return av, nil
+}
+
+func (d *ionDecoder) decodeStruct() (*dynamodb.AttributeValue, error) {
+	m := map[string]*dynamodb.AttributeValue{}
+	for {
+		c, err := d.skipSpace()
+		if err != nil {
+			return nil, d.unexpected(err)
+		}
+		if c == '}' {
+			return &dynamodb.AttributeValue{M: m}, nil
+		}
+		var name string
+		switch {
+		case c == '"':
+			name, err = d.readString()
+		case c == '\'':
+			d.r.UnreadByte()
+			name, _, err = d.readQuoted()
+		case isIdentStart(c):
+			d.r.UnreadByte()
+			name, err = d.readSymbol()
+		default:
+			err = fmt.Errorf("Unexpected character '%c' in ion struct", c)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if c, err = d.skipSpace(); err != nil {
+			return nil, d.unexpected(err)
+		}
+		if c != ':' {
+			return nil, fmt.Errorf("Expected ':' after the field '%s'", name)
+		}
+		v, err := d.decodeValue()
+		if err != nil {
+			return nil, err
+		}
+		m[name] = v
+		if c, err = d.skipSpace(); err != nil {
+			return nil, d.unexpected(err)
+		}
+		switch c {
+		case ',':
+		case '}':
+			return &dynamodb.AttributeValue{M: m}, nil
+		default:
+			return nil, fmt.Errorf("Unexpected character '%c' in ion struct", c)
+		}
+	}
+}
+
+func (d *ionDecoder) decodeList() ([]*dynamodb.AttributeValue, error) {
+	l := []*dynamodb.AttributeValue{}
+	for {
+		c, err := d.skipSpace()
+		if err != nil {
+			return nil, d.unexpected(err)
+		}
+		if c == ']' {
+			return l, nil
+		}
+		d.r.UnreadByte()
+		v, err := d.decodeValue()
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, v)
+		if c, err = d.skipSpace(); err != nil {
+			return nil, d.unexpected(err)
+		}
+		switch c {
+		case ',':
+		case ']':
+			return l, nil
+		default:
+			return nil, fmt.Errorf("Unexpected character '%c' in ion list", c)
+		}
+	}
+}
+
+func (d *ionDecoder) decodeBlob() (*dynamodb.AttributeValue, error) {
+	buf := bytes.Buffer{}
+	for {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return nil, d.unexpected(err)
+		}
+		if c == '}' {
+			if c, err = d.r.ReadByte(); err != nil || c != '}' {
+				return nil, fmt.Errorf("Unterminated ion blob")
+			}
+			break
+		}
+		if !isSpace(c) {
+			buf.WriteByte(c)
+		}
+	}
+	b, err := base64.StdEncoding.DecodeString(buf.String())
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ion blob, got %s", err.Error())
+	}
+	return &dynamodb.AttributeValue{B: b}, nil
+}
+
+// readString reads a double quoted string whose opening quote is already consumed
+func (d *ionDecoder) readString() (string, error) {
+	sb := strings.Builder{}
+	for {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return "", d.unexpected(err)
+		}
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if err := d.readEscape(&sb); err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// readQuoted reads a quoted symbol or the concatenated long strings
+// It reports whether the value was a quoted symbol
+func (d *ionDecoder) readQuoted() (string, bool, error) {
+	if !d.peekLongQuote() {
+		d.r.ReadByte()
+		sb := strings.Builder{}
+		for {
+			c, err := d.r.ReadByte()
+			if err != nil {
+				return "", false, d.unexpected(err)
+			}
+			switch c {
+			case '\'':
+				return sb.String(), true, nil
+			case '\\':
+				if err := d.readEscape(&sb); err != nil {
+					return "", false, err
+				}
+			default:
+				sb.WriteByte(c)
+			}
+		}
+	}
+	sb := strings.Builder{}
+	for d.peekLongQuote() {
+		d.r.Discard(3)
+		for !d.peekLongQuote() {
+			c, err := d.r.ReadByte()
+			if err != nil {
+				return "", false, d.unexpected(err)
+			}
+			if c == '\\' {
+				if err := d.readEscape(&sb); err != nil {
+					return "", false, err
+				}
+				continue
+			}
+			sb.WriteByte(c)
+		}
+		d.r.Discard(3)
+		if _, err := d.skipSpace(); err != nil {
+			break
+		}
+		d.r.UnreadByte()
+	}
+	return sb.String(), false, nil
+}
+
+func (d *ionDecoder) readEscape(sb *strings.Builder) error {
+	c, err := d.r.ReadByte()
+	if err != nil {
+		return d.unexpected(err)
+	}
+	switch c {
+	case 'n':
+		sb.WriteByte('\n')
+	case 't':
+		sb.WriteByte('\t')
+	case 'r':
+		sb.WriteByte('\r')
+	case '0':
+		sb.WriteByte(0)
+	case 'a':
+		sb.WriteByte('\a')
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'v':
+		sb.WriteByte('\v')
+	case '\n':
+	case 'x', 'u', 'U':
+		size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+		hex := make([]byte, size)
+		if _, err := io.ReadFull(d.r, hex); err != nil {
+			return d.unexpected(err)
+		}
+		r, err := strconv.ParseUint(string(hex), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return fmt.Errorf("Invalid ion escape sequence '\\%c%s'", c, hex)
+		}
+		sb.WriteRune(rune(r))
+	default:
+		sb.WriteByte(c)
+	}
+	return nil
+}
+
+func (d *ionDecoder) readSymbol() (string, error) {
+	sb := strings.Builder{}
+	for {
+		c, err := d.r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if !isIdentStart(c) && !(c >= '0' && c <= '9') && c != '.' {
+			d.r.UnreadByte()
+			break
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), nil
+}
+
+// readNumber reads the ion int, decimal or float and normalizes it to the dynamodb number string
+func (d *ionDecoder) readNumber() (string, error) {
+	sb := strings.Builder{}
+	for {
+		c, err := d.r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if !(c >= '0' && c <= '9') && !strings.ContainsRune("+-.dDeE_", rune(c)) {
+			d.r.UnreadByte()
+			break
+		}
+		switch c {
+		case '_':
+		case 'd', 'D':
+			sb.WriteByte('E')
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	n := strings.Replace(sb.String(), ".E", "E", 1)
+	n = strings.TrimSuffix(n, ".")
+	if _, err := strconv.ParseFloat(n, 64); err != nil {
+		if nerr, ok := err.(*strconv.NumError); !ok || nerr.Err != strconv.ErrRange {
+			return "", fmt.Errorf("Invalid ion number '%s'", sb.String())
+		}
+	}
+	return n, nil
+}
+
+// skipSpace skips the whitespaces and comments and returns the next byte
+func (d *ionDecoder) skipSpace() (byte, error) {
+	for {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if isSpace(c) {
+			continue
+		}
+		if c == '/' {
+			next, err := d.r.Peek(1)
+			if err == nil && next[0] == '/' {
+				if _, err := d.r.ReadString('\n'); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			if err == nil && next[0] == '*' {
+				d.r.ReadByte()
+				if err := d.skipBlockComment(); err != nil {
+					return 0, err
+				}
+				continue
+			}
+		}
+		return c, nil
+	}
+}
+
+func (d *ionDecoder) skipBlockComment() error {
+	for {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return d.unexpected(err)
+		}
+		if c != '*' {
+			continue
+		}
+		next, err := d.r.Peek(1)
+		if err == nil && next[0] == '/' {
+			d.r.ReadByte()
+			return nil
+		}
+	}
+}
+
+func (d *ionDecoder) peekAnnotation() bool {
+	for {
+		b, err := d.r.Peek(1)
+		if err != nil || !isSpace(b[0]) {
+			break
+		}
+		d.r.ReadByte()
+	}
+	b, err := d.r.Peek(2)
+	return err == nil && b[0] == ':' && b[1] == ':'
+}
+
+func (d *ionDecoder) peekLongQuote() bool {
+	b, err := d.r.Peek(3)
+	return err == nil && string(b) == "'''"
+}
+
+func (d *ionDecoder) unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '$' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,268 @@
+package toolkit
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/mingrammer/cfmt"
+)
+
+// ItemOptions holds the options of the single item operations
+type ItemOptions struct {
+	Condition      string                              // Condition expression of the put, update and delete
+	Update         string                              // Update expression of the update
+	Projection     string                              // Projection expression of the get, all attributes if empty
+	Names          map[string]*string                  // Expression attribute names
+	Values         map[string]*dynamodb.AttributeValue // Expression attribute values
+	ReturnValues   string                              // Return values of the update. Defaults to ALL_NEW
+	ConsistentRead bool                                // Read the item with the strongly consistent read
+}
+
+// Editor holds dynamodb client
+type Editor struct {
+	client dynamodbiface.DynamoDBAPI
+	out    io.Writer
+}
+
+// NewEditor creates an editor with the dynamodb client
+func NewEditor(client dynamodbiface.DynamoDBAPI) *Editor {
+	return &Editor{client: client, out: os.Stdout}
+}
+
+// typedValue converts the plain JSON value into the attribute type of the definition
+// The numbers can be passed as the strings, and the binaries as the base64 strings
+func typedValue(name, typ string, v *dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
+	switch {
+	case typ == dynamodb.ScalarAttributeTypeS && v.S != nil:
+		return v, nil
+	case typ == dynamodb.ScalarAttributeTypeS && v.N != nil:
+		return &dynamodb.AttributeValue{S: v.N}, nil
+	case typ == dynamodb.ScalarAttributeTypeN && v.N != nil:
+		return v, nil
+	case typ == dynamodb.ScalarAttributeTypeN && v.S != nil:
+		if _, ok := new(big.Float).SetString(*v.S); ok {
+			return &dynamodb.AttributeValue{N: v.S}, nil
+		}
+	case typ == dynamodb.ScalarAttributeTypeB && v.S != nil:
+		if b, err := base64.StdEncoding.DecodeString(*v.S); err == nil {
+			return &dynamodb.AttributeValue{B: b}, nil
+		}
+	}
+	return nil, fmt.Errorf("Attribute '%s' must be the type %s, got %s", name, typ, plainValueJSON(v))
+}
+
+// typedItem returns the plain item whose attributes of the definitions are converted into their types
+func typedItem(item map[string]*dynamodb.AttributeValue, definitions []*dynamodb.AttributeDefinition) (map[string]*dynamodb.AttributeValue, error) {
+	typed := make(map[string]*dynamodb.AttributeValue, len(item))
+	for name, v := range item {
+		typed[name] = v
+	}
+	for _, def := range definitions {
+		v, ok := item[*def.AttributeName]
+		if !ok {
+			continue
+		}
+		tv, err := typedValue(*def.AttributeName, *def.AttributeType, v)
+		if err != nil {
+			return nil, err
+		}
+		typed[*def.AttributeName] = tv
+	}
+	return typed, nil
+}
+
+// typedKey returns the plain key converted into the key attribute types of the table
+func typedKey(key map[string]*dynamodb.AttributeValue, desc *dynamodb.TableDescription) (map[string]*dynamodb.AttributeValue, error) {
+	keyNames := map[string]bool{}
+	for _, k := range desc.KeySchema {
+		keyNames[*k.AttributeName] = true
+		if key[*k.AttributeName] == nil {
+			return nil, fmt.Errorf("Key does not have the key attribute '%s'", *k.AttributeName)
+		}
+	}
+	for name := range key {
+		if !keyNames[name] {
+			return nil, fmt.Errorf("Key has the non key attribute '%s'", name)
+		}
+	}
+	return typedItem(key, desc.AttributeDefinitions)
+}
+
+// keyOf returns the key attributes of the item
+func keyOf(item map[string]*dynamodb.AttributeValue, keySchema []*dynamodb.KeySchemaElement) map[string]*dynamodb.AttributeValue {
+	key := make(map[string]*dynamodb.AttributeValue, len(keySchema))
+	for _, k := range keySchema {
+		if v, ok := item[*k.AttributeName]; ok {
+			key[*k.AttributeName] = v
+		}
+	}
+	return key
+}
+
+// printImages prints the old and new images of the item, skipping the missing ones
+func (e *Editor) printImages(oldItem, newItem map[string]*dynamodb.AttributeValue) error {
+	for _, image := range []struct {
+		name string
+		item map[string]*dynamodb.AttributeValue
+	}{
+		{name: "old", item: oldItem},
+		{name: "new", item: newItem},
+	} {
+		if image.item == nil {
+			continue
+		}
+		s, err := plainJSON(image.item)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(e.out, "  %s: %s\n", image.name, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get prints the item of the plain key
+func (e *Editor) Get(table string, key map[string]*dynamodb.AttributeValue, opts ItemOptions) error {
+	meta, err := readMeta(e.client, table)
+	if err != nil {
+		return err
+	}
+	typed, err := typedKey(key, meta.Table)
+	if err != nil {
+		return err
+	}
+	output, err := e.client.GetItem(&dynamodb.GetItemInput{
+		TableName:                aws.String(table),
+		Key:                      typed,
+		ProjectionExpression:     optionalString(opts.Projection),
+		ExpressionAttributeNames: opts.Names,
+		ConsistentRead:           aws.Bool(opts.ConsistentRead),
+	})
+	if err != nil {
+		return err
+	}
+	keyJSON, err := plainJSON(typed)
+	if err != nil {
+		return err
+	}
+	if output.Item == nil {
+		return fmt.Errorf("Item %s is not found in the table '%s'", keyJSON, table)
+	}
+	s, err := plainJSON(output.Item)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(e.out, s)
+	return err
+}
+
+// Put writes the plain item, and prints the replaced item and the written item
+func (e *Editor) Put(table string, item map[string]*dynamodb.AttributeValue, opts ItemOptions) error {
+	meta, err := readMeta(e.client, table)
+	if err != nil {
+		return err
+	}
+	typed, err := typedItem(item, meta.Table.AttributeDefinitions)
+	if err != nil {
+		return err
+	}
+	output, err := e.client.PutItem(&dynamodb.PutItemInput{
+		TableName:                 aws.String(table),
+		Item:                      typed,
+		ConditionExpression:       optionalString(opts.Condition),
+		ExpressionAttributeNames:  opts.Names,
+		ExpressionAttributeValues: opts.Values,
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllOld),
+	})
+	if err != nil {
+		return err
+	}
+	keyJSON, err := plainJSON(keyOf(typed, meta.Table.KeySchema))
+	if err != nil {
+		return err
+	}
+	cfmt.Successf("Item %s was put into the table '%s'.\n", keyJSON, table)
+	return e.printImages(output.Attributes, typed)
+}
+
+// Update updates the item of the plain key by the update expression, and prints the returned values
+// The values are printed as the old image for ALL_OLD and UPDATED_OLD, and as the new image otherwise
+func (e *Editor) Update(table string, key map[string]*dynamodb.AttributeValue, opts ItemOptions) error {
+	if opts.Update == "" {
+		return fmt.Errorf("Update requires the update expression")
+	}
+	meta, err := readMeta(e.client, table)
+	if err != nil {
+		return err
+	}
+	typed, err := typedKey(key, meta.Table)
+	if err != nil {
+		return err
+	}
+	returnValues := opts.ReturnValues
+	if returnValues == "" {
+		returnValues = dynamodb.ReturnValueAllNew
+	}
+	output, err := e.client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String(table),
+		Key:                       typed,
+		UpdateExpression:          aws.String(opts.Update),
+		ConditionExpression:       optionalString(opts.Condition),
+		ExpressionAttributeNames:  opts.Names,
+		ExpressionAttributeValues: opts.Values,
+		ReturnValues:              aws.String(returnValues),
+	})
+	if err != nil {
+		return err
+	}
+	keyJSON, err := plainJSON(typed)
+	if err != nil {
+		return err
+	}
+	cfmt.Successf("Item %s of the table '%s' was updated.\n", keyJSON, table)
+	switch returnValues {
+	case dynamodb.ReturnValueAllOld, dynamodb.ReturnValueUpdatedOld:
+		return e.printImages(output.Attributes, nil)
+	}
+	return e.printImages(nil, output.Attributes)
+}
+
+// Delete deletes the item of the plain key, and prints the deleted item
+func (e *Editor) Delete(table string, key map[string]*dynamodb.AttributeValue, opts ItemOptions) error {
+	meta, err := readMeta(e.client, table)
+	if err != nil {
+		return err
+	}
+	typed, err := typedKey(key, meta.Table)
+	if err != nil {
+		return err
+	}
+	output, err := e.client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName:                 aws.String(table),
+		Key:                       typed,
+		ConditionExpression:       optionalString(opts.Condition),
+		ExpressionAttributeNames:  opts.Names,
+		ExpressionAttributeValues: opts.Values,
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllOld),
+	})
+	if err != nil {
+		return err
+	}
+	keyJSON, err := plainJSON(typed)
+	if err != nil {
+		return err
+	}
+	if output.Attributes == nil {
+		cfmt.Warningf("Item %s does not exist in the table '%s'.\n", keyJSON, table)
+		return nil
+	}
+	cfmt.Successf("Item %s was deleted from the table '%s'.\n", keyJSON, table)
+	return e.printImages(output.Attributes, nil)
+}
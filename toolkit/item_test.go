@@ -0,0 +1,117 @@
+package toolkit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+func TestEditor(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createFindTestTable(t, client)
+	plain := func(s string) map[string]*dynamodb.AttributeValue {
+		v, err := ParsePlainJSON(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	testCases := []struct {
+		run  func(e *Editor) error
+		want string
+	}{
+		{
+			// The numeric key can be passed as the string
+			run:  func(e *Editor) error { return e.Get("order", plain(`{"user": "user1", "id": "2"}`), ItemOptions{}) },
+			want: `{"id":2,"price":102,"user":"user1"}` + "\n",
+		},
+		{
+			run: func(e *Editor) error {
+				return e.Get("order", plain(`{"user": "user1", "id": 3}`), ItemOptions{Projection: "#s", Names: map[string]*string{"#s": aws.String("status")}})
+			},
+			want: `{"status":"canceled"}` + "\n",
+		},
+		{
+			run: func(e *Editor) error {
+				return e.Put("order", plain(`{"user": "user9", "id": 0, "price": 5, "tags": ["a"]}`), ItemOptions{Condition: "attribute_not_exists(id)"})
+			},
+			want: `  new: {"id":0,"price":5,"tags":["a"],"user":"user9"}` + "\n",
+		},
+		{
+			run: func(e *Editor) error {
+				return e.Put("order", plain(`{"user": "user9", "id": 0, "price": 6}`), ItemOptions{})
+			},
+			want: `  old: {"id":0,"price":5,"tags":["a"],"user":"user9"}` + "\n" + `  new: {"id":0,"price":6,"user":"user9"}` + "\n",
+		},
+		{
+			run: func(e *Editor) error {
+				return e.Update("order", plain(`{"user": "user9", "id": 0}`), ItemOptions{Update: "SET price = price + :d", Values: plain(`{":d": 4}`)})
+			},
+			want: `  new: {"id":0,"price":10,"user":"user9"}` + "\n",
+		},
+		{
+			run: func(e *Editor) error {
+				return e.Update("order", plain(`{"user": "user9", "id": 0}`), ItemOptions{Update: "REMOVE price", ReturnValues: dynamodb.ReturnValueUpdatedOld})
+			},
+			want: `  old: {"price":10}` + "\n",
+		},
+		{
+			run: func(e *Editor) error {
+				return e.Delete("order", plain(`{"user": "user9", "id": 0}`), ItemOptions{Condition: "attribute_not_exists(price)"})
+			},
+			want: `  old: {"id":0,"user":"user9"}` + "\n",
+		},
+		{
+			run:  func(e *Editor) error { return e.Delete("order", plain(`{"user": "user9", "id": 0}`), ItemOptions{}) },
+			want: "",
+		},
+	}
+	for i, tc := range testCases {
+		out := &bytes.Buffer{}
+		editor := NewEditor(client)
+		editor.out = out
+		if err := tc.run(editor); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if out.String() != tc.want {
+			t.Errorf("[%d] Expecting %q, got %q\n", i+1, tc.want, out.String())
+		}
+	}
+}
+
+func TestEditorErrors(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createFindTestTable(t, client)
+	editor := NewEditor(client)
+	plain := func(s string) map[string]*dynamodb.AttributeValue {
+		v, err := ParsePlainJSON(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	testCases := []struct {
+		err error
+		msg string
+	}{
+		{err: editor.Get("order", plain(`{"user": "user1"}`), ItemOptions{}), msg: "Key does not have the key attribute 'id'"},
+		{err: editor.Get("order", plain(`{"user": "user1", "id": 1, "price": 1}`), ItemOptions{}), msg: "Key has the non key attribute 'price'"},
+		{err: editor.Get("order", plain(`{"user": "user1", "id": "one"}`), ItemOptions{}), msg: `Attribute 'id' must be the type N, got "one"`},
+		{err: editor.Get("order", plain(`{"user": "user1", "id": 9}`), ItemOptions{}), msg: `Item {"id":9,"user":"user1"} is not found`},
+		{err: editor.Put("order", plain(`{"user": "user1", "id": 1, "status": true}`), ItemOptions{}), msg: "Attribute 'status' must be the type S, got true"},
+		{err: editor.Put("order", plain(`{"user": "user1", "id": 1}`), ItemOptions{Condition: "attribute_not_exists(id)"}), msg: "ConditionalCheckFailedException"},
+		{err: editor.Update("order", plain(`{"user": "user1", "id": 1}`), ItemOptions{}), msg: "requires the update expression"},
+		{err: editor.Delete("nope", plain(`{"id": 1}`), ItemOptions{}), msg: "Table 'nope' is not found"},
+	}
+	for i, tc := range testCases {
+		if tc.err == nil || !strings.Contains(tc.err.Error(), tc.msg) {
+			t.Errorf("[%d] Expecting the error %q, got %v\n", i+1, tc.msg, tc.err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package toolkit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+)
+
+const writeUnitSize = 1024
+
+// capacityLimiter is a token bucket of the capacity units refilled at the rate per second
+type capacityLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newCapacityLimiter creates a limiter, it returns nil if the rate is unlimited
+func newCapacityLimiter(rate float64) *capacityLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &capacityLimiter{rate: rate, tokens: rate, last: time.Now()}
+}
+
+// Wait blocks until the units can be consumed
+func (l *capacityLimiter) Wait(units float64) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	now := time.Now()
+	l.tokens = math.Min(l.rate, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= units
+	deficit := -l.tokens
+	l.mutex.Unlock()
+	if deficit > 0 {
+		time.Sleep(time.Duration(deficit / l.rate * float64(time.Second)))
+	}
+}
+
+// writeUnits returns the write capacity units consumed by putting the item
+func writeUnits(item map[string]*dynamodb.AttributeValue) float64 {
+	return math.Max(1, math.Ceil(float64(calc.ItemSize(item))/writeUnitSize))
+}
@@ -0,0 +1,165 @@
+package toolkit
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/klauspost/compress/zstd"
+	"github.com/mingrammer/dynamodb-toolkit/awsjson"
+)
+
+// Compression types of the dump parts
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+var partExtensions = map[string]string{
+	CompressionNone: ".json",
+	CompressionGzip: ".json.gz",
+	CompressionZstd: ".json.zst",
+}
+
+// countingWriter counts the bytes written to the underlying writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// partWriter writes the items as dynamodb json lines into a (compressed) part file
+type partWriter struct {
+	file       *os.File
+	hash       hash.Hash
+	compressed *countingWriter
+	raw        *countingWriter
+	closer     io.Closer
+	buf        *bufio.Writer
+	items      int64
+}
+
+func createPart(name, compression string) (*partWriter, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	p := &partWriter{file: f, hash: sha256.New()}
+	p.compressed = &countingWriter{w: io.MultiWriter(f, p.hash)}
+	var w io.Writer
+	switch compression {
+	case CompressionGzip:
+		gz := gzip.NewWriter(p.compressed)
+		p.closer = gz
+		w = gz
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(p.compressed)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		p.closer = zw
+		w = zw
+	default:
+		w = p.compressed
+	}
+	p.raw = &countingWriter{w: w}
+	p.buf = bufio.NewWriter(p.raw)
+	return p, nil
+}
+
+// Write writes the item as a single line
+func (p *partWriter) Write(item map[string]*dynamodb.AttributeValue) error {
+	line, err := awsjson.Marshal(&dynamodb.PutRequest{Item: item})
+	if err != nil {
+		return err
+	}
+	if _, err := p.buf.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	p.items++
+	return nil
+}
+
+// RawBytes returns the uncompressed bytes written so far
+func (p *partWriter) RawBytes() int64 {
+	return p.raw.n + int64(p.buf.Buffered())
+}
+
+// Close flushes the part and returns the checksum of the file
+func (p *partWriter) Close() (string, error) {
+	defer p.file.Close()
+	if err := p.buf.Flush(); err != nil {
+		return "", err
+	}
+	if p.closer != nil {
+		if err := p.closer.Close(); err != nil {
+			return "", err
+		}
+	}
+	if err := p.file.Sync(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(p.hash.Sum(nil)), nil
+}
+
+// Abort closes the part without completing it and removes the file
+func (p *partWriter) Abort() error {
+	if p.closer != nil {
+		p.closer.Close()
+	}
+	p.file.Close()
+	return os.Remove(p.file.Name())
+}
+
+// openPart opens the part file and returns the decompressed reader
+func openPart(name, compression string) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	switch compression {
+	case CompressionGzip:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("Can not decompress '%s', got %s", name, err.Error())
+		}
+		return &partReader{Reader: gz, closers: []func() error{gz.Close, f.Close}}, nil
+	case CompressionZstd:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("Can not decompress '%s', got %s", name, err.Error())
+		}
+		return &partReader{Reader: zr, closers: []func() error{func() error { zr.Close(); return nil }, f.Close}}, nil
+	}
+	return f, nil
+}
+
+type partReader struct {
+	io.Reader
+	closers []func() error
+}
+
+func (p *partReader) Close() error {
+	var err error
+	for _, c := range p.closers {
+		if cerr := c(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// plainValue returns the attribute value without the type as the JSON value
+// The numbers keep their precision as the JSON numbers and the binaries are base64 encoded
+func plainValue(v *dynamodb.AttributeValue) interface{} {
+	switch {
+	case v == nil || v.NULL != nil:
+		return nil
+	case v.S != nil:
+		return *v.S
+	case v.N != nil:
+		return json.Number(*v.N)
+	case v.B != nil:
+		return v.B
+	case v.BOOL != nil:
+		return *v.BOOL
+	case v.SS != nil:
+		ss := make([]string, len(v.SS))
+		for i, s := range v.SS {
+			ss[i] = *s
+		}
+		return ss
+	case v.NS != nil:
+		ns := make([]json.Number, len(v.NS))
+		for i, n := range v.NS {
+			ns[i] = json.Number(*n)
+		}
+		return ns
+	case v.BS != nil:
+		return v.BS
+	case v.L != nil:
+		l := make([]interface{}, len(v.L))
+		for i, e := range v.L {
+			l[i] = plainValue(e)
+		}
+		return l
+	case v.M != nil:
+		return plainItem(v.M)
+	}
+	return nil
+}
+
+// plainItem returns the item without the attribute types
+func plainItem(item map[string]*dynamodb.AttributeValue) map[string]interface{} {
+	m := make(map[string]interface{}, len(item))
+	for k, v := range item {
+		m[k] = plainValue(v)
+	}
+	return m
+}
+
+// plainJSON returns the item as the plain JSON object without the attribute types
+func plainJSON(item map[string]*dynamodb.AttributeValue) (string, error) {
+	b, err := json.Marshal(plainItem(item))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// attributeValueOf returns the attribute value of the JSON value decoded with the numbers
+// The strings are always S since the binaries are not distinguishable from them
+func attributeValueOf(v interface{}) *dynamodb.AttributeValue {
+	switch t := v.(type) {
+	case nil:
+		return &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	case string:
+		return &dynamodb.AttributeValue{S: aws.String(t)}
+	case json.Number:
+		return &dynamodb.AttributeValue{N: aws.String(t.String())}
+	case bool:
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(t)}
+	case []interface{}:
+		l := make([]*dynamodb.AttributeValue, len(t))
+		for i, e := range t {
+			l[i] = attributeValueOf(e)
+		}
+		return &dynamodb.AttributeValue{L: l}
+	case map[string]interface{}:
+		m := make(map[string]*dynamodb.AttributeValue, len(t))
+		for k, e := range t {
+			m[k] = attributeValueOf(e)
+		}
+		return &dynamodb.AttributeValue{M: m}
+	}
+	return nil
+}
+
+// ParsePlainJSON parses the plain JSON object into the attribute values
+func ParsePlainJSON(s string) (map[string]*dynamodb.AttributeValue, error) {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	m := map[string]interface{}{}
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("Invalid JSON object '%s', got %s", s, err.Error())
+	}
+	return attributeValueOf(m).M, nil
+}
+
+// ParsePlainJSONList parses the plain JSON array into the attribute values
+func ParsePlainJSONList(s string) ([]*dynamodb.AttributeValue, error) {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	l := []interface{}{}
+	if err := decoder.Decode(&l); err != nil {
+		return nil, fmt.Errorf("Invalid JSON array '%s', got %s", s, err.Error())
+	}
+	return attributeValueOf(l).L, nil
+}
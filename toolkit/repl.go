@@ -0,0 +1,139 @@
+package toolkit
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mingrammer/cfmt"
+	"github.com/peterh/liner"
+)
+
+// Prompts of the REPL
+const (
+	replPrompt         = "sql> "
+	replContinuePrompt = "  -> "
+)
+
+// sqlKeywords are completed in addition to the table names
+var sqlKeywords = []string{
+	"SELECT", "FROM", "WHERE", "AND", "OR", "NOT", "IN", "BETWEEN", "IS", "MISSING", "NULL",
+	"INSERT", "INTO", "VALUE", "UPDATE", "SET", "REMOVE", "DELETE", "RETURNING", "ALL", "MODIFIED", "OLD", "NEW",
+	"EXISTS", "BEGINS_WITH", "CONTAINS", "SIZE", "ATTRIBUTE_TYPE", "ORDER", "BY", "ASC", "DESC",
+}
+
+// lineReader reads the lines of the REPL with the history
+type lineReader interface {
+	Prompt(prompt string) (string, error)
+	AppendHistory(item string)
+}
+
+// sqlCompleter returns the completer of the keywords and the quoted table names for the word at the cursor
+func sqlCompleter(tables []string) liner.WordCompleter {
+	return func(line string, pos int) (string, []string, string) {
+		runes := []rune(line)
+		head, tail := string(runes[:pos]), string(runes[pos:])
+		start := strings.LastIndexAny(head, " \t(,=") + 1
+		word := head[start:]
+		if word == "" {
+			return head, nil, tail
+		}
+		completions := []string{}
+		for _, keyword := range sqlKeywords {
+			if strings.HasPrefix(keyword, strings.ToUpper(word)) {
+				completions = append(completions, keyword)
+			}
+		}
+		// The table names are quoted since they can have the dashes and dots
+		for _, table := range tables {
+			if strings.HasPrefix(table, strings.TrimPrefix(word, `"`)) {
+				completions = append(completions, `"`+table+`"`)
+			}
+		}
+		sort.Strings(completions)
+		return head[:start], completions, tail
+	}
+}
+
+// REPL reads the statements ending with ; from the terminal and executes them until exit or EOF
+// The history is loaded from and saved into the file if the name is not empty
+func (s *SQLRunner) REPL(historyFile string, opts SQLOptions) error {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	if historyFile != "" {
+		if f, err := os.Open(historyFile); err == nil {
+			line.ReadHistory(f)
+			f.Close()
+		}
+	}
+	tables, err := listTables(s.client)
+	if err != nil {
+		cfmt.Warningf("Table names can not be completed, got %s\n", err.Error())
+	}
+	line.SetWordCompleter(sqlCompleter(tables))
+	cfmt.Infof("Type the statements ending with ; and exit or Ctrl-D to quit.\n")
+
+	err = s.repl(line, opts)
+	if historyFile != "" {
+		f, ferr := os.Create(historyFile)
+		if ferr != nil {
+			return ferr
+		}
+		defer f.Close()
+		if _, ferr := line.WriteHistory(f); ferr != nil {
+			return ferr
+		}
+	}
+	return err
+}
+
+// repl executes the statements read from the reader
+// A statement can span multiple lines until the one ending with ;, and Ctrl-C discards the statement being typed
+func (s *SQLRunner) repl(r lineReader, opts SQLOptions) error {
+	lines := []string{}
+	for {
+		prompt := replPrompt
+		if len(lines) > 0 {
+			prompt = replContinuePrompt
+		}
+		text, err := r.Prompt(prompt)
+		switch err {
+		case nil:
+		case liner.ErrPromptAborted:
+			lines = []string{}
+			continue
+		case io.EOF:
+			fmt.Fprintln(s.out)
+			return nil
+		default:
+			return err
+		}
+		trimmed := strings.TrimSpace(text)
+		if len(lines) == 0 {
+			switch strings.ToLower(trimmed) {
+			case "":
+				continue
+			case "exit", "quit", `\q`:
+				return nil
+			}
+		}
+		lines = append(lines, trimmed)
+		if !strings.HasSuffix(trimmed, ";") {
+			continue
+		}
+		statement := strings.TrimSpace(strings.TrimSuffix(strings.Join(lines, "\n"), ";"))
+		if statement == "" {
+			lines = []string{}
+			continue
+		}
+		// The history has a line per entry, so the lines of the statement are joined
+		r.AppendHistory(strings.Join(lines, " "))
+		lines = []string{}
+		if err := s.Execute(statement, opts); err != nil {
+			cfmt.Errorln(err.Error())
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package toolkit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/mingrammer/cfmt"
+	"github.com/mingrammer/dynamodb-toolkit/awsjson"
+	"github.com/mingrammer/dynamodb-toolkit/retryer"
+)
+
+const readChunk = 100
+
+// ReplayOptions holds the options of the replay
+type ReplayOptions struct {
+	Since  time.Time // Records created before it are skipped, zero for unbounded
+	Until  time.Time // Records created after it are skipped, zero for unbounded
+	DryRun bool      // Print the differences against the target table instead of writing
+}
+
+// Replayer holds the dynamodb client of the target table
+type Replayer struct {
+	client dynamodbiface.DynamoDBAPI
+	out    io.Writer
+}
+
+// NewReplayer creates a replayer with the dynamodb client of the target table
+func NewReplayer(client dynamodbiface.DynamoDBAPI) *Replayer {
+	return &Replayer{client: client, out: os.Stdout}
+}
+
+// LoadRecords reads the stream records from the JSON lines file captured by the tail
+func LoadRecords(name string) ([]*dynamodbstreams.Record, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records := []*dynamodbstreams.Record{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		r := &dynamodbstreams.Record{}
+		if err := awsjson.Unmarshal([]byte(line), r); err != nil {
+			return nil, fmt.Errorf("Invalid stream record at the line %d of '%s', got %s", n, name, err.Error())
+		}
+		if r.Dynamodb == nil || r.Dynamodb.SequenceNumber == nil || r.EventName == nil {
+			return nil, fmt.Errorf("Invalid stream record at the line %d of '%s', it must have the event name and sequence number", n, name)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// change is the last change of an item by the stream records
+type change struct {
+	key  map[string]*dynamodb.AttributeValue
+	item map[string]*dynamodb.AttributeValue // New item, nil for the deletion
+}
+
+// changesOf returns the last changes of the keys by the records in the time window in the sequence number order
+// The earlier changes of a key are overwritten by the last one, so only the last ones are applied
+func changesOf(records []*dynamodbstreams.Record, keySchema []*dynamodb.KeySchemaElement, opts ReplayOptions) ([]*change, error) {
+	type sequenced struct {
+		seq    *big.Int
+		record *dynamodbstreams.Record
+	}
+	window := []sequenced{}
+	for _, r := range records {
+		created := aws.TimeValue(r.Dynamodb.ApproximateCreationDateTime)
+		if (!opts.Since.IsZero() && created.Before(opts.Since)) || (!opts.Until.IsZero() && created.After(opts.Until)) {
+			continue
+		}
+		seq, ok := new(big.Int).SetString(aws.StringValue(r.Dynamodb.SequenceNumber), 10)
+		if !ok {
+			return nil, fmt.Errorf("Invalid sequence number '%s'", aws.StringValue(r.Dynamodb.SequenceNumber))
+		}
+		window = append(window, sequenced{seq: seq, record: r})
+	}
+	sort.SliceStable(window, func(i, j int) bool {
+		return window[i].seq.Cmp(window[j].seq) < 0
+	})
+
+	changes := []*change{}
+	indexes := map[string]int{}
+	for _, w := range window {
+		r := w.record
+		for _, k := range keySchema {
+			if r.Dynamodb.Keys[*k.AttributeName] == nil {
+				return nil, fmt.Errorf("Record '%s' does not have the key attribute '%s' of the target table", *r.Dynamodb.SequenceNumber, *k.AttributeName)
+			}
+		}
+		c := &change{key: r.Dynamodb.Keys}
+		if *r.EventName != dynamodbstreams.OperationTypeRemove {
+			if r.Dynamodb.NewImage == nil {
+				return nil, fmt.Errorf("Record '%s' has no new image, the stream view type must be NEW_IMAGE or NEW_AND_OLD_IMAGES", *r.Dynamodb.SequenceNumber)
+			}
+			c.item = r.Dynamodb.NewImage
+		}
+		key := keyString(r.Dynamodb.Keys, keySchema)
+		if i, ok := indexes[key]; ok {
+			changes[i] = c
+			continue
+		}
+		indexes[key] = len(changes)
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// Replay applies the stream records in the time window to the table as the puts and deletes
+func (r *Replayer) Replay(table string, records []*dynamodbstreams.Record, opts ReplayOptions) error {
+	desc, err := r.client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(table)})
+	if err != nil {
+		return err
+	}
+	keySchema := desc.Table.KeySchema
+	changes, err := changesOf(records, keySchema, opts)
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		return r.diff(table, keySchema, changes)
+	}
+
+	cfmt.Successf("Replaying %d changes into the table '%s'...\n", len(changes), table)
+	puts, deletes := 0, 0
+	reqs := []*dynamodb.WriteRequest{}
+	for i, c := range changes {
+		if c.item != nil {
+			reqs = append(reqs, &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: c.item}})
+			puts++
+		} else {
+			reqs = append(reqs, &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: c.key}})
+			deletes++
+		}
+		if len(reqs) == writeChunk || i == len(changes)-1 {
+			if err := writeBatch(r.client, table, reqs); err != nil {
+				return err
+			}
+			reqs = []*dynamodb.WriteRequest{}
+		}
+	}
+	cfmt.Successf("Table '%s' was replayed with %d puts and %d deletes.\n", table, puts, deletes)
+	return nil
+}
+
+// getItems returns the current items of the keys by their key strings
+func getItems(client dynamodbiface.DynamoDBAPI, table string, keySchema []*dynamodb.KeySchemaElement, keys []map[string]*dynamodb.AttributeValue) (map[string]map[string]*dynamodb.AttributeValue, error) {
+	items := map[string]map[string]*dynamodb.AttributeValue{}
+	for start := 0; start < len(keys); start += readChunk {
+		end := start + readChunk
+		if end > len(keys) {
+			end = len(keys)
+		}
+		unprocessed := map[string]*dynamodb.KeysAndAttributes{
+			table: {Keys: keys[start:end], ConsistentRead: aws.Bool(true)},
+		}
+		attempts := 0
+		for len(unprocessed) > 0 && len(unprocessed[table].Keys) > 0 {
+			if attempts > 0 {
+				time.Sleep(retryer.RetryBackoff(attempts))
+			}
+			output, err := client.BatchGetItem(&dynamodb.BatchGetItemInput{RequestItems: unprocessed})
+			attempts++
+			if err != nil {
+				if retryer.IsRetryable(err) && attempts < retryer.MaxAttempts {
+					continue
+				}
+				return nil, err
+			}
+			for _, it := range output.Responses[table] {
+				items[keyString(it, keySchema)] = it
+			}
+			unprocessed = output.UnprocessedKeys
+		}
+	}
+	return items, nil
+}
+
+// diff prints the changes which would be made to the current items of the table
+func (r *Replayer) diff(table string, keySchema []*dynamodb.KeySchemaElement, changes []*change) error {
+	keys := make([]map[string]*dynamodb.AttributeValue, len(changes))
+	for i, c := range changes {
+		keys[i] = c.key
+	}
+	current, err := getItems(r.client, table, keySchema, keys)
+	if err != nil {
+		return err
+	}
+	puts, deletes, unchanged := 0, 0, 0
+	for _, c := range changes {
+		old := current[keyString(c.key, keySchema)]
+		key, err := plainJSON(c.key)
+		if err != nil {
+			return err
+		}
+		switch {
+		case c.item == nil && old == nil, c.item != nil && old != nil && len(changedAttributes(old, c.item)) == 0:
+			unchanged++
+		case c.item == nil:
+			deletes++
+			fmt.Fprintf(r.out, "%s %s\n", cfmt.Swarning("-"), key)
+		case old == nil:
+			puts++
+			item, err := plainJSON(c.item)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(r.out, "%s %s %s\n", cfmt.Ssuccess("+"), key, item)
+		default:
+			puts++
+			fmt.Fprintf(r.out, "%s %s\n", cfmt.Sinfo("~"), key)
+			for _, name := range changedAttributes(old, c.item) {
+				fmt.Fprintf(r.out, "    %s: %s -> %s\n", name, plainValueJSON(old[name]), plainValueJSON(c.item[name]))
+			}
+		}
+	}
+	cfmt.Infof("Replay would make %d puts and %d deletes on the table '%s', %d items are unchanged.\n", puts, deletes, table, unchanged)
+	return nil
+}
+
+// changedAttributes returns the sorted names of the attributes which differ between the items
+func changedAttributes(a, b map[string]*dynamodb.AttributeValue) []string {
+	names := []string{}
+	for name, v := range a {
+		if !equalValues(v, b[name]) {
+			names = append(names, name)
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// numberPrecision is enough for the 38 significant digits of the dynamodb numbers
+const numberPrecision = 256
+
+// canonicalNumber returns the number text which is the same for the equal numbers like 1.50 and 1.5
+func canonicalNumber(n string) string {
+	f, ok := new(big.Float).SetPrec(numberPrecision).SetString(n)
+	if !ok {
+		return n
+	}
+	return f.Text('g', -1)
+}
+
+// setKeys returns the sorted canonical elements of the set
+func setKeys(v *dynamodb.AttributeValue) []string {
+	keys := []string{}
+	for _, s := range v.SS {
+		keys = append(keys, aws.StringValue(s))
+	}
+	for _, n := range v.NS {
+		keys = append(keys, canonicalNumber(aws.StringValue(n)))
+	}
+	for _, b := range v.BS {
+		keys = append(keys, string(b))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// equalValues reports whether the attribute values are the same in dynamodb
+// The sets are compared regardless of the order and the numbers by their values
+func equalValues(a, b *dynamodb.AttributeValue) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	switch {
+	case a.S != nil:
+		return b.S != nil && *a.S == *b.S
+	case a.N != nil:
+		return b.N != nil && canonicalNumber(*a.N) == canonicalNumber(*b.N)
+	case a.B != nil:
+		return b.B != nil && bytes.Equal(a.B, b.B)
+	case a.BOOL != nil:
+		return b.BOOL != nil && *a.BOOL == *b.BOOL
+	case a.NULL != nil:
+		return b.NULL != nil
+	case a.SS != nil, a.NS != nil, a.BS != nil:
+		if (a.SS != nil) != (b.SS != nil) || (a.NS != nil) != (b.NS != nil) || (a.BS != nil) != (b.BS != nil) {
+			return false
+		}
+		return reflect.DeepEqual(setKeys(a), setKeys(b))
+	case a.L != nil:
+		if b.L == nil || len(a.L) != len(b.L) {
+			return false
+		}
+		for i := range a.L {
+			if !equalValues(a.L[i], b.L[i]) {
+				return false
+			}
+		}
+		return true
+	case a.M != nil:
+		return b.M != nil && len(changedAttributes(a.M, b.M)) == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// plainValueJSON returns the attribute value as the plain JSON, or (none) if it does not exist
+func plainValueJSON(v *dynamodb.AttributeValue) string {
+	if v == nil {
+		return "(none)"
+	}
+	b, err := json.Marshal(plainValue(v))
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
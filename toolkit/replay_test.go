@@ -0,0 +1,299 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+// createReplayTestTables creates the source table with the changes of a minute each and the target table restored before them
+func createReplayTestTables(t *testing.T, client *mock.DynamoDBClient, clock *mock.ManualClock) {
+	createStreamTestTable(t, client, "user")
+	createStreamTestTable(t, client, "user-restored")
+	putStreamTestItems(t, client, "user", 0, 3)
+	putStreamTestItems(t, client, "user-restored", 0, 3)
+
+	key := func(id string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{"id": {N: aws.String(id)}}
+	}
+	changes := []func() error{
+		func() error {
+			_, err := client.PutItem(&dynamodb.PutItemInput{
+				TableName: aws.String("user"),
+				Item:      map[string]*dynamodb.AttributeValue{"id": {N: aws.String("3")}, "name": {S: aws.String("user3")}},
+			})
+			return err
+		},
+		func() error {
+			_, err := client.UpdateItem(&dynamodb.UpdateItemInput{
+				TableName:                 aws.String("user"),
+				Key:                       key("1"),
+				UpdateExpression:          aws.String("SET #n = :n"),
+				ExpressionAttributeNames:  map[string]*string{"#n": aws.String("name")},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":n": {S: aws.String("renamed")}},
+			})
+			return err
+		},
+		func() error {
+			_, err := client.DeleteItem(&dynamodb.DeleteItemInput{TableName: aws.String("user"), Key: key("2")})
+			return err
+		},
+		func() error {
+			_, err := client.UpdateItem(&dynamodb.UpdateItemInput{
+				TableName:                 aws.String("user"),
+				Key:                       key("1"),
+				UpdateExpression:          aws.String("SET age = :a"),
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":a": {N: aws.String("30")}},
+			})
+			return err
+		},
+	}
+	for _, c := range changes {
+		clock.Advance(time.Minute)
+		if err := c(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2020, 9, 13, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		opts ReplayOptions
+		want map[string]string
+	}{
+		{
+			opts: ReplayOptions{Since: start.Add(time.Minute)},
+			want: map[string]string{"0": "user0", "1": "renamed/30", "3": "user3"},
+		},
+		{
+			opts: ReplayOptions{Since: start.Add(time.Minute), Until: start.Add(3 * time.Minute)},
+			want: map[string]string{"0": "user0", "1": "renamed", "3": "user3"},
+		},
+		{
+			opts: ReplayOptions{Since: start.Add(3 * time.Minute)},
+			want: map[string]string{"0": "user0", "1": "renamed/30"},
+		},
+	}
+	for i, tc := range testCases {
+		client := mock.NewDynamoDBClient()
+		clock := mock.NewManualClock(start)
+		client.SetClock(clock.Now)
+		createReplayTestTables(t, client, clock)
+
+		records, err := NewTailer(client, mock.NewDynamoDBStreamsClient(client)).Records(context.Background(), "user")
+		if err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		replayer := NewReplayer(client)
+		if err := replayer.Replay("user-restored", records, tc.opts); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		output, _ := client.Scan(&dynamodb.ScanInput{TableName: aws.String("user-restored")})
+		got := map[string]string{}
+		for _, it := range output.Items {
+			got[*it["id"].N] = *it["name"].S
+			if it["age"] != nil {
+				got[*it["id"].N] += "/" + *it["age"].N
+			}
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("[%d] Expecting %v, got %v\n", i+1, tc.want, got)
+		}
+		for id, name := range tc.want {
+			if got[id] != name {
+				t.Errorf("[%d] Expecting %s for %s, got %s\n", i+1, name, id, got[id])
+			}
+		}
+	}
+}
+
+func TestReplayDryRunFromFile(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	clock := mock.NewManualClock(time.Date(2020, 9, 13, 12, 0, 0, 0, time.UTC))
+	client.SetClock(clock.Now)
+	createReplayTestTables(t, client, clock)
+
+	// Capture the records of the changes only
+	captured := &bytes.Buffer{}
+	tailer := NewTailer(client, mock.NewDynamoDBStreamsClient(client))
+	tailer.out = captured
+	disableTestStream(t, client, "user")
+	opts := TailOptions{From: clock.Now().Add(-4*time.Minute + time.Second).Format(time.RFC3339), JSON: true}
+	if err := tailer.Tail(context.Background(), "user", opts); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	f, err := ioutil.TempFile("", "dynamotk-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(captured.Bytes())
+	f.Close()
+	records, err := LoadRecords(f.Name())
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if len(records) != 4 {
+		t.Fatalf("Expecting 4 records, got %d\n", len(records))
+	}
+
+	out := &bytes.Buffer{}
+	replayer := NewReplayer(client)
+	replayer.out = out
+	if err := replayer.Replay("user-restored", records, ReplayOptions{DryRun: true}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	want := []string{
+		`+ {"id":3} {"id":3,"name":"user3"}`,
+		`~ {"id":1}`,
+		`    age: (none) -> 30`,
+		`    name: "user1" -> "renamed"`,
+		`- {"id":2}`,
+	}
+	// Strip the colors of the markers
+	got := strings.Split(strings.TrimSpace(out.String()), "\n")
+	for i := range got {
+		got[i] = strings.NewReplacer("\x1b[32m", "", "\x1b[36m", "", "\x1b[33m", "", "\x1b[0m", "").Replace(got[i])
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expecting the diff\n%s\ngot\n%s\n", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	// The dry run does not write
+	output, _ := client.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String("user-restored"),
+		Key:       map[string]*dynamodb.AttributeValue{"id": {N: aws.String("2")}},
+	})
+	if output.Item == nil {
+		t.Errorf("Expecting the item not deleted by the dry run\n")
+	}
+}
+
+func TestReplayDryRunLargeNumbers(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createStreamTestTable(t, client, "user")
+	_, err := client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String("user"),
+		Item:      map[string]*dynamodb.AttributeValue{"id": {N: aws.String("1")}, "balance": {N: aws.String("12345678901234567891")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The numbers over 2^53 differ only in the last digit
+	records := []*dynamodbstreams.Record{
+		{
+			EventName: aws.String(dynamodbstreams.OperationTypeModify),
+			Dynamodb: &dynamodbstreams.StreamRecord{
+				SequenceNumber: aws.String("1"),
+				Keys:           map[string]*dynamodb.AttributeValue{"id": {N: aws.String("1")}},
+				NewImage:       map[string]*dynamodb.AttributeValue{"id": {N: aws.String("1")}, "balance": {N: aws.String("12345678901234567892")}},
+			},
+		},
+	}
+	out := &bytes.Buffer{}
+	replayer := NewReplayer(client)
+	replayer.out = out
+	if err := replayer.Replay("user", records, ReplayOptions{DryRun: true}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	want := "~ {\"id\":1}\n    balance: 12345678901234567891 -> 12345678901234567892"
+	got := strings.TrimSpace(strings.NewReplacer("\x1b[36m", "", "\x1b[0m", "").Replace(out.String()))
+	if got != want {
+		t.Errorf("Expecting the diff\n%s\ngot\n%s\n", want, got)
+	}
+}
+
+func TestChangedAttributes(t *testing.T) {
+	old := map[string]*dynamodb.AttributeValue{
+		"id":    {N: aws.String("1")},
+		"price": {N: aws.String("1.50")},
+		"tags":  {SS: aws.StringSlice([]string{"a", "b"})},
+		"sizes": {NS: aws.StringSlice([]string{"10", "2.0"})},
+		"data":  {BS: [][]byte{[]byte("x"), []byte("y")}},
+		"meta":  {M: map[string]*dynamodb.AttributeValue{"n": {N: aws.String("100")}, "l": {L: []*dynamodb.AttributeValue{{S: aws.String("a")}, {S: aws.String("b")}}}}},
+	}
+	testCases := []struct {
+		item map[string]*dynamodb.AttributeValue
+		want []string
+	}{
+		{
+			// The sets in the other order and the numbers in the other form are the same
+			item: map[string]*dynamodb.AttributeValue{
+				"id":    {N: aws.String("1")},
+				"price": {N: aws.String("1.5")},
+				"tags":  {SS: aws.StringSlice([]string{"b", "a"})},
+				"sizes": {NS: aws.StringSlice([]string{"2", "1E1"})},
+				"data":  {BS: [][]byte{[]byte("y"), []byte("x")}},
+				"meta":  {M: map[string]*dynamodb.AttributeValue{"n": {N: aws.String("1e2")}, "l": {L: []*dynamodb.AttributeValue{{S: aws.String("a")}, {S: aws.String("b")}}}}},
+			},
+			want: []string{},
+		},
+		{
+			// The lists are ordered
+			item: map[string]*dynamodb.AttributeValue{
+				"id":    {S: aws.String("1")},
+				"price": {N: aws.String("1.51")},
+				"tags":  {SS: aws.StringSlice([]string{"a"})},
+				"sizes": {SS: aws.StringSlice([]string{"10", "2.0"})},
+				"meta":  {M: map[string]*dynamodb.AttributeValue{"n": {N: aws.String("100")}, "l": {L: []*dynamodb.AttributeValue{{S: aws.String("b")}, {S: aws.String("a")}}}}},
+				"note":  {NULL: aws.Bool(true)},
+			},
+			want: []string{"data", "id", "meta", "note", "price", "sizes", "tags"},
+		},
+	}
+	for i, tc := range testCases {
+		if got := changedAttributes(old, tc.item); strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("[%d] Expecting %v, got %v\n", i+1, tc.want, got)
+		}
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createImportTable(client, "string-keyed")
+	createStreamTestTable(t, client, "user")
+	client.UpdateTable(&dynamodb.UpdateTableInput{
+		TableName:           aws.String("user"),
+		StreamSpecification: &dynamodb.StreamSpecification{StreamEnabled: aws.Bool(false)},
+	})
+	client.UpdateTable(&dynamodb.UpdateTableInput{
+		TableName: aws.String("user"),
+		StreamSpecification: &dynamodb.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: aws.String(dynamodb.StreamViewTypeKeysOnly),
+		},
+	})
+	putStreamTestItems(t, client, "user", 0, 1)
+	records, err := NewTailer(client, mock.NewDynamoDBStreamsClient(client)).Records(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+
+	testCases := []struct {
+		table string
+		err   string
+	}{
+		{table: "user", err: "has no new image"},
+		{table: "string-keyed", err: "does not have the key attribute 'id'"},
+		{table: "nope", err: "Table: nope not found"},
+	}
+	for i, tc := range testCases {
+		if tc.table == "string-keyed" {
+			// The keys of the records differ from the string keyed table
+			records[0].Dynamodb.Keys = map[string]*dynamodb.AttributeValue{"pk": {N: aws.String("0")}}
+		}
+		err := NewReplayer(client).Replay(tc.table, records, ReplayOptions{})
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("[%d] Expecting the error %q, got %v\n", i+1, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package toolkit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/mingrammer/cfmt"
+)
+
+const (
+	defaultRestoreWorkers = 4
+	resumeMarkerSuffix    = ".resume"
+)
+
+// RestoreOptions holds the options of the restore
+type RestoreOptions struct {
+	Workers     int             // Number of parts restored concurrently
+	WCU         float64         // Write capacity units per second shared by all workers, 0 means unlimited
+	Transformer ItemTransformer // Transformer applied before the write, optional
+}
+
+// Restorer holds dynamodb client
+type Restorer struct {
+	client dynamodbiface.DynamoDBAPI
+}
+
+// NewRestorer creates a restorer with the dynamodb client
+func NewRestorer(client dynamodbiface.DynamoDBAPI) *Restorer {
+	return &Restorer{client: client}
+}
+
+// keyString returns the string which identifies the primary key of the item
+func keyString(item map[string]*dynamodb.AttributeValue, keySchema []*dynamodb.KeySchemaElement) string {
+	sb := strings.Builder{}
+	for _, k := range keySchema {
+		v := item[*k.AttributeName]
+		if v == nil {
+			sb.WriteString("|")
+			continue
+		}
+		switch {
+		case v.S != nil:
+			sb.WriteString("S:" + *v.S)
+		case v.N != nil:
+			sb.WriteString("N:" + *v.N)
+		case v.B != nil:
+			sb.WriteString("B:" + hex.EncodeToString(v.B))
+		}
+		sb.WriteString("|")
+	}
+	return sb.String()
+}
+
+func readResumeMarker(name string) (int64, error) {
+	b, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid resume marker '%s'", name)
+	}
+	return n, nil
+}
+
+func writeResumeMarker(name string, restored int64) error {
+	return ioutil.WriteFile(name, []byte(strconv.FormatInt(restored, 10)), 0644)
+}
+
+func verifyPart(name string, part *DumpPart) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != part.SHA256 {
+		return fmt.Errorf("Checksum mismatch for '%s', expected %s but got %s", name, part.SHA256, sum)
+	}
+	return nil
+}
+
+func (r *Restorer) restorePart(dir, table, compression string, part *DumpPart, keySchema []*dynamodb.KeySchemaElement, opts RestoreOptions, limiter *capacityLimiter) error {
+	name := filepath.Join(dir, part.Name)
+	marker := name + resumeMarkerSuffix
+	restored, err := readResumeMarker(marker)
+	if err != nil {
+		return err
+	}
+	if restored >= part.ItemCount {
+		cfmt.Infof("%s was already restored, skipping.\n", part.Name)
+		return nil
+	}
+	if err := verifyPart(name, part); err != nil {
+		return err
+	}
+	f, err := openPart(name, compression)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if restored > 0 {
+		cfmt.Infof("Resuming %s from the %d item.\n", part.Name, restored)
+	}
+
+	// The resume marker counts the lines of the part which are written or filtered out
+	decode := newItemDecoder(f, ExportFormatDynamoDBJSON)
+	read, pending := int64(0), restored
+	batch := []map[string]*dynamodb.AttributeValue{}
+	keys := map[string]bool{}
+	flush := func() error {
+		units := float64(0)
+		for _, it := range batch {
+			units += writeUnits(it)
+		}
+		limiter.Wait(units)
+		if err := putItems(r.client, table, batch); err != nil {
+			return err
+		}
+		restored = pending
+		batch = []map[string]*dynamodb.AttributeValue{}
+		keys = map[string]bool{}
+		return writeResumeMarker(marker, restored)
+	}
+	for {
+		item, err := decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Can not decode '%s', got %s", name, err.Error())
+		}
+		if read++; read <= restored {
+			continue
+		}
+		items := []map[string]*dynamodb.AttributeValue{item}
+		if opts.Transformer != nil {
+			if items, err = opts.Transformer.Transform(item); err != nil {
+				return err
+			}
+		}
+		for _, it := range items {
+			// BatchWriteItem rejects the duplicate keys in a single request
+			key := keyString(it, keySchema)
+			if keys[key] {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			batch = append(batch, it)
+			keys[key] = true
+			if len(batch) == writeChunk {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		pending = read
+	}
+	if pending > restored {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	if read != part.ItemCount {
+		return fmt.Errorf("Item count mismatch for '%s', expected %d but got %d", name, part.ItemCount, read)
+	}
+	return nil
+}
+
+// Restore writes the items of the dump in the directory into the table
+func (r *Restorer) Restore(dir, table string, opts RestoreOptions) error {
+	manifest, err := ReadDumpManifest(dir)
+	if err != nil {
+		return err
+	}
+	meta, err := readMeta(r.client, table)
+	if err != nil {
+		return err
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultRestoreWorkers
+	}
+	limiter := newCapacityLimiter(opts.WCU)
+
+	total := len(manifest.Parts)
+	cfmt.Successf("[%d/%d] Restoring %d items into the table '%s'...\n", 0, total, manifest.ItemCount, table)
+	errc := make(chan error, total)
+	partc := make(chan *DumpPart)
+	mutex := sync.Mutex{}
+	done := 0
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for part := range partc {
+				if err := r.restorePart(dir, table, manifest.Compression, part, meta.Table.KeySchema, opts, limiter); err != nil {
+					errc <- err
+					continue
+				}
+				mutex.Lock()
+				done++
+				cfmt.Infof("[%d/%d] %s was restored into the table '%s'.\n", done, total, part.Name, table)
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, part := range manifest.Parts {
+		partc <- part
+	}
+	close(partc)
+	wg.Wait()
+	close(errc)
+	if err := <-errc; err != nil {
+		return err
+	}
+
+	// Clean up the resume markers only when all parts are restored
+	for _, part := range manifest.Parts {
+		os.Remove(filepath.Join(dir, part.Name+resumeMarkerSuffix))
+	}
+	cfmt.Successf("[%d/%d] Table '%s' was restored successfully.\n", total, total, table)
+	return nil
+}
@@ -0,0 +1,133 @@
+package toolkit
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+// strictClient rejects the batches containing duplicate keys like the dynamodb does
+type strictClient struct {
+	*mock.DynamoDBClient
+	written int
+}
+
+func (c *strictClient) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	for _, reqs := range input.RequestItems {
+		keys := map[string]bool{}
+		for _, r := range reqs {
+			key := *r.PutRequest.Item["id"].S
+			if keys[key] {
+				return nil, errors.New("Provided list of item keys contains duplicates")
+			}
+			keys[key] = true
+		}
+		c.written += len(reqs)
+	}
+	return c.DynamoDBClient.BatchWriteItem(input)
+}
+
+// updatedClient returns the first scanned item twice as if it was updated during the scan
+type updatedClient struct {
+	*mock.DynamoDBClient
+}
+
+func (c *updatedClient) Scan(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	output, err := c.DynamoDBClient.Scan(input)
+	if err == nil && len(output.Items) > 0 {
+		output.Items = append(output.Items, output.Items[0])
+	}
+	return output, err
+}
+
+func dumpTestTable(t *testing.T, n int, opts DumpOptions) string {
+	client := mock.NewDynamoDBClient()
+	createImportTable(client, "user")
+	putTestItems(t, client, "user", n)
+	dir, err := ioutil.TempDir("", "dynamotk-restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewDumper(client).Dump("user", dir, opts); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func countItems(client *mock.DynamoDBClient, table string) int64 {
+	desc, _ := client.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(table),
+	})
+	return *desc.Table.ItemCount
+}
+
+func TestRestore(t *testing.T) {
+	dir := dumpTestTable(t, 100, DumpOptions{Compression: CompressionZstd, PartItems: 30})
+	defer os.RemoveAll(dir)
+
+	client := mock.NewDynamoDBClient()
+	createImportTable(client, "restored")
+	if err := NewRestorer(client).Restore(dir, "restored", RestoreOptions{Workers: 3, WCU: 10000}); err != nil {
+		t.Errorf("There should be no errors, Got %s\n", err.Error())
+	}
+	if n := countItems(client, "restored"); n != 100 {
+		t.Errorf("Expecting 100 items, got %d\n", n)
+	}
+	markers, _ := filepath.Glob(filepath.Join(dir, "*"+resumeMarkerSuffix))
+	if len(markers) > 0 {
+		t.Errorf("Resume markers should be removed, got %v\n", markers)
+	}
+}
+
+func TestRestoreResume(t *testing.T) {
+	dir := dumpTestTable(t, 100, DumpOptions{Compression: CompressionGzip, PartItems: 40})
+	defer os.RemoveAll(dir)
+	manifest, _ := ReadDumpManifest(dir)
+
+	// The first part was fully restored and the second part was interrupted after 10 items
+	writeResumeMarker(filepath.Join(dir, manifest.Parts[0].Name+resumeMarkerSuffix), manifest.Parts[0].ItemCount)
+	writeResumeMarker(filepath.Join(dir, manifest.Parts[1].Name+resumeMarkerSuffix), 10)
+
+	client := mock.NewDynamoDBClient()
+	createImportTable(client, "restored")
+	if err := NewRestorer(client).Restore(dir, "restored", RestoreOptions{}); err != nil {
+		t.Errorf("There should be no errors, Got %s\n", err.Error())
+	}
+	if n := countItems(client, "restored"); n != 50 {
+		t.Errorf("Expecting 50 items, got %d\n", n)
+	}
+}
+
+func TestRestoreDuplicateKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynamotk-restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Write a dump which has the same key twice in a single batch
+	src := mock.NewDynamoDBClient()
+	createImportTable(src, "user")
+	putTestItems(t, src, "user", 10)
+	if err := NewDumper(&updatedClient{src}).Dump("user", dir, DumpOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &strictClient{DynamoDBClient: mock.NewDynamoDBClient()}
+	createImportTable(client.DynamoDBClient, "restored")
+	if err := NewRestorer(client).Restore(dir, "restored", RestoreOptions{}); err != nil {
+		t.Errorf("There should be no errors, Got %s\n", err.Error())
+	}
+	if client.written != 11 {
+		t.Errorf("Expecting 11 written items, got %d\n", client.written)
+	}
+	if n := countItems(client.DynamoDBClient, "restored"); n != 10 {
+		t.Errorf("Expecting 10 items, got %d\n", n)
+	}
+}
@@ -0,0 +1,419 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/mingrammer/cfmt"
+)
+
+// Output formats of the inferred schema
+const (
+	FormatJSONSchema = "json-schema"
+	FormatGo         = "go"
+
+	jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+)
+
+// goInitialisms are written in upper case in the Go field names
+var goInitialisms = map[string]bool{
+	"api": true, "arn": true, "id": true, "ip": true, "json": true, "http": true, "https": true,
+	"ttl": true, "uri": true, "url": true, "uuid": true,
+}
+
+// SchemaOptions holds the options of the schema inference
+type SchemaOptions struct {
+	Sample   int64  // Number of the items to sample at most, 0 means all the items
+	Segments int64  // Number of the parallel scan segments. One segment per megabyte of the table if 0
+	Format   string // One of json-schema and go. Defaults to json-schema
+	TypeName string // Name of the Go struct. Defaults to the table name
+}
+
+// SchemaInferrer holds dynamodb client
+// The progress and the warnings go to the stderr, so the stdout is only the schema
+type SchemaInferrer struct {
+	client   dynamodbiface.DynamoDBAPI
+	out      io.Writer
+	progress io.Writer
+}
+
+// NewSchemaInferrer creates an inferrer of the table schema with the dynamodb client
+func NewSchemaInferrer(client dynamodbiface.DynamoDBAPI) *SchemaInferrer {
+	return &SchemaInferrer{client: client, out: os.Stdout, progress: os.Stderr}
+}
+
+// schemaNode is the values observed at an attribute path
+type schemaNode struct {
+	count      int64                  // Number of the values
+	types      map[string]int64       // Number of the values by the type
+	fractional bool                   // Whether any number has the fraction
+	properties map[string]*schemaNode // Attributes of the maps
+	items      *schemaNode            // Elements of the lists
+}
+
+func newSchemaNode() *schemaNode {
+	return &schemaNode{types: map[string]int64{}, properties: map[string]*schemaNode{}}
+}
+
+func isFractional(n string) bool {
+	f, _, err := big.ParseFloat(n, 10, 256, big.ToNearestEven)
+	return err == nil && !f.IsInt()
+}
+
+func (n *schemaNode) observe(v *dynamodb.AttributeValue) {
+	n.count++
+	n.types[attributeType(v)]++
+	switch {
+	case v.N != nil:
+		n.fractional = n.fractional || isFractional(*v.N)
+	case v.NS != nil:
+		for _, e := range v.NS {
+			n.fractional = n.fractional || isFractional(*e)
+		}
+	case v.M != nil:
+		for name, e := range v.M {
+			child, ok := n.properties[name]
+			if !ok {
+				child = newSchemaNode()
+				n.properties[name] = child
+			}
+			child.observe(e)
+		}
+	case v.L != nil:
+		if n.items == nil {
+			n.items = newSchemaNode()
+		}
+		for _, e := range v.L {
+			n.items.observe(e)
+		}
+	}
+}
+
+// typeNames returns the observed types, the most frequent first
+func (n *schemaNode) typeNames() []string {
+	names := make([]string, 0, len(n.types))
+	for name := range n.types {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if n.types[names[i]] != n.types[names[j]] {
+			return n.types[names[i]] > n.types[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// presence returns the percentage of the maps having the attribute
+func (n *schemaNode) presence(name string) float64 {
+	return math.Round(float64(n.properties[name].count)*1000/float64(n.types["M"])) / 10
+}
+
+// propertyNames returns the attributes of the maps, the first names if observed and the others sorted
+func (n *schemaNode) propertyNames(first []string) []string {
+	names := []string{}
+	for _, name := range first {
+		if _, ok := n.properties[name]; ok {
+			names = append(names, name)
+		}
+	}
+	others := []string{}
+	for name := range n.properties {
+		if !containsString(names, name) {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
+
+func containsString(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// conflicts returns the attribute paths having more than one type with the type counts
+func (n *schemaNode) conflicts(path string) []string {
+	conflicts := []string{}
+	if len(n.types) > 1 {
+		conflicts = append(conflicts, fmt.Sprintf("%s: %s", path, typeCounts(n.types)))
+	}
+	for _, name := range n.propertyNames(nil) {
+		childPath := name
+		if path != "" {
+			childPath = path + "." + name
+		}
+		conflicts = append(conflicts, n.properties[name].conflicts(childPath)...)
+	}
+	if n.items != nil {
+		conflicts = append(conflicts, n.items.conflicts(path+"[]")...)
+	}
+	return conflicts
+}
+
+// jsonSchema returns the JSON Schema of the values, any of the schemas of the types if they conflict
+func (n *schemaNode) jsonSchema() map[string]interface{} {
+	names := n.typeNames()
+	if len(names) == 1 {
+		return n.typeSchema(names[0])
+	}
+	schemas := make([]map[string]interface{}, len(names))
+	for i, name := range names {
+		schemas[i] = n.typeSchema(name)
+	}
+	return map[string]interface{}{"anyOf": schemas, "x-conflicting-types": n.types}
+}
+
+func (n *schemaNode) typeSchema(typ string) map[string]interface{} {
+	number := "integer"
+	if n.fractional {
+		number = "number"
+	}
+	base64String := map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+	switch typ {
+	case dynamodb.ScalarAttributeTypeS:
+		return map[string]interface{}{"type": "string"}
+	case dynamodb.ScalarAttributeTypeN:
+		return map[string]interface{}{"type": number}
+	case dynamodb.ScalarAttributeTypeB:
+		return base64String
+	case "BOOL":
+		return map[string]interface{}{"type": "boolean"}
+	case "NULL":
+		return map[string]interface{}{"type": "null"}
+	case "SS":
+		return map[string]interface{}{"type": "array", "uniqueItems": true, "items": map[string]interface{}{"type": "string"}}
+	case "NS":
+		return map[string]interface{}{"type": "array", "uniqueItems": true, "items": map[string]interface{}{"type": number}}
+	case "BS":
+		return map[string]interface{}{"type": "array", "uniqueItems": true, "items": base64String}
+	case "L":
+		schema := map[string]interface{}{"type": "array"}
+		if n.items != nil && n.items.count > 0 {
+			schema["items"] = n.items.jsonSchema()
+		}
+		return schema
+	}
+	// The attributes present in all the maps are required
+	properties := map[string]interface{}{}
+	required := []string{}
+	for name, child := range n.properties {
+		schema := child.jsonSchema()
+		schema["x-presence"] = n.presence(name)
+		properties[name] = schema
+		if child.count == n.types["M"] {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// goFieldName returns the exported Go name of the attribute name
+func goFieldName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	b := strings.Builder{}
+	for _, part := range parts {
+		if goInitialisms[strings.ToLower(part)] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	field := b.String()
+	if field == "" || !unicode.IsLetter([]rune(field)[0]) {
+		field = "X" + field
+	}
+	return field
+}
+
+// tagRepresentable reports whether the attribute name can be the name of the dynamodbav tag
+// The backquote ends the raw string of the tag, the comma starts the options and "-" skips the field
+func tagRepresentable(name string) bool {
+	return name != "-" && !strings.ContainsAny(name, "`,")
+}
+
+// goGenerator writes the Go struct definitions of the map nodes
+type goGenerator struct {
+	defs  []string
+	names map[string]bool
+}
+
+// structOf defines the struct of the map node and returns its name
+func (g *goGenerator) structOf(name string, n *schemaNode, first []string) string {
+	for i := 2; g.names[name]; i++ {
+		name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), i)
+	}
+	g.names[name] = true
+	// The nested structs are defined after this one
+	index := len(g.defs)
+	g.defs = append(g.defs, "")
+
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	fields := map[string]bool{}
+	for _, attr := range n.propertyNames(first) {
+		child := n.properties[attr]
+		field := goFieldName(attr)
+		for i := 2; fields[field]; i++ {
+			field = fmt.Sprintf("%s%d", goFieldName(attr), i)
+		}
+		fields[field] = true
+		typ, option := g.goType(name+field, child)
+		tag := attr
+		comments := []string{}
+		if presence := n.presence(attr); presence < 100 {
+			tag += ",omitempty"
+			comments = append(comments, fmt.Sprintf("present in %.1f%%", presence))
+		}
+		if option != "" {
+			tag += "," + option
+		}
+		if len(child.types) > 1 {
+			comments = append(comments, "conflicting types "+typeCounts(child.types))
+		}
+		// The field of the name which can not be written in the tag is skipped by the marshaller
+		if !tagRepresentable(attr) {
+			tag = "-"
+			comments = append(comments, fmt.Sprintf("attribute %q not representable in the tag", attr))
+		}
+		fmt.Fprintf(&b, "\t%s %s `dynamodbav:%q`", field, typ, tag)
+		if len(comments) > 0 {
+			fmt.Fprintf(&b, " // %s", strings.Join(comments, ", "))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n")
+	g.defs[index] = b.String()
+	return name
+}
+
+// goType returns the Go type of the node and the tag option of the sets
+// The values of a type mixed with the nulls are pointers, and the other conflicting types are interface{}
+func (g *goGenerator) goType(name string, n *schemaNode) (string, string) {
+	typ, nullable := "", false
+	for _, t := range n.typeNames() {
+		switch {
+		case t == "NULL":
+			nullable = true
+		case typ == "":
+			typ = t
+		default:
+			return "interface{}", ""
+		}
+	}
+	number := "int64"
+	if n.fractional {
+		number = "float64"
+	}
+	pointer := ""
+	if nullable {
+		pointer = "*"
+	}
+	switch typ {
+	case dynamodb.ScalarAttributeTypeS:
+		return pointer + "string", ""
+	case dynamodb.ScalarAttributeTypeN:
+		return pointer + number, ""
+	case "BOOL":
+		return pointer + "bool", ""
+	case dynamodb.ScalarAttributeTypeB:
+		return "[]byte", ""
+	case "SS":
+		return "[]string", "stringset"
+	case "NS":
+		return "[]" + number, "numberset"
+	case "BS":
+		return "[][]byte", "binaryset"
+	case "L":
+		if n.items == nil || n.items.count == 0 {
+			return "[]interface{}", ""
+		}
+		elem, _ := g.goType(name+"Item", n.items)
+		return "[]" + elem, ""
+	case "M":
+		return pointer + g.structOf(name, n, nil), ""
+	}
+	return "interface{}", ""
+}
+
+// Infer prints the schema of the items observed in the table as a JSON Schema or the Go structs
+// The attributes have the percentage of the items having them, and the conflicting types are reported
+func (s *SchemaInferrer) Infer(table string, opts SchemaOptions) error {
+	if opts.Format == "" {
+		opts.Format = FormatJSONSchema
+	}
+	if opts.Format != FormatJSONSchema && opts.Format != FormatGo {
+		return fmt.Errorf("Invalid schema format '%s', it must be json-schema or go", opts.Format)
+	}
+	meta, err := readMeta(s.client, table)
+	if err != nil {
+		return err
+	}
+	root := newSchemaNode()
+	read, err := sampleItems(s.client, meta.Table, opts.Sample, opts.Segments, s.progress, func(item map[string]*dynamodb.AttributeValue) {
+		root.observe(&dynamodb.AttributeValue{M: item})
+	})
+	if err != nil || read == 0 {
+		return err
+	}
+	for _, conflict := range root.conflicts("") {
+		cfmt.Fwarningf(s.progress, "Conflicting types of %s\n", conflict)
+	}
+
+	if opts.Format == FormatGo {
+		name := opts.TypeName
+		if name == "" {
+			name = goFieldName(table)
+		}
+		keyNames := []string{}
+		for _, k := range meta.Table.KeySchema {
+			keyNames = append(keyNames, aws.StringValue(k.AttributeName))
+		}
+		g := &goGenerator{names: map[string]bool{}}
+		g.structOf(name, root, keyNames)
+		src, err := format.Source([]byte(strings.Join(g.defs, "\n")))
+		if err != nil {
+			return err
+		}
+		_, err = s.out.Write(src)
+		return err
+	}
+
+	schema := root.typeSchema("M")
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = table
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return err
+	}
+	_, err = s.out.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,276 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+func createSchemaTestTable(t *testing.T, client *mock.DynamoDBClient) {
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("user_id"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("user_id"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   aws.String("user-profile"),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	items := []string{
+		`{"user_id": "u1", "age": 30, "score": 1.5, "tags": ["a"], "address": {"city": "Seoul", "zip": "04524"}, "nickname": null}`,
+		`{"user_id": "u2", "age": "thirty", "score": 2, "address": {"city": "Busan"}, "nickname": "kim", "logins": [{"at": 1}, {"at": 2, "ip": "1.1.1.1"}]}`,
+		`{"user_id": "u3", "age": 41, "score": 3, "address": {"city": "Jeju"}, "nickname": "lee"}`,
+		`{"user_id": "u4", "age": 25, "score": 4, "address": {"city": "Daegu", "zip": "41900"}, "nickname": "park"}`,
+	}
+	for _, s := range items {
+		item, err := ParsePlainJSON(s)
+		if err != nil {
+			t.Fatalf("There should be no errors, Got %s\n", err.Error())
+		}
+		if _, err := client.PutItem(&dynamodb.PutItemInput{TableName: aws.String("user-profile"), Item: item}); err != nil {
+			t.Fatalf("There should be no errors, Got %s\n", err.Error())
+		}
+	}
+	_, err = client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String("user-profile"),
+		Item: map[string]*dynamodb.AttributeValue{
+			"user_id":  {S: aws.String("u5")},
+			"age":      {N: aws.String("19")},
+			"score":    {N: aws.String("5")},
+			"address":  {M: map[string]*dynamodb.AttributeValue{"city": {S: aws.String("Ulsan")}}},
+			"roles":    {SS: []*string{aws.String("admin")}},
+			"nickname": {NULL: aws.Bool(true)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+}
+
+func TestInferSchema(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createSchemaTestTable(t, client)
+	testCases := []struct {
+		opts SchemaOptions
+		want string
+	}{
+		{
+			opts: SchemaOptions{},
+			want: `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "properties": {
+    "address": {
+      "properties": {
+        "city": {
+          "type": "string",
+          "x-presence": 100
+        },
+        "zip": {
+          "type": "string",
+          "x-presence": 40
+        }
+      },
+      "required": [
+        "city"
+      ],
+      "type": "object",
+      "x-presence": 100
+    },
+    "age": {
+      "anyOf": [
+        {
+          "type": "integer"
+        },
+        {
+          "type": "string"
+        }
+      ],
+      "x-conflicting-types": {
+        "N": 4,
+        "S": 1
+      },
+      "x-presence": 100
+    },
+    "logins": {
+      "items": {
+        "properties": {
+          "at": {
+            "type": "integer",
+            "x-presence": 100
+          },
+          "ip": {
+            "type": "string",
+            "x-presence": 50
+          }
+        },
+        "required": [
+          "at"
+        ],
+        "type": "object"
+      },
+      "type": "array",
+      "x-presence": 20
+    },
+    "nickname": {
+      "anyOf": [
+        {
+          "type": "string"
+        },
+        {
+          "type": "null"
+        }
+      ],
+      "x-conflicting-types": {
+        "NULL": 2,
+        "S": 3
+      },
+      "x-presence": 100
+    },
+    "roles": {
+      "items": {
+        "type": "string"
+      },
+      "type": "array",
+      "uniqueItems": true,
+      "x-presence": 20
+    },
+    "score": {
+      "type": "number",
+      "x-presence": 100
+    },
+    "tags": {
+      "items": {
+        "type": "string"
+      },
+      "type": "array",
+      "x-presence": 20
+    },
+    "user_id": {
+      "type": "string",
+      "x-presence": 100
+    }
+  },
+  "required": [
+    "address",
+    "age",
+    "nickname",
+    "score",
+    "user_id"
+  ],
+  "title": "user-profile",
+  "type": "object"
+}
+`,
+		},
+		{
+			opts: SchemaOptions{Format: FormatGo},
+			want: `type UserProfile struct {
+	UserID   string                  ` + "`" + `dynamodbav:"user_id"` + "`" + `
+	Address  UserProfileAddress      ` + "`" + `dynamodbav:"address"` + "`" + `
+	Age      interface{}             ` + "`" + `dynamodbav:"age"` + "`" + `                       // conflicting types N 4, S 1
+	Logins   []UserProfileLoginsItem ` + "`" + `dynamodbav:"logins,omitempty"` + "`" + `          // present in 20.0%
+	Nickname *string                 ` + "`" + `dynamodbav:"nickname"` + "`" + `                  // conflicting types S 3, NULL 2
+	Roles    []string                ` + "`" + `dynamodbav:"roles,omitempty,stringset"` + "`" + ` // present in 20.0%
+	Score    float64                 ` + "`" + `dynamodbav:"score"` + "`" + `
+	Tags     []string                ` + "`" + `dynamodbav:"tags,omitempty"` + "`" + ` // present in 20.0%
+}
+
+type UserProfileAddress struct {
+	City string ` + "`" + `dynamodbav:"city"` + "`" + `
+	Zip  string ` + "`" + `dynamodbav:"zip,omitempty"` + "`" + ` // present in 40.0%
+}
+
+type UserProfileLoginsItem struct {
+	At int64  ` + "`" + `dynamodbav:"at"` + "`" + `
+	IP string ` + "`" + `dynamodbav:"ip,omitempty"` + "`" + ` // present in 50.0%
+}
+`,
+		},
+	}
+	for i, tc := range testCases {
+		out := &bytes.Buffer{}
+		inferrer := NewSchemaInferrer(client)
+		inferrer.out, inferrer.progress = out, &bytes.Buffer{}
+		if err := inferrer.Infer("user-profile", tc.opts); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if out.String() != tc.want {
+			t.Errorf("[%d] Expecting\n%s\ngot\n%s\n", i+1, tc.want, out.String())
+		}
+	}
+
+	// The progress and the warnings are not in the output
+	out, progress := &bytes.Buffer{}, &bytes.Buffer{}
+	inferrer := NewSchemaInferrer(client)
+	inferrer.out, inferrer.progress = out, progress
+	if err := inferrer.Infer("user-profile", SchemaOptions{}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &schema); err != nil {
+		t.Errorf("Expecting the output to be JSON, got %s, %s\n", err.Error(), out.String())
+	}
+	if !strings.Contains(progress.String(), "Scanning the table 'user-profile'") || !strings.Contains(progress.String(), "Conflicting types of age") {
+		t.Errorf("Expecting the progress and the warnings, got %s\n", progress.String())
+	}
+
+	if err := inferrer.Infer("user-profile", SchemaOptions{Format: "yaml"}); err == nil || err.Error() != "Invalid schema format 'yaml', it must be json-schema or go" {
+		t.Errorf("Expecting the format error, got %v\n", err)
+	}
+}
+
+func TestGoFieldName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "user_id", expected: "UserID"},
+		{name: "createdAt", expected: "CreatedAt"},
+		{name: "user-profile", expected: "UserProfile"},
+		{name: "api.url", expected: "APIURL"},
+		{name: "2fa", expected: "X2fa"},
+		{name: "-", expected: "X"},
+	}
+	for i, tc := range testCases {
+		if got := goFieldName(tc.name); got != tc.expected {
+			t.Errorf("[%d] Expecting %s, got %s\n", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestGoStructTags(t *testing.T) {
+	root := newSchemaNode()
+	root.observe(&dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+		"id":       {N: aws.String("1")},
+		"a,b":      {S: aws.String("comma")},
+		"x`y":      {S: aws.String("backquote")},
+		"-":        {S: aws.String("dash")},
+		`say "hi"`: {S: aws.String("quote")},
+	}})
+	g := &goGenerator{names: map[string]bool{}}
+	g.structOf("Item", root, []string{"id"})
+	src, err := format.Source([]byte(strings.Join(g.defs, "\n")))
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	want := "type Item struct {\n" +
+		"\tID    int64  `dynamodbav:\"id\"`\n" +
+		"\tX     string `dynamodbav:\"-\"` // attribute \"-\" not representable in the tag\n" +
+		"\tAB    string `dynamodbav:\"-\"` // attribute \"a,b\" not representable in the tag\n" +
+		"\tSayHi string `dynamodbav:\"say \\\"hi\\\"\"`\n" +
+		"\tXY    string `dynamodbav:\"-\"` // attribute \"x`y\" not representable in the tag\n" +
+		"}\n"
+	if string(src) != want {
+		t.Errorf("Expecting\n%s\ngot\n%s\n", want, string(src))
+	}
+}
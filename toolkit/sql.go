@@ -0,0 +1,173 @@
+package toolkit
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/mingrammer/cfmt"
+)
+
+// maxBatchStatements is the maximum number of the statements of a BatchExecuteStatement
+const maxBatchStatements = 25
+
+// fromTable matches the table name of the select statement, quoted or not
+var fromTable = regexp.MustCompile(`(?i)\bFROM\s+"?([A-Za-z0-9_.-]+?)"?(?:\s|\.|$)`)
+
+// SQLOptions holds the options of the PartiQL statements
+type SQLOptions struct {
+	Parameters     []*dynamodb.AttributeValue // Values of the ? placeholders, only for a single statement
+	ConsistentRead bool                       // Read the items with the strongly consistent read
+	Limit          int64                      // Maximum number of the items to print, all if zero
+	Format         string                     // table, dynamodb or json. Defaults to table
+}
+
+// SQLRunner holds dynamodb client
+type SQLRunner struct {
+	client dynamodbiface.DynamoDBAPI
+	out    io.Writer
+}
+
+// NewSQLRunner creates a runner of the PartiQL statements with the dynamodb client
+func NewSQLRunner(client dynamodbiface.DynamoDBAPI) *SQLRunner {
+	return &SQLRunner{client: client, out: os.Stdout}
+}
+
+// isSelect reports whether the statement reads the items
+func isSelect(statement string) bool {
+	fields := strings.Fields(statement)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "SELECT")
+}
+
+// statementKeyNames returns the key attribute names of the table which the statement reads from
+// It returns nil if the table is not known, then the columns are only sorted by name
+func (s *SQLRunner) statementKeyNames(statement string) []string {
+	m := fromTable.FindStringSubmatch(statement)
+	if m == nil {
+		return nil
+	}
+	meta, err := s.client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(m[1])})
+	if err != nil {
+		return nil
+	}
+	return keyNamesOf(meta.Table, "")
+}
+
+// Execute runs the statement, and prints the items read by it
+// The pages are read by the next token until the limit
+func (s *SQLRunner) Execute(statement string, opts SQLOptions) error {
+	format, err := validateFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	items := []map[string]*dynamodb.AttributeValue{}
+	input := &dynamodb.ExecuteStatementInput{
+		Statement:      aws.String(statement),
+		Parameters:     opts.Parameters,
+		ConsistentRead: aws.Bool(opts.ConsistentRead),
+	}
+	more := false
+	for {
+		output, err := s.client.ExecuteStatement(input)
+		if err != nil {
+			return err
+		}
+		items = append(items, output.Items...)
+		more = output.NextToken != nil
+		if !more || (opts.Limit > 0 && int64(len(items)) >= opts.Limit) {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	if opts.Limit > 0 && int64(len(items)) > opts.Limit {
+		items, more = items[:opts.Limit], true
+	}
+
+	if len(items) == 0 && !isSelect(statement) {
+		cfmt.Successf("Statement was executed.\n")
+		return nil
+	}
+	if err := printItems(s.out, items, s.statementKeyNames(statement), format); err != nil {
+		return err
+	}
+	if format == FormatTable {
+		cfmt.Infof("%d items.\n", len(items))
+		if more {
+			cfmt.Warningf("There are more items beyond the limit of %d items.\n", opts.Limit)
+		}
+	}
+	return nil
+}
+
+// ExecuteBatch runs the statements by the batches, and prints the items read by them
+// The failed statements are reported, and the others are not rolled back
+func (s *SQLRunner) ExecuteBatch(statements []string, opts SQLOptions) error {
+	format, err := validateFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	if len(opts.Parameters) > 0 {
+		return fmt.Errorf("Parameters can only be used with a single statement")
+	}
+	items := []map[string]*dynamodb.AttributeValue{}
+	failed := 0
+	for start := 0; start < len(statements); start += maxBatchStatements {
+		end := start + maxBatchStatements
+		if end > len(statements) {
+			end = len(statements)
+		}
+		reqs := make([]*dynamodb.BatchStatementRequest, end-start)
+		for i, statement := range statements[start:end] {
+			reqs[i] = &dynamodb.BatchStatementRequest{
+				Statement:      aws.String(statement),
+				ConsistentRead: aws.Bool(opts.ConsistentRead),
+			}
+		}
+		output, err := s.client.BatchExecuteStatement(&dynamodb.BatchExecuteStatementInput{Statements: reqs})
+		if err != nil {
+			return err
+		}
+		for i, r := range output.Responses {
+			if r.Error != nil {
+				failed++
+				cfmt.Warningf("[%d/%d] Statement failed, %s: %s\n", start+i+1, len(statements), aws.StringValue(r.Error.Code), aws.StringValue(r.Error.Message))
+				continue
+			}
+			if r.Item != nil {
+				items = append(items, r.Item)
+			}
+		}
+	}
+	if len(items) > 0 {
+		if err := printItems(s.out, items, s.statementKeyNames(statements[0]), format); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d statements failed", failed, len(statements))
+	}
+	cfmt.Successf("%d statements were executed.\n", len(statements))
+	return nil
+}
+
+// listTables returns the names of all the tables
+func listTables(client dynamodbiface.DynamoDBAPI) ([]string, error) {
+	names := []string{}
+	input := &dynamodb.ListTablesInput{}
+	for {
+		output, err := client.ListTables(input)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, aws.StringValueSlice(output.TableNames)...)
+		if output.LastEvaluatedTableName == nil {
+			return names, nil
+		}
+		input.ExclusiveStartTableName = output.LastEvaluatedTableName
+	}
+}
@@ -0,0 +1,223 @@
+package toolkit
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+	"github.com/peterh/liner"
+)
+
+// statementClient fakes the PartiQL operations which the mock does not support
+// The statements return the pages of the items, and the batch statements containing "bad" fail
+type statementClient struct {
+	*mock.DynamoDBClient
+	pages      [][]map[string]*dynamodb.AttributeValue
+	statements []string
+}
+
+func (c *statementClient) ExecuteStatement(input *dynamodb.ExecuteStatementInput) (*dynamodb.ExecuteStatementOutput, error) {
+	page := 0
+	if input.NextToken != nil {
+		page, _ = strconv.Atoi(*input.NextToken)
+	} else {
+		c.statements = append(c.statements, *input.Statement)
+	}
+	output := &dynamodb.ExecuteStatementOutput{}
+	if page < len(c.pages) {
+		output.Items = c.pages[page]
+	}
+	if page+1 < len(c.pages) {
+		output.NextToken = aws.String(strconv.Itoa(page + 1))
+	}
+	return output, nil
+}
+
+func (c *statementClient) BatchExecuteStatement(input *dynamodb.BatchExecuteStatementInput) (*dynamodb.BatchExecuteStatementOutput, error) {
+	output := &dynamodb.BatchExecuteStatementOutput{}
+	for _, r := range input.Statements {
+		c.statements = append(c.statements, *r.Statement)
+		resp := &dynamodb.BatchStatementResponse{}
+		if strings.Contains(*r.Statement, "bad") {
+			resp.Error = &dynamodb.BatchStatementError{Code: aws.String("ValidationError"), Message: aws.String("bad statement")}
+		} else if isSelect(*r.Statement) {
+			resp.Item = map[string]*dynamodb.AttributeValue{"id": {N: aws.String(strconv.Itoa(len(c.statements)))}}
+		}
+		output.Responses = append(output.Responses, resp)
+	}
+	return output, nil
+}
+
+func newStatementClient(t *testing.T) *statementClient {
+	client := mock.NewDynamoDBClient()
+	createStreamTestTable(t, client, "user")
+	item := func(id int) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{"name": {S: aws.String("user" + strconv.Itoa(id))}, "id": {N: aws.String(strconv.Itoa(id))}}
+	}
+	return &statementClient{
+		DynamoDBClient: client,
+		pages: [][]map[string]*dynamodb.AttributeValue{
+			{item(0), item(1)},
+			{item(2)},
+		},
+	}
+}
+
+func TestSQLExecute(t *testing.T) {
+	testCases := []struct {
+		statement string
+		opts      SQLOptions
+		want      string
+	}{
+		{
+			statement: `SELECT * FROM "user"`,
+			opts:      SQLOptions{Format: FormatJSON},
+			want:      `{"id":0,"name":"user0"}` + "\n" + `{"id":1,"name":"user1"}` + "\n" + `{"id":2,"name":"user2"}` + "\n",
+		},
+		{
+			// The key attribute is the first column
+			statement: `SELECT * FROM user WHERE name = 'user0'`,
+			opts:      SQLOptions{Limit: 1},
+			want:      "id  name\n0   user0\n",
+		},
+	}
+	for i, tc := range testCases {
+		client := newStatementClient(t)
+		out := &bytes.Buffer{}
+		runner := NewSQLRunner(client)
+		runner.out = out
+		if err := runner.Execute(tc.statement, tc.opts); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if out.String() != tc.want {
+			t.Errorf("[%d] Expecting %q, got %q\n", i+1, tc.want, out.String())
+		}
+	}
+
+	client := newStatementClient(t)
+	client.pages = nil
+	runner := NewSQLRunner(client)
+	out := &bytes.Buffer{}
+	runner.out = out
+	if err := runner.Execute(`DELETE FROM "user" WHERE id = 1`, SQLOptions{}); err != nil || out.Len() != 0 {
+		t.Errorf("Expecting no items for the delete, got %q, %v\n", out.String(), err)
+	}
+}
+
+func TestSQLExecuteBatch(t *testing.T) {
+	client := newStatementClient(t)
+	out := &bytes.Buffer{}
+	runner := NewSQLRunner(client)
+	runner.out = out
+	statements := []string{}
+	for i := 0; i < 30; i++ {
+		statements = append(statements, `SELECT * FROM "user" WHERE id = `+strconv.Itoa(i))
+	}
+	if err := runner.ExecuteBatch(statements, SQLOptions{Format: FormatJSON}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if got := strings.Count(out.String(), "\n"); got != 30 {
+		t.Errorf("Expecting %d items, got %d\n", 30, got)
+	}
+
+	err := runner.ExecuteBatch([]string{`UPDATE "user" SET a = 1 WHERE id = 1`, `bad`}, SQLOptions{})
+	if err == nil || err.Error() != "1 of 2 statements failed" {
+		t.Errorf("Expecting the failed statement, got %v\n", err)
+	}
+	err = runner.ExecuteBatch(statements, SQLOptions{Parameters: []*dynamodb.AttributeValue{{N: aws.String("1")}}})
+	if err == nil || !strings.Contains(err.Error(), "only be used with a single statement") {
+		t.Errorf("Expecting the parameters error, got %v\n", err)
+	}
+}
+
+// scriptedReader returns the lines as if they are typed
+type scriptedReader struct {
+	lines   []string
+	prompts []string
+	history []string
+}
+
+func (r *scriptedReader) Prompt(prompt string) (string, error) {
+	r.prompts = append(r.prompts, prompt)
+	if len(r.lines) == 0 {
+		return "", io.EOF
+	}
+	line := r.lines[0]
+	r.lines = r.lines[1:]
+	if line == "^C" {
+		return "", liner.ErrPromptAborted
+	}
+	return line, nil
+}
+
+func (r *scriptedReader) AppendHistory(item string) {
+	r.history = append(r.history, item)
+}
+
+func TestSQLREPL(t *testing.T) {
+	testCases := []struct {
+		lines      []string
+		statements []string
+		history    []string
+		prompts    string
+	}{
+		{
+			lines:      []string{"", "SELECT *", `  FROM "user"`, "WHERE id = 1;", "exit", "SELECT 1;"},
+			statements: []string{"SELECT *\nFROM \"user\"\nWHERE id = 1"},
+			history:    []string{`SELECT * FROM "user" WHERE id = 1;`},
+			prompts:    "sql> ,sql> ,  -> ,  -> ,sql> ",
+		},
+		{
+			// Ctrl-C discards the statement being typed
+			lines:      []string{"SELECT *", "^C", "SELECT * FROM user;", ";"},
+			statements: []string{"SELECT * FROM user"},
+			history:    []string{"SELECT * FROM user;"},
+			prompts:    "sql> ,  -> ,sql> ,sql> ,sql> ",
+		},
+	}
+	for i, tc := range testCases {
+		client := newStatementClient(t)
+		runner := NewSQLRunner(client)
+		runner.out = &bytes.Buffer{}
+		reader := &scriptedReader{lines: tc.lines}
+		if err := runner.repl(reader, SQLOptions{Format: FormatJSON}); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if strings.Join(client.statements, "|") != strings.Join(tc.statements, "|") {
+			t.Errorf("[%d] Expecting the statements %q, got %q\n", i+1, tc.statements, client.statements)
+		}
+		if strings.Join(reader.history, "|") != strings.Join(tc.history, "|") {
+			t.Errorf("[%d] Expecting the history %q, got %q\n", i+1, tc.history, reader.history)
+		}
+		if got := strings.Join(reader.prompts, ","); got != tc.prompts {
+			t.Errorf("[%d] Expecting the prompts %q, got %q\n", i+1, tc.prompts, got)
+		}
+	}
+}
+
+func TestSQLCompleter(t *testing.T) {
+	complete := sqlCompleter([]string{"user", "user-log", "order"})
+	testCases := []struct {
+		line        string
+		pos         int
+		head        string
+		completions []string
+		tail        string
+	}{
+		{line: "sel", pos: 3, head: "", completions: []string{"SELECT"}},
+		{line: "SELECT * FROM us", pos: 16, head: "SELECT * FROM ", completions: []string{`"user"`, `"user-log"`}},
+		{line: `SELECT * FROM "or WHERE`, pos: 17, head: "SELECT * FROM ", completions: []string{`"order"`}, tail: " WHERE"},
+		{line: "SELECT * ", pos: 9, head: "SELECT * "},
+	}
+	for i, tc := range testCases {
+		head, completions, tail := complete(tc.line, tc.pos)
+		if head != tc.head || tail != tc.tail || strings.Join(completions, ",") != strings.Join(tc.completions, ",") {
+			t.Errorf("[%d] Expecting %q %q %q, got %q %q %q\n", i+1, tc.head, tc.completions, tc.tail, head, completions, tail)
+		}
+	}
+}
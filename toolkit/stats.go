@@ -0,0 +1,335 @@
+package toolkit
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/mingrammer/cfmt"
+	"github.com/mingrammer/dynamodb-toolkit/calc"
+)
+
+const (
+	defaultTopPartitions = 10
+
+	// Items over 90% of the maximum item size are reported as near the limit
+	nearItemSizeLimit = calc.MaxItemSize * 9 / 10
+)
+
+// StatsOptions holds the options of the statistics
+type StatsOptions struct {
+	Sample   int64 // Number of the items to sample at most, 0 means all the items
+	Segments int64 // Number of the parallel scan segments. One segment per megabyte of the table if 0
+	Top      int   // Number of the largest partitions to print. Defaults to 10
+}
+
+// Profiler holds dynamodb client
+type Profiler struct {
+	client dynamodbiface.DynamoDBAPI
+	out    io.Writer
+}
+
+// NewProfiler creates a profiler of the table data with the dynamodb client
+func NewProfiler(client dynamodbiface.DynamoDBAPI) *Profiler {
+	return &Profiler{client: client, out: os.Stdout}
+}
+
+type attributeStats struct {
+	items int64
+	types map[string]int64
+}
+
+type partitionStats struct {
+	key   *dynamodb.AttributeValue
+	items int64
+	bytes int64
+}
+
+// exactSizes is the number of the item sizes in bytes which have their own buckets
+const exactSizes = 1024
+
+// sizeHistogram counts the item sizes in the buckets, so its memory does not grow with the number of the items
+// The sizes under 1 KiB are exact and the larger ones are in the buckets of 0.2% of the size at most
+type sizeHistogram struct {
+	buckets map[int64]int64 // Counts by the lower bounds of the buckets
+	count   int64
+	min     int64
+	max     int64
+}
+
+// bucketOf returns the lower bound of the bucket of the size, which keeps the 10 most significant bits of the size
+func bucketOf(size int64) int64 {
+	if size < exactSizes {
+		return size
+	}
+	shift := uint(bits.Len64(uint64(size)) - 10)
+	return size >> shift << shift
+}
+
+func (h *sizeHistogram) add(size int64) {
+	if h.count == 0 || size < h.min {
+		h.min = size
+	}
+	if h.count == 0 || size > h.max {
+		h.max = size
+	}
+	h.count++
+	h.buckets[bucketOf(size)]++
+}
+
+// percentile returns the nearest rank percentile, which is the upper bound of its bucket
+// The min and max are exact
+func (h *sizeHistogram) percentile(p float64) int64 {
+	if h.count == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p / 100 * float64(h.count)))
+	switch {
+	case rank <= 1:
+		return h.min
+	case rank >= h.count:
+		return h.max
+	}
+	lowers := make([]int64, 0, len(h.buckets))
+	for lower := range h.buckets {
+		lowers = append(lowers, lower)
+	}
+	sort.Slice(lowers, func(i, j int) bool { return lowers[i] < lowers[j] })
+	seen := int64(0)
+	for _, lower := range lowers {
+		if seen += h.buckets[lower]; seen >= rank {
+			upper := lower
+			if lower >= exactSizes {
+				upper = lower + int64(1)<<uint(bits.Len64(uint64(lower))-10) - 1
+			}
+			return calc.Min(upper, h.max)
+		}
+	}
+	return h.max
+}
+
+// tableStats accumulates the statistics of the items
+type tableStats struct {
+	hashKey    string
+	hashSchema []*dynamodb.KeySchemaElement
+	rangeKey   string
+	sizes      *sizeHistogram
+	nearLimit  int64
+	bytes      int64
+	attributes map[string]*attributeStats
+	partitions map[string]*partitionStats
+}
+
+func newTableStats(keySchema []*dynamodb.KeySchemaElement) *tableStats {
+	s := &tableStats{
+		sizes:      &sizeHistogram{buckets: map[int64]int64{}},
+		attributes: map[string]*attributeStats{},
+		partitions: map[string]*partitionStats{},
+	}
+	for _, k := range keySchema {
+		if *k.KeyType == dynamodb.KeyTypeHash {
+			s.hashKey = *k.AttributeName
+			s.hashSchema = []*dynamodb.KeySchemaElement{k}
+		} else {
+			s.rangeKey = *k.AttributeName
+		}
+	}
+	return s
+}
+
+func (s *tableStats) add(item map[string]*dynamodb.AttributeValue) {
+	size := calc.ItemSize(item)
+	s.sizes.add(size)
+	if size > nearItemSizeLimit {
+		s.nearLimit++
+	}
+	s.bytes += size
+	for name, v := range item {
+		a, ok := s.attributes[name]
+		if !ok {
+			a = &attributeStats{types: map[string]int64{}}
+			s.attributes[name] = a
+		}
+		a.items++
+		a.types[attributeType(v)]++
+	}
+	// The key string tells the string "1" from the number 1 and keeps the numbers as they are
+	hash := keyString(item, s.hashSchema)
+	p, ok := s.partitions[hash]
+	if !ok {
+		p = &partitionStats{key: item[s.hashKey]}
+		s.partitions[hash] = p
+	}
+	p.items++
+	p.bytes += size
+}
+
+// percentile returns the nearest rank percentile of the sorted values
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// typeCounts returns the types of the attribute with their counts, the most frequent first
+func typeCounts(types map[string]int64) string {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if types[names[i]] != types[names[j]] {
+			return types[names[i]] > types[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	counts := make([]string, len(names))
+	for i, name := range names {
+		counts[i] = fmt.Sprintf("%s %d", name, types[name])
+	}
+	return strings.Join(counts, ", ")
+}
+
+// topPartitions returns the n largest partitions by the value
+func (s *tableStats) topPartitions(n int, value func(p *partitionStats) int64) []*partitionStats {
+	partitions := make([]*partitionStats, 0, len(s.partitions))
+	for _, p := range s.partitions {
+		partitions = append(partitions, p)
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		if value(partitions[i]) != value(partitions[j]) {
+			return value(partitions[i]) > value(partitions[j])
+		}
+		return plainValueJSON(partitions[i].key) < plainValueJSON(partitions[j].key)
+	})
+	if len(partitions) > n {
+		partitions = partitions[:n]
+	}
+	return partitions
+}
+
+// print writes the statistics, noting the approximate item count of the table if they come from a sample
+func (s *tableStats) print(out io.Writer, top int, sampled bool, tableItems int64) error {
+	items := s.sizes.count
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%d items, %s in total.\n", items, humanBytes(s.bytes))
+	if sampled {
+		fmt.Fprintf(w, "The numbers come from a sample of %d items of about %d items in the table.\n", items, tableItems)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "item size\tmin\tp50\tp95\tmax")
+	fmt.Fprintf(w, "\t%s\t%s\t%s\t%s\n", humanBytes(s.sizes.percentile(0)), humanBytes(s.sizes.percentile(50)), humanBytes(s.sizes.percentile(95)), humanBytes(s.sizes.percentile(100)))
+	fmt.Fprintf(w, "%d items are over 90%% of the %s item size limit.\n\n", s.nearLimit, humanBytes(calc.MaxItemSize))
+
+	names := make([]string, 0, len(s.attributes))
+	for name := range s.attributes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.attributes[names[i]].items != s.attributes[names[j]].items {
+			return s.attributes[names[i]].items > s.attributes[names[j]].items
+		}
+		return names[i] < names[j]
+	})
+	fmt.Fprintln(w, "attribute\titems\tratio\ttypes")
+	for _, name := range names {
+		a := s.attributes[name]
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%s\n", name, a.items, float64(a.items)*100/float64(items), typeCounts(a.types))
+	}
+
+	byItems := s.topPartitions(top, func(p *partitionStats) int64 { return p.items })
+	fmt.Fprintf(w, "\n%s\titems\tsize\n", s.hashKey)
+	for _, p := range byItems {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", cellString(p.key), p.items, humanBytes(p.bytes))
+	}
+	bySize := s.topPartitions(top, func(p *partitionStats) int64 { return p.bytes })
+	fmt.Fprintf(w, "\n%s\tsize\titems\n", s.hashKey)
+	for _, p := range bySize {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", cellString(p.key), humanBytes(p.bytes), p.items)
+	}
+
+	// The keys of the table are unique, so the number of the sort keys of a hash key is its item count
+	if s.rangeKey != "" {
+		counts := make([]int64, 0, len(s.partitions))
+		for _, p := range s.partitions {
+			counts = append(counts, p.items)
+		}
+		sort.Slice(counts, func(i, j int) bool { return counts[i] < counts[j] })
+		fmt.Fprintf(w, "\n%s per %s\thash keys\tmin\tp50\tp95\tmax\n", s.rangeKey, s.hashKey)
+		fmt.Fprintf(w, "\t%d\t%d\t%d\t%d\t%d\n", len(counts), percentile(counts, 0), percentile(counts, 50), percentile(counts, 95), percentile(counts, 100))
+	}
+	return w.Flush()
+}
+
+// sampleItems reads the items of the table with the parallel segmented scans and passes them to the add function
+// The sample is the first items of each scan segment, which are spread over the partitions by the hash keys
+// The progress is written to the progress writer, which is not the output of the machine readable formats
+// It returns the number of the items read
+func sampleItems(client dynamodbiface.DynamoDBAPI, desc *dynamodb.TableDescription, sample, segments int64, progress io.Writer, add func(map[string]*dynamodb.AttributeValue)) (int64, error) {
+	table := *desc.TableName
+	totalSegments := segments
+	if totalSegments <= 0 {
+		totalSegments = totalSegmentsOf(desc)
+	}
+	totalSegments = calc.Min(totalSegments, maxTotalSegments)
+	segmentLimit := int64(0)
+	if sample > 0 {
+		totalSegments = calc.Min(totalSegments, sample)
+		segmentLimit = int64(math.Ceil(float64(sample) / float64(totalSegments)))
+		cfmt.Finfof(progress, "Sampling %d items of the table '%s' with %d segments...\n", sample, table, totalSegments)
+	} else {
+		cfmt.Finfof(progress, "Scanning the table '%s' with %d segments...\n", table, totalSegments)
+	}
+
+	itemc := make(chan map[string]*dynamodb.AttributeValue, writeChunk)
+	scanc := make(chan error, 1)
+	go func() {
+		scanc <- scanSegments(client, table, totalSegments, segmentLimit, itemc)
+		close(itemc)
+	}()
+	read := int64(0)
+	for it := range itemc {
+		if sample > 0 && read >= sample {
+			continue
+		}
+		add(it)
+		read++
+	}
+	if err := <-scanc; err != nil {
+		return read, err
+	}
+	if read == 0 {
+		cfmt.Fwarningf(progress, "Table '%s' has no items.\n", table)
+	}
+	return read, nil
+}
+
+// Profile prints the item size distribution, the attribute types, the largest partitions and the sort key cardinality of the table
+func (p *Profiler) Profile(table string, opts StatsOptions) error {
+	meta, err := readMeta(p.client, table)
+	if err != nil {
+		return err
+	}
+	if opts.Top <= 0 {
+		opts.Top = defaultTopPartitions
+	}
+	stats := newTableStats(meta.Table.KeySchema)
+	read, err := sampleItems(p.client, meta.Table, opts.Sample, opts.Segments, os.Stdout, stats.add)
+	if err != nil || read == 0 {
+		return err
+	}
+	return stats.print(p.out, opts.Top, opts.Sample > 0, aws.Int64Value(meta.Table.ItemCount))
+}
@@ -0,0 +1,160 @@
+package toolkit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	testCases := []struct {
+		p        float64
+		expected int64
+	}{
+		{p: 0, expected: 1},
+		{p: 50, expected: 5},
+		{p: 95, expected: 10},
+		{p: 100, expected: 10},
+	}
+	for i, tc := range testCases {
+		if got := percentile(sorted, tc.p); got != tc.expected {
+			t.Errorf("[%d] Expecting %d, got %d\n", i+1, tc.expected, got)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("Expecting 0 of no values, got %d\n", got)
+	}
+}
+
+func TestSizeHistogram(t *testing.T) {
+	h := &sizeHistogram{buckets: map[int64]int64{}}
+	for size := int64(1); size <= 100000; size++ {
+		h.add(size)
+	}
+	testCases := []struct {
+		p        float64
+		expected int64
+	}{
+		{p: 0, expected: 1},
+		{p: 1, expected: 1000},
+		{p: 50, expected: 50047},
+		{p: 95, expected: 95103},
+		{p: 100, expected: 100000},
+	}
+	for i, tc := range testCases {
+		got := h.percentile(tc.p)
+		if got != tc.expected {
+			t.Errorf("[%d] Expecting %d, got %d\n", i+1, tc.expected, got)
+		}
+		// The buckets are within 0.2% of the size
+		if exact := int64(tc.p * 1000); exact > 0 && float64(got-exact)/float64(exact) > 0.002 {
+			t.Errorf("[%d] Expecting %d within 0.2%%, got %d\n", i+1, exact, got)
+		}
+	}
+	if len(h.buckets) > exactSizes+7*512 {
+		t.Errorf("Expecting the bounded buckets, got %d\n", len(h.buckets))
+	}
+}
+
+func TestProfile(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createFindTestTable(t, client)
+	// A large item near the item size limit
+	client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String("order"),
+		Item: map[string]*dynamodb.AttributeValue{
+			"user":  {S: aws.String("user3")},
+			"id":    {N: aws.String("0")},
+			"price": {S: aws.String("free")},
+			"note":  {S: aws.String(strings.Repeat("a", 380*1024))},
+		},
+	})
+
+	out := &bytes.Buffer{}
+	profiler := NewProfiler(client)
+	profiler.out = out
+	if err := profiler.Profile("order", StatsOptions{Segments: 3, Top: 2}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	want := `13 items, 380.3 KiB in total.
+
+item size  min   p50   p95        max
+           18 B  21 B  380.0 KiB  380.0 KiB
+1 items are over 90% of the 400.0 KiB item size limit.
+
+attribute  items  ratio   types
+id         13     100.0%  N 13
+price      13     100.0%  N 12, S 1
+user       13     100.0%  S 13
+status     3      23.1%   S 3
+note       1      7.7%    S 1
+
+user   items  size
+user0  4      92 B
+user1  4      96 B
+
+user   size       items
+user3  380.0 KiB  1
+user1  96 B       4
+
+id per user  hash keys  min  p50  p95  max
+             4          1    4    4    4
+`
+	if out.String() != want {
+		t.Errorf("Expecting\n%s\ngot\n%s\n", want, out.String())
+	}
+
+	// The sample is divided into the segments, and the small segments have fewer items
+	sampleCases := []struct {
+		opts     StatsOptions
+		expected string
+	}{
+		{opts: StatsOptions{Sample: 5, Segments: 1}, expected: "5 items, 380.1 KiB in total.\nThe numbers come from a sample of 5 items of about 13 items in the table.\n\n"},
+		{opts: StatsOptions{Sample: 6, Segments: 2}, expected: "3 items, "},
+	}
+	for i, tc := range sampleCases {
+		out.Reset()
+		if err := profiler.Profile("order", tc.opts); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		if !strings.HasPrefix(out.String(), tc.expected) {
+			t.Errorf("[%d] Expecting %q, got %s\n", i+1, tc.expected, out.String())
+		}
+	}
+	if err := profiler.Profile("unknown", StatsOptions{}); err == nil {
+		t.Errorf("Expecting the unknown table error\n")
+	}
+}
+
+func TestProfileLargeNumberKeys(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createStreamTestTable(t, client, "user")
+	// The hash keys over 2^53 are different partitions
+	for _, id := range []string{"12345678901234567891", "12345678901234567892"} {
+		_, err := client.PutItem(&dynamodb.PutItemInput{
+			TableName: aws.String("user"),
+			Item:      map[string]*dynamodb.AttributeValue{"id": {N: aws.String(id)}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := &bytes.Buffer{}
+	profiler := NewProfiler(client)
+	profiler.out = out
+	if err := profiler.Profile("user", StatsOptions{}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	want := `id                    items  size
+12345678901234567891  1      13 B
+12345678901234567892  1      13 B
+`
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("Expecting\n%s\nin\n%s\n", want, out.String())
+	}
+}
@@ -0,0 +1,331 @@
+package toolkit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+	"github.com/mingrammer/cfmt"
+	"github.com/mingrammer/dynamodb-toolkit/awsjson"
+)
+
+// Starting positions of the tail
+const (
+	TailFromTrimHorizon = "trim-horizon"
+	TailFromLatest      = "latest"
+)
+
+const defaultPollInterval = time.Second
+
+// TailOptions holds the options of the tail
+type TailOptions struct {
+	From         string            // trim-horizon, latest, an RFC3339 timestamp or a duration ago like 10m. Defaults to latest
+	EventNames   []string          // Event names to print among INSERT, MODIFY and REMOVE, all if empty
+	Keys         map[string]string // Key attribute values which the records must have, all if empty
+	JSON         bool              // Print the records as JSON lines instead of the human readable form
+	PollInterval time.Duration     // Interval of polling the shards when there are no new records
+}
+
+// Tailer holds the dynamodb and dynamodb streams clients
+type Tailer struct {
+	client  dynamodbiface.DynamoDBAPI
+	streams dynamodbstreamsiface.DynamoDBStreamsAPI
+	out     io.Writer
+}
+
+// NewTailer creates a tailer with the dynamodb and dynamodb streams clients
+func NewTailer(client dynamodbiface.DynamoDBAPI, streams dynamodbstreamsiface.DynamoDBStreamsAPI) *Tailer {
+	return &Tailer{client: client, streams: streams, out: os.Stdout}
+}
+
+// tailShard is the reading state of a shard
+type tailShard struct {
+	parentID string
+	iterator *string
+	started  bool
+	done     bool
+}
+
+// parseFrom returns the shard iterator type of the starting position and the time of the first record to print
+func parseFrom(from string, now time.Time) (string, time.Time, error) {
+	switch from {
+	case "", TailFromLatest:
+		return dynamodbstreams.ShardIteratorTypeLatest, time.Time{}, nil
+	case TailFromTrimHorizon:
+		return dynamodbstreams.ShardIteratorTypeTrimHorizon, time.Time{}, nil
+	}
+	// The streams have no timestamp iterator, so the records before the time are skipped
+	if since, err := time.Parse(time.RFC3339, from); err == nil {
+		return dynamodbstreams.ShardIteratorTypeTrimHorizon, since, nil
+	}
+	if ago, err := time.ParseDuration(from); err == nil && ago > 0 {
+		return dynamodbstreams.ShardIteratorTypeTrimHorizon, now.Add(-ago), nil
+	}
+	return "", time.Time{}, fmt.Errorf("Invalid starting position '%s', it must be trim-horizon, latest, an RFC3339 timestamp or a duration", from)
+}
+
+// describeShards returns all the shards of the stream and whether the stream is disabled
+func (t *Tailer) describeShards(arn *string) ([]*dynamodbstreams.Shard, bool, error) {
+	shards := []*dynamodbstreams.Shard{}
+	input := &dynamodbstreams.DescribeStreamInput{StreamArn: arn}
+	for {
+		output, err := t.streams.DescribeStream(input)
+		if err != nil {
+			return nil, false, err
+		}
+		shards = append(shards, output.StreamDescription.Shards...)
+		if output.StreamDescription.LastEvaluatedShardId == nil {
+			return shards, *output.StreamDescription.StreamStatus == dynamodbstreams.StreamStatusDisabled, nil
+		}
+		input.ExclusiveStartShardId = output.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// Tail prints the records of the latest stream of the table until the context is done or the stream is disabled
+func (t *Tailer) Tail(ctx context.Context, table string, opts TailOptions) error {
+	iteratorType, since, err := parseFrom(opts.From, time.Now())
+	if err != nil {
+		return err
+	}
+	arn, err := t.latestStreamArn(table)
+	if err != nil {
+		return err
+	}
+	if !opts.JSON {
+		cfmt.Infof("Tailing the stream '%s'...\n", *arn)
+	}
+	return t.walk(ctx, arn, iteratorType, true, opts.PollInterval, func(r *dynamodbstreams.Record) error {
+		if r.Dynamodb.ApproximateCreationDateTime != nil && r.Dynamodb.ApproximateCreationDateTime.Before(since) {
+			return nil
+		}
+		if !matchRecord(r, opts) {
+			return nil
+		}
+		return t.print(r, opts.JSON)
+	})
+}
+
+// Records returns all the records of the latest stream of the table from the oldest one to the latest one
+func (t *Tailer) Records(ctx context.Context, table string) ([]*dynamodbstreams.Record, error) {
+	arn, err := t.latestStreamArn(table)
+	if err != nil {
+		return nil, err
+	}
+	records := []*dynamodbstreams.Record{}
+	err = t.walk(ctx, arn, dynamodbstreams.ShardIteratorTypeTrimHorizon, false, 0, func(r *dynamodbstreams.Record) error {
+		records = append(records, r)
+		return nil
+	})
+	return records, err
+}
+
+func (t *Tailer) latestStreamArn(table string) (*string, error) {
+	desc, err := t.client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(table)})
+	if err != nil {
+		return nil, err
+	}
+	if desc.Table.LatestStreamArn == nil {
+		return nil, fmt.Errorf("Table '%s' has no stream", table)
+	}
+	return desc.Table.LatestStreamArn, nil
+}
+
+// walk calls the function with the records of all the shards of the stream
+// The child shards are read after their parent shards are completely read
+// If following, it polls the new records until the context is done or the stream is disabled,
+// otherwise it returns once all the shards have no more records
+func (t *Tailer) walk(ctx context.Context, arn *string, iteratorType string, follow bool, pollInterval time.Duration, fn func(r *dynamodbstreams.Record) error) error {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	shards := map[string]*tailShard{}
+	order := []string{}
+	initial := true
+	refresh := true
+	for {
+		if refresh {
+			described, disabled, err := t.describeShards(arn)
+			if err != nil {
+				return err
+			}
+			for _, sh := range described {
+				if _, ok := shards[*sh.ShardId]; ok {
+					continue
+				}
+				s := &tailShard{parentID: aws.StringValue(sh.ParentShardId)}
+				// Only the open shards have the new records from the latest position
+				if initial && iteratorType == dynamodbstreams.ShardIteratorTypeLatest && sh.SequenceNumberRange.EndingSequenceNumber != nil {
+					s.done = true
+				}
+				shards[*sh.ShardId] = s
+				order = append(order, *sh.ShardId)
+			}
+			if disabled && t.allDone(shards) {
+				return nil
+			}
+			refresh = false
+		}
+		read := 0
+		for _, id := range order {
+			s := shards[id]
+			if s.done {
+				continue
+			}
+			if parent, ok := shards[s.parentID]; ok && !parent.done {
+				continue
+			}
+			if !s.started {
+				typ := dynamodbstreams.ShardIteratorTypeTrimHorizon
+				if initial {
+					typ = iteratorType
+				}
+				output, err := t.streams.GetShardIterator(&dynamodbstreams.GetShardIteratorInput{
+					StreamArn:         arn,
+					ShardId:           aws.String(id),
+					ShardIteratorType: aws.String(typ),
+				})
+				if err != nil {
+					return err
+				}
+				s.iterator, s.started = output.ShardIterator, true
+			}
+			output, err := t.streams.GetRecords(&dynamodbstreams.GetRecordsInput{ShardIterator: s.iterator})
+			if err != nil {
+				return err
+			}
+			for _, r := range output.Records {
+				if err := fn(r); err != nil {
+					return err
+				}
+			}
+			read += len(output.Records)
+			s.iterator = output.NextShardIterator
+			if s.iterator == nil {
+				// The children of the closed shard are found by describing the stream again
+				s.done, refresh = true, true
+			}
+		}
+		// The shards found later are read from the beginning not to miss their records
+		initial = false
+		if !follow && read == 0 && !refresh {
+			return nil
+		}
+		wait := pollInterval
+		if read > 0 || !follow {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+			refresh = refresh || read == 0
+		}
+	}
+}
+
+func (t *Tailer) allDone(shards map[string]*tailShard) bool {
+	for _, s := range shards {
+		if !s.done {
+			return false
+		}
+	}
+	return true
+}
+
+// matchRecord reports whether the record has one of the event names and all the key values of the options
+func matchRecord(r *dynamodbstreams.Record, opts TailOptions) bool {
+	if len(opts.EventNames) > 0 {
+		matched := false
+		for _, name := range opts.EventNames {
+			if strings.EqualFold(name, aws.StringValue(r.EventName)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for name, value := range opts.Keys {
+		if !matchKeyValue(r.Dynamodb.Keys[name], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchKeyValue reports whether the key attribute value equals the plain value
+// The numbers are compared by their values and the binaries by their base64 encodings
+func matchKeyValue(v *dynamodb.AttributeValue, value string) bool {
+	switch {
+	case v == nil:
+		return false
+	case v.S != nil:
+		return *v.S == value
+	case v.N != nil:
+		a, ok := new(big.Float).SetString(*v.N)
+		b, ok2 := new(big.Float).SetString(value)
+		return ok && ok2 && a.Cmp(b) == 0
+	case v.B != nil:
+		return base64.StdEncoding.EncodeToString(v.B) == value
+	}
+	return false
+}
+
+// print writes the record as a JSON line or in the human readable form
+func (t *Tailer) print(r *dynamodbstreams.Record, asJSON bool) error {
+	if asJSON {
+		b, err := awsjson.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(t.out, "%s\n", b)
+		return err
+	}
+	keys, err := plainJSON(r.Dynamodb.Keys)
+	if err != nil {
+		return err
+	}
+	event := aws.StringValue(r.EventName)
+	switch event {
+	case dynamodbstreams.OperationTypeInsert:
+		event = cfmt.Ssuccess(event)
+	case dynamodbstreams.OperationTypeModify:
+		event = cfmt.Sinfo(event)
+	case dynamodbstreams.OperationTypeRemove:
+		event = cfmt.Swarning(event)
+	}
+	line := fmt.Sprintf("%s %s %s", aws.TimeValue(r.Dynamodb.ApproximateCreationDateTime).UTC().Format(time.RFC3339), event, keys)
+	if r.UserIdentity != nil {
+		line += fmt.Sprintf(" by %s", aws.StringValue(r.UserIdentity.PrincipalId))
+	}
+	lines := []string{line}
+	for _, image := range []struct {
+		name string
+		item map[string]*dynamodb.AttributeValue
+	}{
+		{name: "old", item: r.Dynamodb.OldImage},
+		{name: "new", item: r.Dynamodb.NewImage},
+	} {
+		if image.item == nil {
+			continue
+		}
+		s, err := plainJSON(image.item)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s", image.name, s))
+	}
+	_, err = fmt.Fprintln(t.out, strings.Join(lines, "\n"))
+	return err
+}
@@ -0,0 +1,173 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/mingrammer/dynamodb-toolkit/awsjson"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+func createStreamTestTable(t *testing.T, client *mock.DynamoDBClient, name string) {
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: aws.String("N")},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		StreamSpecification: &dynamodb.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: aws.String(dynamodb.StreamViewTypeNewAndOldImages),
+		},
+		TableName: aws.String(name),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func putStreamTestItems(t *testing.T, client *mock.DynamoDBClient, name string, from, to int) {
+	for i := from; i < to; i++ {
+		_, err := client.PutItem(&dynamodb.PutItemInput{
+			TableName: aws.String(name),
+			Item: map[string]*dynamodb.AttributeValue{
+				"id":   {N: aws.String(strconv.Itoa(i))},
+				"name": {S: aws.String("user" + strconv.Itoa(i))},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func disableTestStream(t *testing.T, client *mock.DynamoDBClient, name string) {
+	_, err := client.UpdateTable(&dynamodb.UpdateTableInput{
+		TableName:           aws.String(name),
+		StreamSpecification: &dynamodb.StreamSpecification{StreamEnabled: aws.Bool(false)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tailedRecords parses the JSON lines of the tail output
+func tailedRecords(t *testing.T, out *bytes.Buffer) []*dynamodbstreams.Record {
+	records := []*dynamodbstreams.Record{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		r := &dynamodbstreams.Record{}
+		if err := awsjson.Unmarshal([]byte(line), r); err != nil {
+			t.Fatalf("There should be no errors, Got %s\n", err.Error())
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestTail(t *testing.T) {
+	// The records span the parent and child shards
+	total := 1200
+	testCases := []struct {
+		opts TailOptions
+		want int
+	}{
+		{opts: TailOptions{From: TailFromTrimHorizon}, want: total + 1},
+		{opts: TailOptions{From: "1h"}, want: total + 1},
+		{opts: TailOptions{From: time.Now().Add(time.Hour).Format(time.RFC3339)}, want: 0},
+		{opts: TailOptions{From: TailFromTrimHorizon, EventNames: []string{"remove"}}, want: 1},
+		{opts: TailOptions{From: TailFromTrimHorizon, Keys: map[string]string{"id": "1100.0"}}, want: 1},
+		{opts: TailOptions{From: TailFromTrimHorizon, Keys: map[string]string{"id": "7"}, EventNames: []string{"INSERT", "REMOVE"}}, want: 2},
+	}
+	for i, tc := range testCases {
+		client := mock.NewDynamoDBClient()
+		createStreamTestTable(t, client, "user")
+		putStreamTestItems(t, client, "user", 0, total)
+		client.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String("user"),
+			Key:       map[string]*dynamodb.AttributeValue{"id": {N: aws.String("7")}},
+		})
+		disableTestStream(t, client, "user")
+
+		out := &bytes.Buffer{}
+		tailer := NewTailer(client, mock.NewDynamoDBStreamsClient(client))
+		tailer.out = out
+		tc.opts.JSON = true
+		tc.opts.PollInterval = 10 * time.Millisecond
+		// The tail ends after reading all the shards of the disabled stream
+		if err := tailer.Tail(context.Background(), "user", tc.opts); err != nil {
+			t.Fatalf("[%d] There should be no errors, Got %s\n", i+1, err.Error())
+		}
+		records := tailedRecords(t, out)
+		if len(records) != tc.want {
+			t.Fatalf("[%d] Expecting %d records, got %d\n", i+1, tc.want, len(records))
+		}
+		for j := 1; j < len(records); j++ {
+			if *records[j-1].Dynamodb.SequenceNumber >= *records[j].Dynamodb.SequenceNumber {
+				t.Errorf("[%d] Expecting the records in the sequence order, got %s after %s\n", i+1, *records[j].Dynamodb.SequenceNumber, *records[j-1].Dynamodb.SequenceNumber)
+				break
+			}
+		}
+	}
+}
+
+func TestTailFromLatest(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createStreamTestTable(t, client, "user")
+	putStreamTestItems(t, client, "user", 0, 10)
+
+	out := &bytes.Buffer{}
+	tailer := NewTailer(client, mock.NewDynamoDBStreamsClient(client))
+	tailer.out = out
+	errc := make(chan error, 1)
+	go func() {
+		errc <- tailer.Tail(context.Background(), "user", TailOptions{PollInterval: 10 * time.Millisecond})
+	}()
+	// Wait for the tail to get the latest positions
+	time.Sleep(100 * time.Millisecond)
+	putStreamTestItems(t, client, "user", 10, 11)
+	disableTestStream(t, client, "user")
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("There should be no errors, Got %s\n", err.Error())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expecting the tail to end after the stream is disabled")
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "INSERT") || !strings.HasSuffix(lines[0], ` {"id":10}`) || lines[1] != `  new: {"id":10,"name":"user10"}` {
+		t.Errorf("Expecting the new record only, got %q\n", out.String())
+	}
+}
+
+func TestTailCancel(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createStreamTestTable(t, client, "user")
+	tailer := NewTailer(client, mock.NewDynamoDBStreamsClient(client))
+	tailer.out = &bytes.Buffer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := tailer.Tail(ctx, "user", TailOptions{PollInterval: 10 * time.Millisecond}); err != nil {
+		t.Errorf("There should be no errors, Got %s\n", err.Error())
+	}
+
+	createImportTable(client, "nostream")
+	if err := tailer.Tail(ctx, "nostream", TailOptions{}); err == nil || !strings.Contains(err.Error(), "has no stream") {
+		t.Errorf("Expecting the no stream error, got %v\n", err)
+	}
+	if err := tailer.Tail(ctx, "user", TailOptions{From: "yesterday"}); err == nil || !strings.Contains(err.Error(), "Invalid starting position") {
+		t.Errorf("Expecting the invalid starting position error, got %v\n", err)
+	}
+}
@@ -0,0 +1,271 @@
+package toolkit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Actions of the transform rules
+const (
+	TransformDrop   = "drop"
+	TransformRename = "rename"
+	TransformSet    = "set"
+	TransformHash   = "hash"
+	TransformMask   = "mask"
+	TransformPrefix = "prefix"
+	TransformFilter = "filter"
+)
+
+// Formats of the mask action
+const (
+	MaskAll   = "all"
+	MaskEmail = "email"
+	MaskPhone = "phone"
+)
+
+// Operators of the filter action
+const (
+	FilterEqual      = "="
+	FilterNotEqual   = "<>"
+	FilterExists     = "exists"
+	FilterNotExists  = "not_exists"
+	FilterBeginsWith = "begins_with"
+)
+
+// TransformRule is a single rule of the transform rules file
+type TransformRule struct {
+	Action     string                   `json:"action"`
+	Attribute  string                   `json:"attribute,omitempty"`
+	Attributes []string                 `json:"attributes,omitempty"`
+	To         string                   `json:"to,omitempty"`       // rename
+	Value      *dynamodb.AttributeValue `json:"value,omitempty"`    // set, filter
+	Salt       string                   `json:"salt,omitempty"`     // hash
+	Format     string                   `json:"format,omitempty"`   // mask
+	Prefix     string                   `json:"prefix,omitempty"`   // prefix
+	Operator   string                   `json:"operator,omitempty"` // filter
+}
+
+// names returns the attribute names which the rule applies to
+func (r *TransformRule) names() []string {
+	if r.Attribute != "" {
+		return append([]string{r.Attribute}, r.Attributes...)
+	}
+	return r.Attributes
+}
+
+func (r *TransformRule) validate() error {
+	if len(r.names()) == 0 {
+		return fmt.Errorf("Transform rule '%s' requires the attribute", r.Action)
+	}
+	switch r.Action {
+	case TransformDrop, TransformHash:
+	case TransformRename:
+		if r.Attribute == "" || r.To == "" {
+			return fmt.Errorf("Transform rule 'rename' requires the attribute and to")
+		}
+	case TransformSet:
+		if r.Value == nil {
+			return fmt.Errorf("Transform rule 'set' requires the value")
+		}
+	case TransformMask:
+		switch r.Format {
+		case "":
+			r.Format = MaskAll
+		case MaskAll, MaskEmail, MaskPhone:
+		default:
+			return fmt.Errorf("Unsupported mask format '%s'", r.Format)
+		}
+	case TransformPrefix:
+		if r.Prefix == "" {
+			return fmt.Errorf("Transform rule 'prefix' requires the prefix")
+		}
+	case TransformFilter:
+		switch r.Operator {
+		case FilterExists, FilterNotExists:
+		case FilterEqual, FilterNotEqual, FilterBeginsWith:
+			if r.Value == nil {
+				return fmt.Errorf("Filter operator '%s' requires the value", r.Operator)
+			}
+		default:
+			return fmt.Errorf("Unsupported filter operator '%s'", r.Operator)
+		}
+	default:
+		return fmt.Errorf("Unsupported transform action '%s'", r.Action)
+	}
+	return nil
+}
+
+// Transformer applies the transform rules to the items before they are written
+type Transformer struct {
+	rules []*TransformRule
+}
+
+// NewTransformer creates a transformer with the validated rules
+func NewTransformer(rules []*TransformRule) (*Transformer, error) {
+	for _, r := range rules {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &Transformer{rules: rules}, nil
+}
+
+// LoadTransformer reads the transform rules file
+func LoadTransformer(name string) (*Transformer, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("Can not read the transform rules, got %s", err.Error())
+	}
+	file := struct {
+		Rules []*TransformRule `json:"rules"`
+	}{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("Invalid transform rules, got %s", err.Error())
+	}
+	return NewTransformer(file.Rules)
+}
+
+// Apply returns the transformed copy of the item
+// It reports false if the item is filtered out
+func (t *Transformer) Apply(item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, bool, error) {
+	out := make(map[string]*dynamodb.AttributeValue, len(item))
+	for k, v := range item {
+		out[k] = v
+	}
+	for _, r := range t.rules {
+		if r.Action == TransformFilter {
+			if matchFilter(out, r) {
+				return nil, false, nil
+			}
+			continue
+		}
+		if r.Action == TransformRename {
+			if v, ok := out[r.Attribute]; ok {
+				delete(out, r.Attribute)
+				out[r.To] = v
+			}
+			continue
+		}
+		for _, name := range r.names() {
+			if r.Action == TransformSet {
+				out[name] = r.Value
+				continue
+			}
+			v, ok := out[name]
+			if !ok {
+				continue
+			}
+			switch r.Action {
+			case TransformDrop:
+				delete(out, name)
+			case TransformHash:
+				out[name] = hashValue(v, r.Salt)
+			case TransformMask:
+				if v.S == nil {
+					return nil, false, fmt.Errorf("Can not mask the non-string attribute '%s'", name)
+				}
+				out[name] = &dynamodb.AttributeValue{S: aws.String(maskString(*v.S, r.Format))}
+			case TransformPrefix:
+				if v.S == nil {
+					return nil, false, fmt.Errorf("Can not prefix the non-string attribute '%s'", name)
+				}
+				out[name] = &dynamodb.AttributeValue{S: aws.String(r.Prefix + *v.S)}
+			}
+		}
+	}
+	return out, true, nil
+}
+
+// Transform implements the ItemTransformer, the filtered out item results in no items
+func (t *Transformer) Transform(item map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+	out, keep, err := t.Apply(item)
+	if err != nil || !keep {
+		return nil, err
+	}
+	return []map[string]*dynamodb.AttributeValue{out}, nil
+}
+
+func matchFilter(item map[string]*dynamodb.AttributeValue, r *TransformRule) bool {
+	for _, name := range r.names() {
+		v, ok := item[name]
+		var match bool
+		switch r.Operator {
+		case FilterExists:
+			match = ok
+		case FilterNotExists:
+			match = !ok
+		case FilterEqual:
+			match = ok && reflect.DeepEqual(v, r.Value)
+		case FilterNotEqual:
+			match = !ok || !reflect.DeepEqual(v, r.Value)
+		case FilterBeginsWith:
+			match = ok && v.S != nil && r.Value.S != nil && strings.HasPrefix(*v.S, *r.Value.S)
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// hashValue replaces the value with its salted sha256 while preserving the scalar type
+func hashValue(v *dynamodb.AttributeValue, salt string) *dynamodb.AttributeValue {
+	h := sha256.New()
+	h.Write([]byte(salt))
+	switch {
+	case v.S != nil:
+		h.Write([]byte(*v.S))
+		return &dynamodb.AttributeValue{S: aws.String(fmt.Sprintf("%x", h.Sum(nil)))}
+	case v.N != nil:
+		h.Write([]byte(*v.N))
+		n := binary.BigEndian.Uint64(h.Sum(nil)[:8]) >> 1
+		return &dynamodb.AttributeValue{N: aws.String(strconv.FormatUint(n, 10))}
+	case v.B != nil:
+		h.Write(v.B)
+		return &dynamodb.AttributeValue{B: h.Sum(nil)}
+	}
+	b, _ := json.Marshal(v)
+	h.Write(b)
+	return &dynamodb.AttributeValue{S: aws.String(fmt.Sprintf("%x", h.Sum(nil)))}
+}
+
+func maskString(s, format string) string {
+	switch format {
+	case MaskEmail:
+		at := strings.LastIndex(s, "@")
+		if at > 0 {
+			return s[:1] + strings.Repeat("*", at-1) + s[at:]
+		}
+	case MaskPhone:
+		// Keep the last 4 digits and the separators
+		digits := 0
+		for _, c := range s {
+			if c >= '0' && c <= '9' {
+				digits++
+			}
+		}
+		b := []rune(s)
+		for i, c := range b {
+			if c >= '0' && c <= '9' {
+				if digits > 4 {
+					b[i] = '*'
+				}
+				digits--
+			}
+		}
+		return string(b)
+	}
+	return strings.Repeat("*", len([]rune(s)))
+}
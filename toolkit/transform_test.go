@@ -0,0 +1,172 @@
+package toolkit
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mingrammer/dynamodb-toolkit/mock"
+)
+
+const testTransformRules = `{
+  "rules": [
+    {"action": "filter", "attribute": "status", "operator": "=", "value": {"S": "deleted"}},
+    {"action": "drop", "attributes": ["ssn"]},
+    {"action": "rename", "attribute": "name", "to": "full_name"},
+    {"action": "set", "attribute": "env", "value": {"S": "staging"}},
+    {"action": "hash", "attributes": ["token", "code"], "salt": "pepper"},
+    {"action": "mask", "attribute": "email", "format": "email"},
+    {"action": "mask", "attribute": "phone", "format": "phone"},
+    {"action": "prefix", "attribute": "id", "prefix": "stg#"}
+  ]
+}`
+
+func loadTestTransformer(t *testing.T) *Transformer {
+	f, err := ioutil.TempFile("", "dynamotk-transform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(testTransformRules)
+	f.Close()
+	transformer, err := LoadTransformer(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transformer
+}
+
+func TestTransformerApply(t *testing.T) {
+	transformer := loadTestTransformer(t)
+
+	item := map[string]*dynamodb.AttributeValue{
+		"id":    {S: aws.String("1")},
+		"name":  {S: aws.String("foo")},
+		"ssn":   {S: aws.String("123-45-6789")},
+		"token": {S: aws.String("secret")},
+		"code":  {N: aws.String("1234")},
+		"email": {S: aws.String("john.doe@example.com")},
+		"phone": {S: aws.String("+82 10-1234-5678")},
+	}
+	out, keep, err := transformer.Apply(item)
+	if err != nil || !keep {
+		t.Fatalf("The item should be kept without errors, got %v\n", err)
+	}
+	expected := map[string]string{
+		"id":        "stg#1",
+		"full_name": "foo",
+		"env":       "staging",
+		"email":     "j*******@example.com",
+		"phone":     "+** **-****-5678",
+	}
+	for k, v := range expected {
+		if out[k] == nil || *out[k].S != v {
+			t.Errorf("Expecting %s to be %s, got %v\n", k, v, out[k])
+		}
+	}
+	if _, ok := out["ssn"]; ok {
+		t.Errorf("ssn should be dropped\n")
+	}
+	if _, ok := out["name"]; ok {
+		t.Errorf("name should be renamed\n")
+	}
+	if out["token"].S == nil || *out["token"].S == "secret" || len(*out["token"].S) != 64 {
+		t.Errorf("token should be hashed, got %v\n", out["token"])
+	}
+	if out["code"].N == nil || *out["code"].N == "1234" {
+		t.Errorf("code should be hashed into a number, got %v\n", out["code"])
+	}
+	if *item["id"].S != "1" || item["ssn"] == nil {
+		t.Errorf("The source item should not be modified\n")
+	}
+
+	// Filtered out by the predicate
+	item["status"] = &dynamodb.AttributeValue{S: aws.String("deleted")}
+	if _, keep, _ := transformer.Apply(item); keep {
+		t.Errorf("The deleted item should be filtered out\n")
+	}
+}
+
+func TestInvalidTransformRules(t *testing.T) {
+	testCases := []*TransformRule{
+		{Action: "unknown", Attribute: "a"},
+		{Action: TransformDrop},
+		{Action: TransformRename, Attribute: "a"},
+		{Action: TransformSet, Attribute: "a"},
+		{Action: TransformMask, Attribute: "a", Format: "card"},
+		{Action: TransformFilter, Attribute: "a", Operator: "="},
+	}
+	for i, tc := range testCases {
+		if _, err := NewTransformer([]*TransformRule{tc}); err == nil {
+			t.Errorf("[%d] There should be a validation error\n", i+1)
+		}
+	}
+}
+
+func TestCopyWithTransform(t *testing.T) {
+	source := mock.NewDynamoDBClient()
+	createImportTable(source, "user")
+	putTestItems(t, source, "user", 50)
+	putItems(source, "user", []map[string]*dynamodb.AttributeValue{
+		{"id": {S: aws.String("51")}, "status": {S: aws.String("deleted")}},
+	})
+	target := mock.NewDynamoDBClient()
+	createImportTable(target, "user")
+
+	copier := NewCopier(source, target)
+	opts := CopyOptions{Workers: 2, Transformer: loadTestTransformer(t)}
+	if err := copier.Copy("user", "user", opts); err != nil {
+		t.Errorf("There should be no errors, Got %s\n", err.Error())
+	}
+	if n := countItems(target, "user"); n != 50 {
+		t.Errorf("Expecting 50 items, got %d\n", n)
+	}
+	output, _ := target.Scan(&dynamodb.ScanInput{
+		TableName:     aws.String("user"),
+		Segment:       aws.Int64(0),
+		TotalSegments: aws.Int64(1),
+	})
+	for _, it := range output.Items {
+		if it["full_name"] == nil || it["env"] == nil || (*it["id"].S)[:4] != "stg#" {
+			t.Errorf("The item should be transformed, got %v\n", it)
+			break
+		}
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	createImportTable(client, "user")
+	putTestItems(t, client, "user", 20)
+	backfiller := NewBackfiller(client)
+
+	setEnv := ItemTransformerFunc(func(item map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+		item["env"] = &dynamodb.AttributeValue{S: aws.String("prod")}
+		return []map[string]*dynamodb.AttributeValue{item}, nil
+	})
+	if err := backfiller.Backfill("user", CopyOptions{Transformer: setEnv}); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	output, _ := client.Scan(&dynamodb.ScanInput{TableName: aws.String("user")})
+	if len(output.Items) != 20 {
+		t.Errorf("Expecting 20 items, got %d\n", len(output.Items))
+	}
+	for _, it := range output.Items {
+		if it["env"] == nil {
+			t.Errorf("The item should be backfilled, got %v\n", it)
+			break
+		}
+	}
+
+	// The items written with the new keys would be scanned and transformed again
+	err := backfiller.Backfill("user", CopyOptions{Transformer: loadTestTransformer(t)})
+	if err == nil || !strings.Contains(err.Error(), "Backfill can not change the key of the item") {
+		t.Errorf("Expecting the key change error, got %v\n", err)
+	}
+	if n := countItems(client, "user"); n != 20 {
+		t.Errorf("Expecting 20 items, got %d\n", n)
+	}
+}
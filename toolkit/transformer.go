@@ -0,0 +1,80 @@
+package toolkit
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// ItemTransformer transforms an item into zero or more items before they are written
+// It is accepted by the copy, restore and backfill pipelines
+type ItemTransformer interface {
+	Transform(item map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error)
+}
+
+// ItemTransformerFunc is an adapter to use the ordinary function as an ItemTransformer
+type ItemTransformerFunc func(item map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error)
+
+// Transform calls f(item)
+func (f ItemTransformerFunc) Transform(item map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+	return f(item)
+}
+
+// ChainTransformers creates a transformer which applies the transformers in order
+func ChainTransformers(transformers ...ItemTransformer) ItemTransformer {
+	return ItemTransformerFunc(func(item map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+		items := []map[string]*dynamodb.AttributeValue{item}
+		for _, t := range transformers {
+			next := []map[string]*dynamodb.AttributeValue{}
+			for _, it := range items {
+				out, err := t.Transform(it)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, out...)
+			}
+			items = next
+		}
+		return items, nil
+	})
+}
+
+// UnmarshalItem unmarshals the item into the Go value pointed by v
+func UnmarshalItem(item map[string]*dynamodb.AttributeValue, v interface{}) error {
+	return dynamodbattribute.UnmarshalMap(item, v)
+}
+
+// MarshalItem marshals the Go value into the item
+func MarshalItem(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	return dynamodbattribute.MarshalMap(v)
+}
+
+// NewStructTransformer creates a transformer which works on the Go structs instead of the attribute values
+// Each item is unmarshalled into a new value of the prototype's type, and the values returned by fn are marshalled back
+func NewStructTransformer(prototype interface{}, fn func(v interface{}) ([]interface{}, error)) ItemTransformer {
+	typ := reflect.TypeOf(prototype)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return ItemTransformerFunc(func(item map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+		v := reflect.New(typ).Interface()
+		if err := UnmarshalItem(item, v); err != nil {
+			return nil, fmt.Errorf("Can not unmarshal the item into %s, got %s", typ, err.Error())
+		}
+		values, err := fn(v)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]map[string]*dynamodb.AttributeValue, 0, len(values))
+		for _, out := range values {
+			it, err := MarshalItem(out)
+			if err != nil {
+				return nil, fmt.Errorf("Can not marshal %T into the item, got %s", out, err.Error())
+			}
+			items = append(items, it)
+		}
+		return items, nil
+	})
+}
@@ -32,8 +32,8 @@ func NewTruncator(client dynamodbiface.DynamoDBAPI) *Truncator {
 	return &Truncator{client: client}
 }
 
-func (t *Truncator) readMeta(table string) (*dynamodb.DescribeTableOutput, error) {
-	meta, err := t.client.DescribeTable(&dynamodb.DescribeTableInput{
+func readMeta(client dynamodbiface.DynamoDBAPI, table string) (*dynamodb.DescribeTableOutput, error) {
+	meta, err := client.DescribeTable(&dynamodb.DescribeTableInput{
 		TableName: aws.String(table),
 	})
 	if err != nil {
@@ -48,6 +48,14 @@ func (t *Truncator) readMeta(table string) (*dynamodb.DescribeTableOutput, error
 	return meta, nil
 }
 
+// sendError sends the error without blocking, only the first error is reported
+func sendError(errc chan<- error, err error) {
+	select {
+	case errc <- err:
+	default:
+	}
+}
+
 func (t *Truncator) delete(table string, scanned *dynamodb.ScanOutput) error {
 	errc := make(chan error, 1)
 	wg := sync.WaitGroup{}
@@ -62,22 +70,8 @@ func (t *Truncator) delete(table string, scanned *dynamodb.ScanOutput) error {
 		if (i+1)%deleteChunk == 0 || i >= int(*scanned.Count)-1 {
 			go func(reqChunk []*dynamodb.WriteRequest) {
 				defer wg.Done()
-				unprocessed := map[string][]*dynamodb.WriteRequest{
-					table: reqChunk,
-				}
-				attempts := 0
-				for len(unprocessed[table]) > 0 {
-					if attempts > 0 {
-						time.Sleep(retryer.RetryBackoff(attempts))
-					}
-					output, err := t.client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
-						RequestItems: unprocessed,
-					})
-					if err != nil {
-						errc <- err
-					}
-					unprocessed = output.UnprocessedItems
-					attempts++
+				if err := writeBatch(t.client, table, reqChunk); err != nil {
+					sendError(errc, err)
 				}
 			}(req)
 			req = []*dynamodb.WriteRequest{}
@@ -91,7 +85,7 @@ func (t *Truncator) delete(table string, scanned *dynamodb.ScanOutput) error {
 }
 
 func (t *Truncator) truncate(table string) error {
-	meta, err := t.readMeta(table)
+	meta, err := readMeta(t.client, table)
 	if err != nil {
 		return err
 	}
@@ -131,10 +125,17 @@ func (t *Truncator) truncate(table string) error {
 					TotalSegments:     aws.Int64(totalSegments),
 				})
 				if err != nil {
-					errc <- err
+					attempts++
+					if retryer.IsRetryable(err) && attempts < retryer.MaxAttempts {
+						continue
+					}
+					sendError(errc, err)
+					return
 				}
+				attempts = 0
 				if err = t.delete(table, scanned); err != nil {
-					errc <- err
+					sendError(errc, err)
+					return
 				}
 				startKey = scanned.LastEvaluatedKey
 				if len(startKey) == 0 {
@@ -153,7 +154,7 @@ func (t *Truncator) truncate(table string) error {
 }
 
 func (t *Truncator) recreate(table string) error {
-	meta, err := t.readMeta(table)
+	meta, err := readMeta(t.client, table)
 	if err != nil {
 		return err
 	}
@@ -178,9 +179,11 @@ func (t *Truncator) recreate(table string) error {
 		KeySchema:            meta.Table.KeySchema,
 		TableName:            meta.Table.TableName,
 	}
+	onDemand := meta.Table.BillingModeSummary != nil && *meta.Table.BillingModeSummary.BillingMode == dynamodb.BillingModePayPerRequest
 	if meta.Table.BillingModeSummary != nil {
 		input.SetBillingMode(*meta.Table.BillingModeSummary.BillingMode)
-	} else {
+	}
+	if !onDemand {
 		input.SetProvisionedThroughput(&dynamodb.ProvisionedThroughput{
 			ReadCapacityUnits:  meta.Table.ProvisionedThroughput.ReadCapacityUnits,
 			WriteCapacityUnits: meta.Table.ProvisionedThroughput.WriteCapacityUnits,
@@ -191,15 +194,18 @@ func (t *Truncator) recreate(table string) error {
 	}
 	globalSecondaryIndexes := []*dynamodb.GlobalSecondaryIndex{}
 	for _, v := range meta.Table.GlobalSecondaryIndexes {
-		globalSecondaryIndexes = append(globalSecondaryIndexes, &dynamodb.GlobalSecondaryIndex{
+		gsi := &dynamodb.GlobalSecondaryIndex{
 			IndexName:  v.IndexName,
 			KeySchema:  v.KeySchema,
 			Projection: v.Projection,
-			ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+		}
+		if !onDemand {
+			gsi.SetProvisionedThroughput(&dynamodb.ProvisionedThroughput{
 				ReadCapacityUnits:  v.ProvisionedThroughput.ReadCapacityUnits,
 				WriteCapacityUnits: v.ProvisionedThroughput.WriteCapacityUnits,
-			},
-		})
+			})
+		}
+		globalSecondaryIndexes = append(globalSecondaryIndexes, gsi)
 	}
 	if len(globalSecondaryIndexes) > 0 {
 		input.SetGlobalSecondaryIndexes(globalSecondaryIndexes)
@@ -212,7 +218,7 @@ func (t *Truncator) recreate(table string) error {
 			Projection: v.Projection,
 		})
 	}
-	if len(globalSecondaryIndexes) > 0 {
+	if len(localSecondaryIndexes) > 0 {
 		input.SetLocalSecondaryIndexes(localSecondaryIndexes)
 	}
 
@@ -234,6 +240,7 @@ func (t *Truncator) recreate(table string) error {
 // Truncate truncates the dynamodb tables
 func (t *Truncator) Truncate(tables []string, willRecreate bool) []error {
 	errs := make([]error, 0)
+	mutex := sync.Mutex{}
 	wg := sync.WaitGroup{}
 	for _, table := range tables {
 		wg.Add(1)
@@ -246,7 +253,9 @@ func (t *Truncator) Truncate(tables []string, willRecreate bool) []error {
 				err = t.truncate(table)
 			}
 			if err != nil {
+				mutex.Lock()
 				errs = append(errs, err)
+				mutex.Unlock()
 			}
 		}(table)
 	}
@@ -165,3 +165,128 @@ func TestTruncateWithRecreate(t *testing.T) {
 		t.Errorf("Creation datetime of the recreated table should be after old one\n")
 	}
 }
+
+func TestTruncateWithRecreateIndexes(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	truncator := NewTruncator(client)
+
+	// Create a table with the secondary indexes
+	name := "order"
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("user_id"), AttributeType: aws.String("N")},
+			{AttributeName: aws.String("order_id"), AttributeType: aws.String("N")},
+			{AttributeName: aws.String("status"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("created_at"), AttributeType: aws.String("N")},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("user_id"), KeyType: aws.String("HASH")},
+			{AttributeName: aws.String("order_id"), KeyType: aws.String("RANGE")},
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String("status-index"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("status"), KeyType: aws.String("HASH")},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("KEYS_ONLY")},
+			},
+		},
+		LocalSecondaryIndexes: []*dynamodb.LocalSecondaryIndex{
+			{
+				IndexName: aws.String("created-index"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("user_id"), KeyType: aws.String("HASH")},
+					{AttributeName: aws.String("created_at"), KeyType: aws.String("RANGE")},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			},
+		},
+		TableName: aws.String(name),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(name),
+		Item: map[string]*dynamodb.AttributeValue{
+			"user_id":    {N: aws.String("1")},
+			"order_id":   {N: aws.String("1")},
+			"status":     {S: aws.String("paid")},
+			"created_at": {N: aws.String("1577836800")},
+		},
+	})
+
+	// Truncate with recreate option
+	if errs := truncator.Truncate([]string{name}, true); len(errs) > 0 {
+		for _, err := range errs {
+			t.Errorf("There should be no errors, Got %s\n", err.Error())
+		}
+	}
+
+	// Check the indexes are recreated and empty
+	desc, err := client.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(name),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if len(desc.Table.GlobalSecondaryIndexes) != 1 || *desc.Table.GlobalSecondaryIndexes[0].ItemCount > 0 {
+		t.Errorf("The global secondary index should be recreated, got %v\n", desc.Table.GlobalSecondaryIndexes)
+	}
+	if len(desc.Table.LocalSecondaryIndexes) != 1 || *desc.Table.LocalSecondaryIndexes[0].ItemCount > 0 {
+		t.Errorf("The local secondary index should be recreated, got %v\n", desc.Table.LocalSecondaryIndexes)
+	}
+}
+
+func TestTruncateWithFaults(t *testing.T) {
+	client := mock.NewDynamoDBClient()
+	truncator := NewTruncator(client)
+
+	// Create a table
+	name := "user"
+	client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: aws.String("N")},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: aws.String("HASH")},
+		},
+		TableName: aws.String(name),
+	})
+	items := []map[string]*dynamodb.AttributeValue{}
+	for i := 0; i < 100; i++ {
+		items = append(items, map[string]*dynamodb.AttributeValue{
+			"id": {N: aws.String(strconv.Itoa(i + 1))},
+		})
+	}
+	if err := putItems(client, name, items); err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+
+	// Truncate while the requests are throttled or partially processed
+	client.SetFaults(&mock.Faults{
+		Seed:            1,
+		ThrottleRates:   map[string]float64{"Scan": 0.2, "BatchWriteItem": 0.2},
+		UnprocessedRate: 0.1,
+	})
+	if errs := truncator.Truncate([]string{name}, false); len(errs) > 0 {
+		for _, err := range errs {
+			t.Errorf("There should be no errors, Got %s\n", err.Error())
+		}
+	}
+	client.SetFaults(nil)
+
+	// Check the number of items is zero
+	desc, err := client.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(name),
+	})
+	if err != nil {
+		t.Fatalf("There should be no errors, Got %s\n", err.Error())
+	}
+	if *desc.Table.ItemCount > 0 {
+		t.Errorf("There should be no items, %d items is remaining\n", *desc.Table.ItemCount)
+	}
+}
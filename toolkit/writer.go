@@ -0,0 +1,55 @@
+package toolkit
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/mingrammer/dynamodb-toolkit/retryer"
+)
+
+const writeChunk = 25
+
+// writeBatch writes the requests to the table, retrying the unprocessed items and the retryable errors with backoff
+func writeBatch(client dynamodbiface.DynamoDBAPI, table string, reqs []*dynamodb.WriteRequest) error {
+	unprocessed := map[string][]*dynamodb.WriteRequest{
+		table: reqs,
+	}
+	attempts := 0
+	for len(unprocessed[table]) > 0 {
+		if attempts > 0 {
+			time.Sleep(retryer.RetryBackoff(attempts))
+		}
+		output, err := client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+			RequestItems: unprocessed,
+		})
+		attempts++
+		if err != nil {
+			if retryer.IsRetryable(err) && attempts < retryer.MaxAttempts {
+				continue
+			}
+			return err
+		}
+		unprocessed = output.UnprocessedItems
+	}
+	return nil
+}
+
+// putItems writes the items to the table in chunks of 25 items
+func putItems(client dynamodbiface.DynamoDBAPI, table string, items []map[string]*dynamodb.AttributeValue) error {
+	req := []*dynamodb.WriteRequest{}
+	for i, it := range items {
+		req = append(req, &dynamodb.WriteRequest{
+			PutRequest: &dynamodb.PutRequest{
+				Item: it,
+			},
+		})
+		if len(req) == writeChunk || i == len(items)-1 {
+			if err := writeBatch(client, table, req); err != nil {
+				return err
+			}
+			req = []*dynamodb.WriteRequest{}
+		}
+	}
+	return nil
+}